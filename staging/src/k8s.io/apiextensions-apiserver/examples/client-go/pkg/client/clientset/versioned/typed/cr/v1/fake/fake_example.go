@@ -105,7 +105,7 @@ func (c *FakeExamples) Update(ctx context.Context, example *crv1.Example, opts v
 // Delete takes name of the example and deletes it. Returns an error if one occurs.
 func (c *FakeExamples) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(examplesResource, c.ns, name), &crv1.Example{})
+		Invokes(testing.NewDeleteActionWithOptions(examplesResource, c.ns, name, opts), &crv1.Example{})
 
 	return err
 }
@@ -121,7 +121,7 @@ func (c *FakeExamples) DeleteCollection(ctx context.Context, opts v1.DeleteOptio
 // Patch applies the patch and returns the patched example.
 func (c *FakeExamples) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *crv1.Example, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(examplesResource, c.ns, name, pt, data, subresources...), &crv1.Example{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(examplesResource, c.ns, name, pt, data, opts, subresources...), &crv1.Example{})
 
 	if obj == nil {
 		return nil, err
@@ -29,6 +29,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	cache "k8s.io/client-go/tools/cache"
 )
 
@@ -42,11 +43,19 @@ type sharedInformerFactory struct {
 	lock             sync.Mutex
 	defaultResync    time.Duration
 	customResync     map[reflect.Type]time.Duration
+	transform        cache.TransformFunc
+	incrementalList  bool
 
 	informers map[reflect.Type]cache.SharedIndexInformer
 	// startedInformers is used for tracking which informers have been started.
 	// This allows Start() to be called multiple times safely.
 	startedInformers map[reflect.Type]bool
+	// informerStoppers holds, for every started informer, a function that
+	// stops just that informer and waits for it to exit.
+	informerStoppers map[reflect.Type]func()
+	// shuttingDown is true when Shutdown has been called. Start is a no-op
+	// from then on.
+	shuttingDown bool
 }
 
 // WithCustomResyncConfig sets a custom resync period for the specified informer types.
@@ -75,6 +84,24 @@ func WithNamespace(namespace string) SharedInformerOption {
 	}
 }
 
+// WithTransform sets a transform on all informers of the configured SharedInformerFactory.
+// The transform is run on every object before it is stored in an informer's cache.
+func WithTransform(transform cache.TransformFunc) SharedInformerOption {
+	return func(factory *sharedInformerFactory) *sharedInformerFactory {
+		factory.transform = transform
+		return factory
+	}
+}
+
+// WithIncrementalList makes all informers of the configured SharedInformerFactory apply paginated lists
+// to their caches page by page. See SharedIndexInformer.SetIncrementalList for what this trades off.
+func WithIncrementalList() SharedInformerOption {
+	return func(factory *sharedInformerFactory) *sharedInformerFactory {
+		factory.incrementalList = true
+		return factory
+	}
+}
+
 // NewSharedInformerFactory constructs a new instance of sharedInformerFactory for all namespaces.
 func NewSharedInformerFactory(client versioned.Interface, defaultResync time.Duration) SharedInformerFactory {
 	return NewSharedInformerFactoryWithOptions(client, defaultResync)
@@ -96,6 +123,7 @@ func NewSharedInformerFactoryWithOptions(client versioned.Interface, defaultResy
 		defaultResync:    defaultResync,
 		informers:        make(map[reflect.Type]cache.SharedIndexInformer),
 		startedInformers: make(map[reflect.Type]bool),
+		informerStoppers: make(map[reflect.Type]func()),
 		customResync:     make(map[reflect.Type]time.Duration),
 	}
 
@@ -112,14 +140,115 @@ func (f *sharedInformerFactory) Start(stopCh <-chan struct{}) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
+	if f.shuttingDown {
+		return
+	}
+
 	for informerType, informer := range f.informers {
 		if !f.startedInformers[informerType] {
-			go informer.Run(stopCh)
+			f.informerStoppers[informerType] = runInformer(informer, stopCh)
 			f.startedInformers[informerType] = true
 		}
 	}
 }
 
+// runInformer runs informer until either stopCh or the informer's own stop
+// channel is closed. It returns a function that closes the latter and waits
+// for the informer to exit.
+func runInformer(informer cache.SharedIndexInformer, stopCh <-chan struct{}) func() {
+	informerStopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runStopCh := make(chan struct{})
+		go func() {
+			defer close(runStopCh)
+			select {
+			case <-stopCh:
+			case <-informerStopCh:
+			}
+		}()
+		informer.Run(runStopCh)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(informerStopCh) })
+		<-done
+	}
+}
+
+// ShutdownInformer stops the informer for the given resource, if one was
+// requested, waits for it to exit and forgets it, so that a later ForResource
+// call followed by Start runs a fresh informer. The other informers keep
+// running.
+func (f *sharedInformerFactory) ShutdownInformer(resource schema.GroupVersionResource) error {
+	informerType, err := f.informerTypeFor(resource)
+	if err != nil {
+		return err
+	}
+
+	stop := func() func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		if _, exists := f.informers[informerType]; !exists {
+			return nil
+		}
+		stop := f.informerStoppers[informerType]
+		delete(f.informers, informerType)
+		delete(f.startedInformers, informerType)
+		delete(f.informerStoppers, informerType)
+		return stop
+	}()
+
+	if stop != nil {
+		stop()
+	}
+	return nil
+}
+
+// informerTypeFor returns the key of the informer for resource in f.informers.
+// It resolves the resource against a scratch factory, so that no informer is
+// registered in f.
+func (f *sharedInformerFactory) informerTypeFor(resource schema.GroupVersionResource) (reflect.Type, error) {
+	scratch := &sharedInformerFactory{
+		client:           f.client,
+		namespace:        f.namespace,
+		tweakListOptions: f.tweakListOptions,
+		informers:        make(map[reflect.Type]cache.SharedIndexInformer),
+		customResync:     make(map[reflect.Type]time.Duration),
+	}
+	if _, err := scratch.ForResource(resource); err != nil {
+		return nil, err
+	}
+	var informerType reflect.Type
+	for t := range scratch.informers {
+		informerType = t
+	}
+	return informerType, nil
+}
+
+// Shutdown stops all informers that have been started and waits for them to
+// exit. Start is a no-op once Shutdown has been called.
+func (f *sharedInformerFactory) Shutdown() {
+	stoppers := func() []func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		f.shuttingDown = true
+		stoppers := make([]func(), 0, len(f.informerStoppers))
+		for _, stop := range f.informerStoppers {
+			stoppers = append(stoppers, stop)
+		}
+		return stoppers
+	}()
+
+	for _, stop := range stoppers {
+		stop()
+	}
+}
+
 // WaitForCacheSync waits for all started informers' cache were synced.
 func (f *sharedInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool {
 	informers := func() map[reflect.Type]cache.SharedIndexInformer {
@@ -160,6 +289,16 @@ func (f *sharedInformerFactory) InformerFor(obj runtime.Object, newFunc internal
 	}
 
 	informer = newFunc(f.client, resyncPeriod)
+	if f.transform != nil {
+		if err := informer.SetTransform(f.transform); err != nil {
+			utilruntime.HandleError(err)
+		}
+	}
+	if f.incrementalList {
+		if err := informer.SetIncrementalList(true); err != nil {
+			utilruntime.HandleError(err)
+		}
+	}
 	f.informers[informerType] = informer
 
 	return informer
@@ -171,6 +310,8 @@ type SharedInformerFactory interface {
 	internalinterfaces.SharedInformerFactory
 	ForResource(resource schema.GroupVersionResource) (GenericInformer, error)
 	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+	ShutdownInformer(resource schema.GroupVersionResource) error
+	Shutdown()
 
 	Cr() cr.Interface
 }
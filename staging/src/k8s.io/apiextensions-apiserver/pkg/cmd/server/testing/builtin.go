@@ -0,0 +1,244 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"context"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/registry/generic"
+	genericregistry "k8s.io/apiserver/pkg/registry/generic/registry"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/apiserver/pkg/storage/names"
+)
+
+var (
+	// builtinScheme holds the built-in types served by the in-memory test
+	// server. There are no internal types: the external ones are registered
+	// as internal too, which makes every conversion a no-op.
+	builtinScheme = runtime.NewScheme()
+	builtinCodecs = serializer.NewCodecFactory(builtinScheme)
+)
+
+func init() {
+	utilruntime.Must(corev1.AddToScheme(builtinScheme))
+	utilruntime.Must(coordinationv1.AddToScheme(builtinScheme))
+	utilruntime.Must(builtinScheme.SetVersionPriority(corev1.SchemeGroupVersion))
+	utilruntime.Must(builtinScheme.SetVersionPriority(coordinationv1.SchemeGroupVersion))
+	for _, resource := range builtinResources {
+		internal := schema.GroupVersion{Group: resource.groupVersion.Group, Version: runtime.APIVersionInternal}
+		builtinScheme.AddKnownTypeWithName(internal.WithKind(resource.kind), resource.newFunc())
+		builtinScheme.AddKnownTypeWithName(internal.WithKind(resource.kind+"List"), resource.newListFunc())
+	}
+
+	metav1.AddToGroupVersion(builtinScheme, schema.GroupVersion{Group: "", Version: "v1"})
+	builtinScheme.AddUnversionedTypes(schema.GroupVersion{Group: "", Version: "v1"},
+		&metav1.Status{},
+		&metav1.APIVersions{},
+		&metav1.APIGroupList{},
+		&metav1.APIGroup{},
+		&metav1.APIResourceList{},
+	)
+}
+
+// builtinResource is a built-in resource served by the in-memory test server.
+type builtinResource struct {
+	groupVersion schema.GroupVersion
+	resource     string
+	kind         string
+	namespaced   bool
+	newFunc      func() runtime.Object
+	newListFunc  func() runtime.Object
+}
+
+// builtinResources is the subset of the kube-apiserver resources served by
+// the in-memory test server. Pods have a status subresource.
+var builtinResources = []builtinResource{
+	{corev1.SchemeGroupVersion, "namespaces", "Namespace", false,
+		func() runtime.Object { return &corev1.Namespace{} }, func() runtime.Object { return &corev1.NamespaceList{} }},
+	{corev1.SchemeGroupVersion, "configmaps", "ConfigMap", true,
+		func() runtime.Object { return &corev1.ConfigMap{} }, func() runtime.Object { return &corev1.ConfigMapList{} }},
+	{corev1.SchemeGroupVersion, "secrets", "Secret", true,
+		func() runtime.Object { return &corev1.Secret{} }, func() runtime.Object { return &corev1.SecretList{} }},
+	{corev1.SchemeGroupVersion, "pods", "Pod", true,
+		func() runtime.Object { return &corev1.Pod{} }, func() runtime.Object { return &corev1.PodList{} }},
+	{coordinationv1.SchemeGroupVersion, "leases", "Lease", true,
+		func() runtime.Object { return &coordinationv1.Lease{} }, func() runtime.Object { return &coordinationv1.LeaseList{} }},
+}
+
+// installBuiltinAPIs serves the builtinResources from server, storing them
+// with optsGetter.
+func installBuiltinAPIs(server *genericapiserver.GenericAPIServer, optsGetter generic.RESTOptionsGetter) error {
+	groups := map[string]*genericapiserver.APIGroupInfo{}
+	for _, resource := range builtinResources {
+		group, ok := groups[resource.groupVersion.Group]
+		if !ok {
+			info := genericapiserver.NewDefaultAPIGroupInfo(resource.groupVersion.Group, builtinScheme, runtime.NewParameterCodec(builtinScheme), builtinCodecs)
+			group = &info
+			groups[resource.groupVersion.Group] = group
+		}
+		storage := group.VersionedResourcesStorageMap[resource.groupVersion.Version]
+		if storage == nil {
+			storage = map[string]rest.Storage{}
+			group.VersionedResourcesStorageMap[resource.groupVersion.Version] = storage
+		}
+
+		store, err := newBuiltinStore(resource, optsGetter)
+		if err != nil {
+			return err
+		}
+		storage[resource.resource] = store
+		if resource.resource == "pods" {
+			statusStore := *store
+			statusStore.UpdateStrategy = builtinStrategy{builtinScheme, names.SimpleNameGenerator, resource.namespaced, true}
+			storage["pods/status"] = &statusREST{store: &statusStore}
+		}
+	}
+
+	if err := server.InstallLegacyAPIGroup(genericapiserver.DefaultLegacyAPIPrefix, groups[corev1.GroupName]); err != nil {
+		return err
+	}
+	return server.InstallAPIGroup(groups[coordinationv1.GroupName])
+}
+
+func newBuiltinStore(resource builtinResource, optsGetter generic.RESTOptionsGetter) (*genericregistry.Store, error) {
+	strategy := builtinStrategy{builtinScheme, names.SimpleNameGenerator, resource.namespaced, false}
+	qualifiedResource := resource.groupVersion.WithResource(resource.resource).GroupResource()
+	store := &genericregistry.Store{
+		NewFunc:                  resource.newFunc,
+		NewListFunc:              resource.newListFunc,
+		DefaultQualifiedResource: qualifiedResource,
+
+		CreateStrategy: strategy,
+		UpdateStrategy: strategy,
+		DeleteStrategy: strategy,
+
+		TableConvertor: rest.NewDefaultTableConvertor(qualifiedResource),
+	}
+	attrFunc := storage.DefaultClusterScopedAttr
+	if resource.namespaced {
+		attrFunc = storage.DefaultNamespaceScopedAttr
+	}
+	options := &generic.StoreOptions{RESTOptions: optsGetter, AttrFunc: attrFunc}
+	if err := store.CompleteWithOptions(options); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// builtinStrategy only validates the metadata of built-in objects. The
+// status of pods can only be changed through their status subresource.
+type builtinStrategy struct {
+	runtime.ObjectTyper
+	names.NameGenerator
+
+	namespaced bool
+	// status is set for the strategy of the status subresource.
+	status bool
+}
+
+func (s builtinStrategy) NamespaceScoped() bool {
+	return s.namespaced
+}
+
+func (builtinStrategy) PrepareForCreate(ctx context.Context, obj runtime.Object) {
+	if pod, ok := obj.(*corev1.Pod); ok {
+		pod.Status = corev1.PodStatus{Phase: corev1.PodPending}
+	}
+}
+
+func (s builtinStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	oldPod := old.(*corev1.Pod)
+	if s.status {
+		pod.Spec = oldPod.Spec
+	} else {
+		pod.Status = oldPod.Status
+	}
+}
+
+func (s builtinStrategy) Validate(ctx context.Context, obj runtime.Object) field.ErrorList {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return field.ErrorList{field.InternalError(field.NewPath("metadata"), err)}
+	}
+	nameFn := validation.NameIsDNSSubdomain
+	if _, ok := obj.(*corev1.Namespace); ok {
+		nameFn = validation.NameIsDNSLabel
+	}
+	return validation.ValidateObjectMetaAccessor(accessor, s.namespaced, nameFn, field.NewPath("metadata"))
+}
+
+func (builtinStrategy) AllowCreateOnUpdate() bool {
+	return false
+}
+
+func (builtinStrategy) AllowUnconditionalUpdate() bool {
+	return true
+}
+
+func (builtinStrategy) Canonicalize(obj runtime.Object) {
+}
+
+func (builtinStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object) field.ErrorList {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return field.ErrorList{field.InternalError(field.NewPath("metadata"), err)}
+	}
+	oldAccessor, err := meta.Accessor(old)
+	if err != nil {
+		return field.ErrorList{field.InternalError(field.NewPath("metadata"), err)}
+	}
+	return validation.ValidateObjectMetaAccessorUpdate(accessor, oldAccessor, field.NewPath("metadata"))
+}
+
+// statusREST implements the REST endpoint for changing the status of a pod.
+type statusREST struct {
+	store *genericregistry.Store
+}
+
+var _ = rest.Patcher(&statusREST{})
+
+func (r *statusREST) New() runtime.Object {
+	return &corev1.Pod{}
+}
+
+// Get retrieves the object from the storage. It is required to support Patch.
+func (r *statusREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	return r.store.Get(ctx, name, options)
+}
+
+// Update alters the status subset of an object.
+func (r *statusREST) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	// We are explicitly setting forceAllowCreate to false in the call to the underlying storage because
+	// subresources should never allow create on update.
+	return r.store.Update(ctx, name, objInfo, createValidation, updateValidation, false, options)
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/pflag"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver"
+	"k8s.io/apiextensions-apiserver/pkg/cmd/server/options"
+	"k8s.io/apiextensions-apiserver/pkg/cmd/server/testing/openapi"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	openapinamer "k8s.io/apiserver/pkg/endpoints/openapi"
+	"k8s.io/apiserver/pkg/registry/generic"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/apiserver/pkg/storage/memory"
+	"k8s.io/apiserver/pkg/storage/storagebackend"
+	"k8s.io/apiserver/pkg/util/webhook"
+)
+
+// inMemoryStoragePrefix is the key prefix of the objects in the in-memory
+// storage, like the default etcd prefix of the apiextensions-apiserver.
+const inMemoryStoragePrefix = "/registry/apiextensions.kubernetes.io"
+
+// inMemoryRESTOptionsGetter returns REST options storing every resource in
+// the same in-memory backend.
+type inMemoryRESTOptionsGetter struct {
+	backend *memory.Backend
+	codec   runtime.Codec
+}
+
+func (g inMemoryRESTOptionsGetter) GetRESTOptions(resource schema.GroupResource) (generic.RESTOptions, error) {
+	return generic.RESTOptions{
+		StorageConfig: &storagebackend.Config{
+			Prefix: inMemoryStoragePrefix,
+			Paging: true,
+			Codec:  g.codec,
+		},
+		Decorator:               g.backend.Decorator,
+		EnableGarbageCollection: false,
+		DeleteCollectionWorkers: 1,
+		ResourcePrefix:          resource.Group + "/" + resource.Resource,
+	}, nil
+}
+
+// StartInMemoryTestServer starts an apiextensions-apiserver storing its objects in
+// memory instead of etcd, so that tests need no external binaries. It serves
+// CustomResourceDefinitions and custom resources, including watches and server-side
+// apply, over real HTTPS. A rest client config and a tear-down func are returned.
+//
+// Of the built-in types, namespaces, configmaps, secrets, pods and leases are
+// served too, with server-side apply. Only their metadata is validated, and there are
+// no controllers acting on them, e.g. deleting a namespace doesn't delete its content.
+//
+// The storage keeps only the latest version of every object. Lists at an exact
+// resourceVersion other than the current one fail with an Expired error, and TTLs
+// are ignored.
+func StartInMemoryTestServer(t Logger, customFlags []string) (result TestServer, err error) {
+	stopCh := make(chan struct{})
+	tearDown := func() {
+		close(stopCh)
+		if len(result.TmpDir) != 0 {
+			os.RemoveAll(result.TmpDir)
+		}
+	}
+	defer func() {
+		if result.TearDownFn == nil {
+			tearDown()
+		}
+	}()
+
+	result.TmpDir, err = ioutil.TempDir("", "apiextensions-apiserver")
+	if err != nil {
+		return result, fmt.Errorf("failed to create temp dir: %v", err)
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.PanicOnError)
+
+	s := options.NewCustomResourceDefinitionsServerOptions(os.Stdout, os.Stderr)
+	s.AddFlags(fs)
+
+	s.RecommendedOptions.SecureServing.Listener, s.RecommendedOptions.SecureServing.BindPort, err = createLocalhostListenerOnFreePort()
+	if err != nil {
+		return result, fmt.Errorf("failed to create listener: %v", err)
+	}
+	s.RecommendedOptions.SecureServing.ServerCert.CertDirectory = result.TmpDir
+	s.RecommendedOptions.SecureServing.ExternalAddress = s.RecommendedOptions.SecureServing.Listener.Addr().(*net.TCPAddr).IP // use listener addr although it is a loopback device
+
+	pkgPath, err := pkgPath(t)
+	if err != nil {
+		return result, err
+	}
+	s.RecommendedOptions.SecureServing.ServerCert.FixtureDirectory = filepath.Join(pkgPath, "testdata")
+	s.APIEnablement.RuntimeConfig.Set("api/all=true")
+
+	fs.Parse(customFlags)
+
+	if err := s.Complete(); err != nil {
+		return result, fmt.Errorf("failed to set default options: %v", err)
+	}
+	// The storage is configured below. Without a kube-apiserver to delegate to, all requests
+	// are allowed and no admission plugins run.
+	s.RecommendedOptions.Etcd = nil
+	s.RecommendedOptions.Authentication = nil
+	s.RecommendedOptions.Authorization = nil
+	s.RecommendedOptions.CoreAPI = nil
+	s.RecommendedOptions.Admission = nil
+	errs := append(s.RecommendedOptions.SecureServing.Validate(), s.APIEnablement.Validate(apiserver.Scheme)...)
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return result, fmt.Errorf("failed to validate options: %v", err)
+	}
+
+	t.Logf("runtime-config=%v", s.APIEnablement.RuntimeConfig)
+	t.Logf("Starting in-memory apiextensions-apiserver on port %d...", s.RecommendedOptions.SecureServing.BindPort)
+
+	config, backend, err := inMemoryConfig(s)
+	if err != nil {
+		return result, fmt.Errorf("failed to create config from options: %v", err)
+	}
+	server, err := config.Complete().New(genericapiserver.NewEmptyDelegate())
+	if err != nil {
+		return result, fmt.Errorf("failed to create server: %v", err)
+	}
+	builtinRESTOptionsGetter := inMemoryRESTOptionsGetter{
+		backend: backend,
+		codec:   builtinCodecs.LegacyCodec(corev1.SchemeGroupVersion, coordinationv1.SchemeGroupVersion),
+	}
+	if err := installBuiltinAPIs(server.GenericAPIServer, builtinRESTOptionsGetter); err != nil {
+		return result, fmt.Errorf("failed to install built-in APIs: %v", err)
+	}
+	if err := runAndWaitForHealthz(t, server, stopCh); err != nil {
+		return result, err
+	}
+
+	// from here the caller must call tearDown
+	result.ClientConfig = server.GenericAPIServer.LoopbackClientConfig
+	result.ServerOpts = s
+	result.TearDownFn = tearDown
+
+	return result, nil
+}
+
+// StartInMemoryTestServerOrDie calls StartInMemoryTestServer t.Fatal if it does not succeed.
+func StartInMemoryTestServerOrDie(t Logger, flags []string) *TestServer {
+	result, err := StartInMemoryTestServer(t, flags)
+	if err == nil {
+		return &result
+	}
+
+	t.Fatalf("failed to launch server: %v", err)
+	return nil
+}
+
+// inMemoryConfig builds the server config like
+// CustomResourceDefinitionsServerOptions.Config, with the storage of all
+// resources in a new in-memory backend, which is returned too.
+func inMemoryConfig(s *options.CustomResourceDefinitionsServerOptions) (*apiserver.Config, *memory.Backend, error) {
+	if err := s.RecommendedOptions.SecureServing.MaybeDefaultWithSelfSignedCerts("localhost", nil, []net.IP{net.ParseIP("127.0.0.1")}); err != nil {
+		return nil, nil, fmt.Errorf("error creating self-signed certificates: %v", err)
+	}
+
+	serverConfig := genericapiserver.NewRecommendedConfig(apiserver.Codecs)
+	if err := s.RecommendedOptions.ApplyTo(serverConfig); err != nil {
+		return nil, nil, err
+	}
+	if err := s.APIEnablement.ApplyTo(&serverConfig.Config, apiserver.DefaultAPIResourceConfigSource(), apiserver.Scheme); err != nil {
+		return nil, nil, err
+	}
+	// The field managers of the built-in types are built from their OpenAPI
+	// definitions.
+	serverConfig.OpenAPIConfig = genericapiserver.DefaultOpenAPIConfig(openapi.GetOpenAPIDefinitions, openapinamer.NewDefinitionNamer(apiserver.Scheme, builtinScheme))
+
+	backend := memory.NewBackend()
+	serverConfig.RESTOptionsGetter = inMemoryRESTOptionsGetter{
+		backend: backend,
+		codec:   apiserver.Codecs.LegacyCodec(v1beta1.SchemeGroupVersion, v1.SchemeGroupVersion),
+	}
+	return &apiserver.Config{
+		GenericConfig: serverConfig,
+		ExtraConfig: apiserver.ExtraConfig{
+			CRDRESTOptionsGetter: inMemoryRESTOptionsGetter{
+				backend: backend,
+				codec:   unstructured.UnstructuredJSONScheme,
+			},
+			ServiceResolver:     webhook.NewDefaultServiceResolver(),
+			AuthResolverWrapper: webhook.NewDefaultAuthenticationInfoResolverWrapper(nil, nil, serverConfig.LoopbackClientConfig),
+		},
+	}, backend, nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	servertesting "k8s.io/apiextensions-apiserver/pkg/cmd/server/testing"
+	"k8s.io/apiextensions-apiserver/test/integration/fixtures"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+func TestInMemoryTestServer(t *testing.T) {
+	server := servertesting.StartInMemoryTestServerOrDie(t, nil)
+	defer server.TearDownFn()
+
+	apiExtensionsClient, err := clientset.NewForConfig(server.ClientConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(server.ClientConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crd := fixtures.NewNoxuV1CustomResourceDefinition(apiextensionsv1.NamespaceScoped)
+	crd, err = fixtures.CreateNewV1CustomResourceDefinition(crd, apiExtensionsClient, dynamicClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noxuClient := dynamicClient.Resource(schema.GroupVersionResource{Group: crd.Spec.Group, Version: "v1beta1", Resource: crd.Spec.Names.Plural}).Namespace("default")
+
+	list, err := noxuClient.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := noxuClient.Watch(context.TODO(), metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	created, err := noxuClient.Create(context.TODO(), fixtures.NewNoxuInstance("default", "foo"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-w.ResultChan():
+		if e.Type != watch.Added || e.Object.(*unstructured.Unstructured).GetName() != "foo" {
+			t.Errorf("expected foo to be added, got %v", e)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the watch event")
+	}
+
+	// The content was set on creation by another field manager.
+	force := true
+	patch := []byte(`{"apiVersion":"mygroup.example.com/v1beta1","kind":"WishIHadChosenNoxu","metadata":{"name":"foo"},"content":{"key":"applied"}}`)
+	applied, err := noxuClient.Patch(context.TODO(), "foo", types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: "test", Force: &force})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _, _ := unstructured.NestedString(applied.Object, "content", "key"); value != "applied" {
+		t.Errorf("expected the applied value, got %q", value)
+	}
+	if applied.GetResourceVersion() == created.GetResourceVersion() {
+		t.Errorf("expected a new resourceVersion, got %q", applied.GetResourceVersion())
+	}
+	managers := map[string]bool{}
+	for _, entry := range applied.GetManagedFields() {
+		managers[entry.Manager] = true
+	}
+	if !managers["test"] {
+		t.Errorf("expected fields managed by test, got %v", applied.GetManagedFields())
+	}
+}
+
+func TestInMemoryTestServerBuiltinTypes(t *testing.T) {
+	server := servertesting.StartInMemoryTestServerOrDie(t, nil)
+	defer server.TearDownFn()
+
+	client, err := kubernetes.NewForConfig(server.ClientConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	configMaps := client.CoreV1().ConfigMaps("test")
+	list, err := configMaps.List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := configMaps.Watch(context.TODO(), metav1.ListOptions{ResourceVersion: list.ResourceVersion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	if _, err := configMaps.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo"}, Data: map[string]string{"key": "value"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e := <-w.ResultChan():
+		if cm, ok := e.Object.(*corev1.ConfigMap); e.Type != watch.Added || !ok || cm.Data["key"] != "value" {
+			t.Errorf("expected foo to be added, got %v", e)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("timed out waiting for the watch event")
+	}
+	if _, err := configMaps.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "Invalid_Name"}}, metav1.CreateOptions{}); !apierrors.IsInvalid(err) {
+		t.Errorf("expected an invalid name to be rejected, got %v", err)
+	}
+
+	// The status of pods is only changed through the status subresource.
+	pods := client.CoreV1().Pods("test")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "test", Image: "test"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	pod, err = pods.Create(context.TODO(), pod, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Status.Phase != corev1.PodPending {
+		t.Errorf("expected a pending pod, got %q", pod.Status.Phase)
+	}
+	pod.Status.Phase = corev1.PodRunning
+	pod.Spec.Containers[0].Image = "other"
+	pod, err = pods.UpdateStatus(context.TODO(), pod, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Status.Phase != corev1.PodRunning || pod.Spec.Containers[0].Image != "test" {
+		t.Errorf("expected only the status to be updated, got phase %q and image %q", pod.Status.Phase, pod.Spec.Containers[0].Image)
+	}
+
+	holder := "test"
+	leases := client.CoordinationV1().Leases("test")
+	if _, err := leases.Create(context.TODO(), &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "foo"}, Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	lease, err := leases.Get(context.TODO(), "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder {
+		t.Errorf("expected the lease to be held by %q, got %v", holder, lease.Spec.HolderIdentity)
+	}
+	if err := leases.Delete(context.TODO(), "foo", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leases.Get(context.TODO(), "foo", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the lease to be deleted, got %v", err)
+	}
+}
+
+func TestInMemoryTestServerBuiltinTypesApply(t *testing.T) {
+	server := servertesting.StartInMemoryTestServerOrDie(t, nil)
+	defer server.TearDownFn()
+
+	client, err := kubernetes.NewForConfig(server.ClientConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Applying creates the config map.
+	configMaps := client.CoreV1().ConfigMaps("test")
+	patch := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"foo"},"data":{"key":"applied"}}`)
+	applied, err := configMaps.Patch(context.TODO(), "foo", types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Data["key"] != "applied" {
+		t.Errorf("expected the applied value, got %q", applied.Data["key"])
+	}
+	if len(applied.ManagedFields) != 1 || applied.ManagedFields[0].Manager != "test" || applied.ManagedFields[0].Operation != metav1.ManagedFieldsOperationApply {
+		t.Errorf("expected the fields to be applied by test, got %v", applied.ManagedFields)
+	}
+
+	// Another manager can only take the field over by force.
+	patch = []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"foo"},"data":{"key":"other"}}`)
+	if _, err := configMaps.Patch(context.TODO(), "foo", types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: "other"}); !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	force := true
+	applied, err = configMaps.Patch(context.TODO(), "foo", types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: "other", Force: &force})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied.Data["key"] != "other" {
+		t.Errorf("expected the forced value, got %q", applied.Data["key"])
+	}
+
+	// Fields of other managers are kept, e.g. the containers of a pod.
+	pods := client.CoreV1().Pods("test")
+	patch = []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"foo"},"spec":{"containers":[{"name":"a","image":"a"}]}}`)
+	if _, err := pods.Patch(context.TODO(), "foo", types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	patch = []byte(`{"apiVersion":"v1","kind":"Pod","metadata":{"name":"foo"},"spec":{"containers":[{"name":"b","image":"b"}]}}`)
+	pod, err := pods.Patch(context.TODO(), "foo", types.ApplyPatchType, patch, metav1.PatchOptions{FieldManager: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pod.Spec.Containers) != 2 {
+		t.Errorf("expected the containers to be merged by name, got %v", pod.Spec.Containers)
+	}
+}
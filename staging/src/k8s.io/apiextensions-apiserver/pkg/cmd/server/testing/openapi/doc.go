@@ -0,0 +1,26 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package openapi holds the generated definitions of the types served by the
+// in-memory test server, the built-in ones included, so that server-side
+// apply works for all of them. It is generated by running openapi-gen with
+// --input-dirs k8s.io/api/core/v1,k8s.io/api/coordination/v1,
+// k8s.io/apimachinery/pkg/apis/meta/v1,k8s.io/apimachinery/pkg/runtime,
+// k8s.io/apimachinery/pkg/version,k8s.io/apimachinery/pkg/util/intstr,
+// k8s.io/apimachinery/pkg/api/resource,
+// k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1 and
+// k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1.
+package openapi
@@ -28,6 +28,7 @@ import (
 
 	"github.com/spf13/pflag"
 
+	"k8s.io/apiextensions-apiserver/pkg/apiserver"
 	"k8s.io/apiextensions-apiserver/pkg/cmd/server/options"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/registry/generic/registry"
@@ -151,6 +152,32 @@ func StartTestServer(t Logger, instanceOptions *TestServerInstanceOptions, custo
 		return result, fmt.Errorf("failed to create server: %v", err)
 	}
 
+	if err := runAndWaitForHealthz(t, server, stopCh); err != nil {
+		return result, err
+	}
+
+	// from here the caller must call tearDown
+	result.ClientConfig = server.GenericAPIServer.LoopbackClientConfig
+	result.ServerOpts = s
+	result.TearDownFn = tearDown
+
+	return result, nil
+}
+
+// StartTestServerOrDie calls StartTestServer t.Fatal if it does not succeed.
+func StartTestServerOrDie(t Logger, instanceOptions *TestServerInstanceOptions, flags []string, storageConfig *storagebackend.Config) *TestServer {
+	result, err := StartTestServer(t, instanceOptions, flags, storageConfig)
+	if err == nil {
+		return &result
+	}
+
+	t.Fatalf("failed to launch server: %v", err)
+	return nil
+}
+
+// runAndWaitForHealthz runs server until stopCh is closed, and waits for its
+// /healthz to be ok.
+func runAndWaitForHealthz(t Logger, server *apiserver.CustomResourceDefinitions, stopCh <-chan struct{}) error {
 	errCh := make(chan error)
 	go func(stopCh <-chan struct{}) {
 		if err := server.GenericAPIServer.PrepareRun().Run(stopCh); err != nil {
@@ -162,7 +189,7 @@ func StartTestServer(t Logger, instanceOptions *TestServerInstanceOptions, custo
 
 	client, err := kubernetes.NewForConfig(server.GenericAPIServer.LoopbackClientConfig)
 	if err != nil {
-		return result, fmt.Errorf("failed to create a client: %v", err)
+		return fmt.Errorf("failed to create a client: %v", err)
 	}
 	err = wait.Poll(100*time.Millisecond, time.Minute, func() (bool, error) {
 		select {
@@ -180,25 +207,8 @@ func StartTestServer(t Logger, instanceOptions *TestServerInstanceOptions, custo
 		return false, nil
 	})
 	if err != nil {
-		return result, fmt.Errorf("failed to wait for /healthz to return ok: %v", err)
+		return fmt.Errorf("failed to wait for /healthz to return ok: %v", err)
 	}
-
-	// from here the caller must call tearDown
-	result.ClientConfig = server.GenericAPIServer.LoopbackClientConfig
-	result.ServerOpts = s
-	result.TearDownFn = tearDown
-
-	return result, nil
-}
-
-// StartTestServerOrDie calls StartTestServer t.Fatal if it does not succeed.
-func StartTestServerOrDie(t Logger, instanceOptions *TestServerInstanceOptions, flags []string, storageConfig *storagebackend.Config) *TestServer {
-	result, err := StartTestServer(t, instanceOptions, flags, storageConfig)
-	if err == nil {
-		return &result
-	}
-
-	t.Fatalf("failed to launch server: %v", err)
 	return nil
 }
 
@@ -0,0 +1,659 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/features"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/apiserver/pkg/storage/etcd3"
+	"k8s.io/apiserver/pkg/storage/storagebackend"
+	"k8s.io/apiserver/pkg/storage/storagebackend/factory"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// defaultHistorySize is the number of events a Backend keeps for watches
+// starting from an earlier resourceVersion.
+const defaultHistorySize = 10000
+
+// Backend is an in-memory key-value store standing in for etcd. Like etcd,
+// it has a single revision that is incremented by every write, and it keeps
+// the latest events for watches. It only keeps the latest value of every
+// key, so lists at an exact resourceVersion other than the current one fail.
+// TTLs are ignored.
+type Backend struct {
+	lock sync.RWMutex
+	// rev is the revision of the latest write.
+	rev int64
+	kvs map[string]*keyValue
+	// history holds the latest events, oldest first.
+	history []*event
+	// compactedRev is the revision of the latest event dropped from the
+	// history. Watches must start after it.
+	compactedRev int64
+	watchers     map[*watchChan]struct{}
+}
+
+type keyValue struct {
+	value  []byte
+	modRev int64
+}
+
+// NewBackend returns an empty Backend. Like a new etcd cluster, it starts at
+// revision 1, since resourceVersion 0 has a special meaning.
+func NewBackend() *Backend {
+	return &Backend{
+		rev:      1,
+		kvs:      map[string]*keyValue{},
+		watchers: map[*watchChan]struct{}{},
+	}
+}
+
+// Decorator returns a storage.Interface storing objects in the backend. It
+// has the signature of generic.StorageDecorator, so that generic registries
+// can be backed by b instead of etcd.
+func (b *Backend) Decorator(
+	config *storagebackend.Config,
+	resourcePrefix string,
+	keyFunc func(obj runtime.Object) (string, error),
+	newFunc func() runtime.Object,
+	newListFunc func() runtime.Object,
+	getAttrsFunc storage.AttrFunc,
+	trigger storage.IndexerFuncs,
+	indexers *cache.Indexers) (storage.Interface, factory.DestroyFunc, error) {
+	return New(b, config.Codec, config.Prefix, config.Paging), func() {}, nil
+}
+
+// putLocked stores value at key, records the event and sends it to the
+// watchers. It returns the revision of the write.
+func (b *Backend) putLocked(key string, value []byte) int64 {
+	b.rev++
+	e := &event{key: key, value: value, rev: b.rev}
+	if prev, ok := b.kvs[key]; ok {
+		e.prevValue = prev.value
+	} else {
+		e.isCreated = true
+	}
+	b.kvs[key] = &keyValue{value: value, modRev: b.rev}
+	b.notifyLocked(e)
+	return b.rev
+}
+
+// deleteLocked deletes key, records the event and sends it to the
+// watchers.
+func (b *Backend) deleteLocked(key string) {
+	b.rev++
+	e := &event{key: key, prevValue: b.kvs[key].value, rev: b.rev, isDeleted: true}
+	delete(b.kvs, key)
+	b.notifyLocked(e)
+}
+
+func (b *Backend) notifyLocked(e *event) {
+	b.history = append(b.history, e)
+	if len(b.history) > defaultHistorySize {
+		b.compactedRev = b.history[0].rev
+		b.history = b.history[1:]
+	}
+	for w := range b.watchers {
+		if w.matchesKey(e.key) {
+			w.enqueue(e, nil)
+		}
+	}
+}
+
+// compareAndPut stores value at key if the key was last modified at
+// revision expectedRev, or doesn't exist and expectedRev is 0.
+func (b *Backend) compareAndPut(key string, expectedRev int64, value []byte) (int64, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.modRevLocked(key) != expectedRev {
+		return 0, false
+	}
+	return b.putLocked(key, value), true
+}
+
+// compareAndDelete deletes key if it was last modified at revision
+// expectedRev.
+func (b *Backend) compareAndDelete(key string, expectedRev int64) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.modRevLocked(key) != expectedRev {
+		return false
+	}
+	b.deleteLocked(key)
+	return true
+}
+
+func (b *Backend) modRevLocked(key string) int64 {
+	if kv, ok := b.kvs[key]; ok {
+		return kv.modRev
+	}
+	return 0
+}
+
+// get returns the value at key, its modification revision and the current
+// revision. The value is nil if the key doesn't exist.
+func (b *Backend) get(key string) ([]byte, int64, int64) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if kv, ok := b.kvs[key]; ok {
+		return kv.value, kv.modRev, b.rev
+	}
+	return nil, 0, b.rev
+}
+
+// keysWithPrefix returns the sorted keys starting with prefix, and the
+// current revision.
+func (b *Backend) keysWithPrefix(prefix string) ([]string, int64) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	var keys []string
+	for key := range b.kvs {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, b.rev
+}
+
+type store struct {
+	backend       *Backend
+	codec         runtime.Codec
+	versioner     storage.Versioner
+	pathPrefix    string
+	pagingEnabled bool
+}
+
+type objState struct {
+	obj  runtime.Object
+	meta *storage.ResponseMeta
+	rev  int64
+	data []byte
+}
+
+// New returns an implementation of storage.Interface storing objects
+// encoded with codec in backend, under the given key prefix.
+func New(backend *Backend, codec runtime.Codec, prefix string, pagingEnabled bool) storage.Interface {
+	return &store{
+		backend:       backend,
+		codec:         codec,
+		versioner:     etcd3.APIObjectVersioner{},
+		pathPrefix:    path.Join("/", prefix),
+		pagingEnabled: pagingEnabled,
+	}
+}
+
+// Versioner implements storage.Interface.Versioner.
+func (s *store) Versioner() storage.Versioner {
+	return s.versioner
+}
+
+// Get implements storage.Interface.Get.
+func (s *store) Get(ctx context.Context, key string, opts storage.GetOptions, out runtime.Object) error {
+	key = path.Join(s.pathPrefix, key)
+	data, modRev, rev := s.backend.get(key)
+	if err := s.validateMinimumResourceVersion(opts.ResourceVersion, uint64(rev)); err != nil {
+		return err
+	}
+	if data == nil {
+		if opts.IgnoreNotFound {
+			return runtime.SetZeroValue(out)
+		}
+		return storage.NewKeyNotFoundError(key, 0)
+	}
+	return decode(s.codec, s.versioner, data, out, modRev)
+}
+
+// Create implements storage.Interface.Create.
+func (s *store) Create(ctx context.Context, key string, obj, out runtime.Object, ttl uint64) error {
+	if version, err := s.versioner.ObjectResourceVersion(obj); err == nil && version != 0 {
+		return errors.New("resourceVersion should not be set on objects to be created")
+	}
+	if err := s.versioner.PrepareObjectForStorage(obj); err != nil {
+		return fmt.Errorf("PrepareObjectForStorage failed: %v", err)
+	}
+	data, err := runtime.Encode(s.codec, obj)
+	if err != nil {
+		return err
+	}
+	key = path.Join(s.pathPrefix, key)
+
+	rev, ok := s.backend.compareAndPut(key, 0, data)
+	if !ok {
+		return storage.NewKeyExistsError(key, 0)
+	}
+	if out != nil {
+		return decode(s.codec, s.versioner, data, out, rev)
+	}
+	return nil
+}
+
+// Delete implements storage.Interface.Delete.
+func (s *store) Delete(ctx context.Context, key string, out runtime.Object, preconditions *storage.Preconditions, validateDeletion storage.ValidateObjectFunc) error {
+	v, err := conversion.EnforcePtr(out)
+	if err != nil {
+		return fmt.Errorf("unable to convert output object to pointer: %v", err)
+	}
+	key = path.Join(s.pathPrefix, key)
+	for {
+		origState, err := s.getState(key, v, false)
+		if err != nil {
+			return err
+		}
+		if preconditions != nil {
+			if err := preconditions.Check(key, origState.obj); err != nil {
+				return err
+			}
+		}
+		if err := validateDeletion(ctx, origState.obj); err != nil {
+			return err
+		}
+		if !s.backend.compareAndDelete(key, origState.rev) {
+			klog.V(4).Infof("deletion of %s failed because of a conflict, going to retry", key)
+			continue
+		}
+		return decode(s.codec, s.versioner, origState.data, out, origState.rev)
+	}
+}
+
+// GuaranteedUpdate implements storage.Interface.GuaranteedUpdate. The
+// suggestion is ignored, the update always starts from the stored object.
+func (s *store) GuaranteedUpdate(
+	ctx context.Context, key string, out runtime.Object, ignoreNotFound bool,
+	preconditions *storage.Preconditions, tryUpdate storage.UpdateFunc, suggestion ...runtime.Object) error {
+	v, err := conversion.EnforcePtr(out)
+	if err != nil {
+		return fmt.Errorf("unable to convert output object to pointer: %v", err)
+	}
+	key = path.Join(s.pathPrefix, key)
+
+	origState, err := s.getState(key, v, ignoreNotFound)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := preconditions.Check(key, origState.obj); err != nil {
+			return err
+		}
+
+		ret, _, err := tryUpdate(origState.obj, *origState.meta)
+		if err != nil {
+			return err
+		}
+		if err := s.versioner.PrepareObjectForStorage(ret); err != nil {
+			return fmt.Errorf("PrepareObjectForStorage failed: %v", err)
+		}
+		data, err := runtime.Encode(s.codec, ret)
+		if err != nil {
+			return err
+		}
+		if origState.data != nil && bytes.Equal(data, origState.data) {
+			return decode(s.codec, s.versioner, origState.data, out, origState.rev)
+		}
+
+		rev, ok := s.backend.compareAndPut(key, origState.rev, data)
+		if !ok {
+			klog.V(4).Infof("GuaranteedUpdate of %s failed because of a conflict, going to retry", key)
+			origState, err = s.getState(key, v, ignoreNotFound)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		return decode(s.codec, s.versioner, data, out, rev)
+	}
+}
+
+// GetToList implements storage.Interface.GetToList.
+func (s *store) GetToList(ctx context.Context, key string, listOpts storage.ListOptions, listObj runtime.Object) error {
+	pred := listOpts.Predicate
+	listPtr, err := meta.GetItemsPtr(listObj)
+	if err != nil {
+		return err
+	}
+	v, err := conversion.EnforcePtr(listPtr)
+	if err != nil || v.Kind() != reflect.Slice {
+		return fmt.Errorf("need ptr to slice: %v", err)
+	}
+	newItemFunc := getNewItemFunc(listObj, v)
+
+	key = path.Join(s.pathPrefix, key)
+	data, modRev, rev := s.backend.get(key)
+	if len(listOpts.ResourceVersion) > 0 && listOpts.ResourceVersionMatch == metav1.ResourceVersionMatchExact {
+		if err := s.validateExactResourceVersion(listOpts.ResourceVersion, rev); err != nil {
+			return err
+		}
+	}
+	if err := s.validateMinimumResourceVersion(listOpts.ResourceVersion, uint64(rev)); err != nil {
+		return err
+	}
+	if data != nil {
+		if err := appendListItem(v, data, uint64(modRev), pred, s.codec, s.versioner, newItemFunc); err != nil {
+			return err
+		}
+	}
+	return s.versioner.UpdateList(listObj, uint64(rev), "", nil)
+}
+
+// List implements storage.Interface.List.
+func (s *store) List(ctx context.Context, key string, opts storage.ListOptions, listObj runtime.Object) error {
+	resourceVersion := opts.ResourceVersion
+	match := opts.ResourceVersionMatch
+	pred := opts.Predicate
+	listPtr, err := meta.GetItemsPtr(listObj)
+	if err != nil {
+		return err
+	}
+	v, err := conversion.EnforcePtr(listPtr)
+	if err != nil || v.Kind() != reflect.Slice {
+		return fmt.Errorf("need ptr to slice: %v", err)
+	}
+	newItemFunc := getNewItemFunc(listObj, v)
+
+	key = path.Join(s.pathPrefix, key)
+	// We need to make sure the key ended with "/" so that we only get children "directories".
+	if !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	keyPrefix := key
+	keys, rev := s.backend.keysWithPrefix(keyPrefix)
+	paging := s.pagingEnabled && pred.Limit > 0
+
+	// returnedRV is the revision of the list. Continued lists keep the
+	// revision of their first page, so that watches starting from it
+	// don't miss changes to the objects of earlier pages.
+	returnedRV := rev
+	if s.pagingEnabled && len(pred.Continue) > 0 {
+		if len(resourceVersion) > 0 && resourceVersion != "0" {
+			return apierrors.NewBadRequest("specifying resource version is not allowed when using continue")
+		}
+		continueKey, continueRV, err := decodeContinue(pred.Continue, keyPrefix)
+		if err != nil {
+			return apierrors.NewBadRequest(fmt.Sprintf("invalid continue token: %v", err))
+		}
+		if continueRV > 0 {
+			returnedRV = continueRV
+		}
+		keys = keys[sort.SearchStrings(keys, continueKey):]
+	} else if len(resourceVersion) > 0 {
+		switch match {
+		case metav1.ResourceVersionMatchNotOlderThan:
+		case metav1.ResourceVersionMatchExact:
+			if err := s.validateExactResourceVersion(resourceVersion, rev); err != nil {
+				return err
+			}
+		case "": // legacy case
+			if paging && resourceVersion != "0" {
+				if err := s.validateExactResourceVersion(resourceVersion, rev); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("unknown ResourceVersionMatch value: %v", match)
+		}
+		if err := s.validateMinimumResourceVersion(resourceVersion, uint64(rev)); err != nil {
+			return err
+		}
+	}
+
+	var lastKey string
+	hasMore := false
+	remaining := int64(0)
+	for i, key := range keys {
+		if paging && int64(v.Len()) >= pred.Limit {
+			hasMore = true
+			remaining = int64(len(keys) - i)
+			break
+		}
+		data, modRev, _ := s.backend.get(key)
+		if data == nil {
+			// The key was deleted since the keys were listed.
+			continue
+		}
+		lastKey = key
+		if err := appendListItem(v, data, uint64(modRev), pred, s.codec, s.versioner, newItemFunc); err != nil {
+			return err
+		}
+	}
+
+	if hasMore {
+		// we want to start immediately after the last key
+		next, err := encodeContinue(lastKey+"\x00", keyPrefix, returnedRV)
+		if err != nil {
+			return err
+		}
+		var remainingItemCount *int64
+		// Only set remainingItemCount if the predicate is empty, like etcd3.
+		if utilfeature.DefaultFeatureGate.Enabled(features.RemainingItemCount) && pred.Empty() {
+			remainingItemCount = &remaining
+		}
+		return s.versioner.UpdateList(listObj, uint64(returnedRV), next, remainingItemCount)
+	}
+	return s.versioner.UpdateList(listObj, uint64(returnedRV), "", nil)
+}
+
+// Count implements storage.Interface.Count.
+func (s *store) Count(key string) (int64, error) {
+	key = path.Join(s.pathPrefix, key)
+	if !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	keys, _ := s.backend.keysWithPrefix(key)
+	return int64(len(keys)), nil
+}
+
+// Watch implements storage.Interface.Watch.
+func (s *store) Watch(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	return s.watch(ctx, key, opts, false)
+}
+
+// WatchList implements storage.Interface.WatchList.
+func (s *store) WatchList(ctx context.Context, key string, opts storage.ListOptions) (watch.Interface, error) {
+	return s.watch(ctx, key, opts, true)
+}
+
+func (s *store) watch(ctx context.Context, key string, opts storage.ListOptions, recursive bool) (watch.Interface, error) {
+	rev, err := s.versioner.ParseResourceVersion(opts.ResourceVersion)
+	if err != nil {
+		return nil, err
+	}
+	key = path.Join(s.pathPrefix, key)
+	if recursive && !strings.HasSuffix(key, "/") {
+		key += "/"
+	}
+	return s.backend.watch(ctx, key, int64(rev), recursive, opts.Predicate, s.codec, s.versioner), nil
+}
+
+func (s *store) getState(key string, v reflect.Value, ignoreNotFound bool) (*objState, error) {
+	state := &objState{
+		meta: &storage.ResponseMeta{},
+	}
+
+	if u, ok := v.Addr().Interface().(runtime.Unstructured); ok {
+		state.obj = u.NewEmptyInstance()
+	} else {
+		state.obj = reflect.New(v.Type()).Interface().(runtime.Object)
+	}
+
+	data, modRev, _ := s.backend.get(key)
+	if data == nil {
+		if !ignoreNotFound {
+			return nil, storage.NewKeyNotFoundError(key, 0)
+		}
+		if err := runtime.SetZeroValue(state.obj); err != nil {
+			return nil, err
+		}
+		return state, nil
+	}
+	state.rev = modRev
+	state.meta.ResourceVersion = uint64(modRev)
+	state.data = data
+	if err := decode(s.codec, s.versioner, data, state.obj, modRev); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// validateMinimumResourceVersion returns a 'too large resource' version error when the provided minimumResourceVersion is
+// greater than the most recent actualRevision available from storage.
+func (s *store) validateMinimumResourceVersion(minimumResourceVersion string, actualRevision uint64) error {
+	if minimumResourceVersion == "" {
+		return nil
+	}
+	minimumRV, err := s.versioner.ParseResourceVersion(minimumResourceVersion)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("invalid resource version: %v", err))
+	}
+	if minimumRV > actualRevision {
+		return storage.NewTooLargeResourceVersionError(minimumRV, actualRevision, 0)
+	}
+	return nil
+}
+
+// validateExactResourceVersion returns an error if resourceVersion isn't
+// the current revision, since only the latest values are kept.
+func (s *store) validateExactResourceVersion(resourceVersion string, actualRevision int64) error {
+	rv, err := s.versioner.ParseResourceVersion(resourceVersion)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("invalid resource version: %v", err))
+	}
+	if int64(rv) < actualRevision {
+		return apierrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", rv, actualRevision))
+	}
+	return nil
+}
+
+func getNewItemFunc(listObj runtime.Object, v reflect.Value) func() runtime.Object {
+	// For unstructured lists with a target group/version, preserve the group/version in the instantiated list items
+	if unstructuredList, isUnstructured := listObj.(*unstructured.UnstructuredList); isUnstructured {
+		if apiVersion := unstructuredList.GetAPIVersion(); len(apiVersion) > 0 {
+			return func() runtime.Object {
+				return &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": apiVersion}}
+			}
+		}
+	}
+
+	// Otherwise just instantiate an empty item
+	elem := v.Type().Elem()
+	return func() runtime.Object {
+		return reflect.New(elem).Interface().(runtime.Object)
+	}
+}
+
+// continueToken is encoded like the continue tokens of etcd3, so that
+// clients can't tell the storages apart.
+type continueToken struct {
+	APIVersion      string `json:"v"`
+	ResourceVersion int64  `json:"rv"`
+	StartKey        string `json:"start"`
+}
+
+func decodeContinue(continueValue, keyPrefix string) (fromKey string, rv int64, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(continueValue)
+	if err != nil {
+		return "", 0, fmt.Errorf("continue key is not valid: %v", err)
+	}
+	var c continueToken
+	if err := json.Unmarshal(data, &c); err != nil {
+		return "", 0, fmt.Errorf("continue key is not valid: %v", err)
+	}
+	if c.APIVersion != "meta.k8s.io/v1" {
+		return "", 0, fmt.Errorf("continue key is not valid: server does not recognize this encoded version %q", c.APIVersion)
+	}
+	if c.ResourceVersion == 0 {
+		return "", 0, fmt.Errorf("continue key is not valid: incorrect encoded start resourceVersion (version meta.k8s.io/v1)")
+	}
+	if len(c.StartKey) == 0 {
+		return "", 0, fmt.Errorf("continue key is not valid: encoded start key empty (version meta.k8s.io/v1)")
+	}
+	// path.Clean ensures that the start key can't range over anything
+	// less specific than keyPrefix.
+	key := c.StartKey
+	if !strings.HasPrefix(key, "/") {
+		key = "/" + key
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key {
+		return "", 0, fmt.Errorf("continue key is not valid: %s", c.StartKey)
+	}
+	return keyPrefix + cleaned[1:], c.ResourceVersion, nil
+}
+
+func encodeContinue(key, keyPrefix string, resourceVersion int64) (string, error) {
+	nextKey := strings.TrimPrefix(key, keyPrefix)
+	if nextKey == key {
+		return "", fmt.Errorf("unable to encode next field: the key and key prefix do not match")
+	}
+	out, err := json.Marshal(&continueToken{APIVersion: "meta.k8s.io/v1", ResourceVersion: resourceVersion, StartKey: nextKey})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// decode decodes value of bytes into object. It will also set the object resource version to rev.
+// On success, objPtr would be set to the object.
+func decode(codec runtime.Codec, versioner storage.Versioner, value []byte, objPtr runtime.Object, rev int64) error {
+	if _, err := conversion.EnforcePtr(objPtr); err != nil {
+		return fmt.Errorf("unable to convert output object to pointer: %v", err)
+	}
+	if _, _, err := codec.Decode(value, nil, objPtr); err != nil {
+		return err
+	}
+	// being unable to set the version does not prevent the object from being extracted
+	if err := versioner.UpdateObject(objPtr, uint64(rev)); err != nil {
+		klog.Errorf("failed to update object version: %v", err)
+	}
+	return nil
+}
+
+// appendListItem decodes and appends the object (if it passes filter) to v, which must be a slice.
+func appendListItem(v reflect.Value, data []byte, rev uint64, pred storage.SelectionPredicate, codec runtime.Codec, versioner storage.Versioner, newItemFunc func() runtime.Object) error {
+	obj, _, err := codec.Decode(data, nil, newItemFunc())
+	if err != nil {
+		return err
+	}
+	// being unable to set the version does not prevent the object from being extracted
+	if err := versioner.UpdateObject(obj, rev); err != nil {
+		klog.Errorf("failed to update object version: %v", err)
+	}
+	if matched, err := pred.Matches(obj); err == nil && matched {
+		v.Set(reflect.Append(v, reflect.ValueOf(obj).Elem()))
+	}
+	return nil
+}
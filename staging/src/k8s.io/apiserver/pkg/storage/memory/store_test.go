@@ -0,0 +1,230 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	apitesting "k8s.io/apimachinery/pkg/api/apitesting"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/apis/example"
+	examplev1 "k8s.io/apiserver/pkg/apis/example/v1"
+	"k8s.io/apiserver/pkg/storage"
+)
+
+var scheme = runtime.NewScheme()
+var codecs = serializer.NewCodecFactory(scheme)
+
+func init() {
+	metav1.AddToGroupVersion(scheme, metav1.SchemeGroupVersion)
+	utilruntime.Must(example.AddToScheme(scheme))
+	utilruntime.Must(examplev1.AddToScheme(scheme))
+}
+
+func testSetup() (context.Context, *Backend, storage.Interface) {
+	backend := NewBackend()
+	codec := apitesting.TestCodec(codecs, examplev1.SchemeGroupVersion)
+	return context.Background(), backend, New(backend, codec, "/registry", true)
+}
+
+func createPods(ctx context.Context, t *testing.T, store storage.Interface, pods ...*example.Pod) {
+	for _, pod := range pods {
+		if err := store.Create(ctx, "/pods/"+pod.Name, pod, nil, 0); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+}
+
+func newPod(name, node string) *example.Pod {
+	return &example.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       example.PodSpec{NodeName: node},
+	}
+}
+
+func nodeNamePredicate(node string) storage.SelectionPredicate {
+	return storage.SelectionPredicate{
+		Label: labels.Everything(),
+		Field: fields.OneTermEqualSelector("spec.nodeName", node),
+		GetAttrs: func(obj runtime.Object) (labels.Set, fields.Set, error) {
+			pod := obj.(*example.Pod)
+			return nil, fields.Set{"spec.nodeName": pod.Spec.NodeName}, nil
+		},
+	}
+}
+
+func TestCreateUpdateDelete(t *testing.T) {
+	ctx, _, store := testSetup()
+	out := &example.Pod{}
+	if err := store.Create(ctx, "/pods/foo", newPod("foo", ""), out, 0); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if out.ResourceVersion != "2" {
+		t.Errorf("expected resourceVersion 2, got %q", out.ResourceVersion)
+	}
+	if err := store.Create(ctx, "/pods/foo", newPod("foo", ""), nil, 0); !storage.IsNodeExist(err) {
+		t.Errorf("expected an existing key, got %v", err)
+	}
+
+	stale := out.DeepCopy()
+	err := store.GuaranteedUpdate(ctx, "/pods/foo", out, false, nil, func(obj runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+		pod := obj.(*example.Pod)
+		pod.Spec.NodeName = "node-1"
+		return pod, nil, nil
+	})
+	if err != nil {
+		t.Fatalf("GuaranteedUpdate failed: %v", err)
+	}
+	if out.ResourceVersion != "3" || out.Spec.NodeName != "node-1" {
+		t.Errorf("expected the update at resourceVersion 3, got %#v", out)
+	}
+
+	// An update without changes doesn't write.
+	err = store.GuaranteedUpdate(ctx, "/pods/foo", out, false, nil, func(obj runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+		return obj, nil, nil
+	})
+	if err != nil {
+		t.Fatalf("GuaranteedUpdate failed: %v", err)
+	}
+	if out.ResourceVersion != "3" {
+		t.Errorf("expected resourceVersion 3 for a no-op update, got %q", out.ResourceVersion)
+	}
+
+	rv := stale.ResourceVersion
+	err = store.Delete(ctx, "/pods/foo", &example.Pod{}, &storage.Preconditions{ResourceVersion: &rv}, storage.ValidateAllObjectFunc)
+	if !storage.IsInvalidObj(err) {
+		t.Errorf("expected a failed precondition, got %v", err)
+	}
+	if err := store.Delete(ctx, "/pods/foo", out, nil, storage.ValidateAllObjectFunc); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Get(ctx, "/pods/foo", storage.GetOptions{}, &example.Pod{}); !storage.IsNotFound(err) {
+		t.Errorf("expected a missing key, got %v", err)
+	}
+	if err := store.Get(ctx, "/pods/foo", storage.GetOptions{ResourceVersion: "5"}, &example.Pod{}); !storage.IsTooLargeResourceVersion(err) {
+		t.Errorf("expected a too large resourceVersion, got %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx, _, store := testSetup()
+	createPods(ctx, t, store, newPod("a", "node-1"), newPod("b", "node-2"), newPod("c", "node-1"), newPod("d", "node-1"))
+	if err := store.Create(ctx, "/podsx/e", newPod("e", "node-1"), nil, 0); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	pred := nodeNamePredicate("node-1")
+	pred.Limit = 2
+	var pages [][]string
+	for {
+		list := &example.PodList{}
+		if err := store.List(ctx, "/pods", storage.ListOptions{Predicate: pred}, list); err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if list.ResourceVersion != "6" {
+			t.Errorf("expected resourceVersion 6, got %q", list.ResourceVersion)
+		}
+		var names []string
+		for _, pod := range list.Items {
+			names = append(names, pod.Name)
+		}
+		pages = append(pages, names)
+		if len(list.Continue) == 0 {
+			break
+		}
+		pred.Continue = list.Continue
+	}
+	if e, a := [][]string{{"a", "c"}, {"d"}}, pages; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected pages %v, got %v", e, a)
+	}
+
+	err := store.List(ctx, "/pods", storage.ListOptions{ResourceVersion: "1", ResourceVersionMatch: metav1.ResourceVersionMatchExact, Predicate: storage.Everything}, &example.PodList{})
+	if !apierrors.IsResourceExpired(err) {
+		t.Errorf("expected an expired resourceVersion, got %v", err)
+	}
+	if count, err := store.Count("/pods"); err != nil || count != 4 {
+		t.Errorf("expected 4 pods, got %d, %v", count, err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, backend, store := testSetup()
+	createPods(ctx, t, store, newPod("a", "node-1"))
+
+	w, err := store.WatchList(ctx, "/pods", storage.ListOptions{ResourceVersion: "0", Predicate: nodeNamePredicate("node-1")})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+
+	createPods(ctx, t, store, newPod("b", "node-2"))
+	err = store.GuaranteedUpdate(ctx, "/pods/a", &example.Pod{}, false, nil, func(obj runtime.Object, _ storage.ResponseMeta) (runtime.Object, *uint64, error) {
+		pod := obj.(*example.Pod)
+		pod.Spec.NodeName = "node-2"
+		return pod, nil, nil
+	})
+	if err != nil {
+		t.Fatalf("GuaranteedUpdate failed: %v", err)
+	}
+
+	type event struct {
+		eventType       watch.EventType
+		name            string
+		resourceVersion string
+	}
+	var events []event
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-w.ResultChan():
+			pod := e.Object.(*example.Pod)
+			events = append(events, event{e.Type, pod.Name, pod.ResourceVersion})
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	expected := []event{{watch.Added, "a", "2"}, {watch.Deleted, "a", "4"}}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+
+	// Watches from a resourceVersion older than the history fail.
+	backend.compactedRev = 3
+	w, err = store.WatchList(ctx, "/pods", storage.ListOptions{ResourceVersion: "1", Predicate: storage.Everything})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer w.Stop()
+	select {
+	case e := <-w.ResultChan():
+		if status, ok := e.Object.(*metav1.Status); e.Type != watch.Error || !ok || status.Reason != metav1.StatusReasonExpired {
+			t.Errorf("expected an expired error, got %v", e)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("timed out waiting for the error")
+	}
+}
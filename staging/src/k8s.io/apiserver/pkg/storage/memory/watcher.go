@@ -0,0 +1,272 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/klog/v2"
+)
+
+// event is a write to a key of a Backend, like an etcd event.
+type event struct {
+	key       string
+	value     []byte
+	prevValue []byte
+	rev       int64
+	isDeleted bool
+	isCreated bool
+}
+
+// queuedEvent is an event or an error waiting to be sent to a watcher.
+type queuedEvent struct {
+	event *event
+	err   *apierrors.StatusError
+}
+
+// watchChan is a watch of a key, or of all keys with a prefix if recursive.
+// Events are queued without bound by the backend while it holds its lock,
+// and transformed and sent to the result channel by the watch goroutine.
+type watchChan struct {
+	backend   *Backend
+	key       string
+	recursive bool
+	pred      storage.SelectionPredicate
+	codec     runtime.Codec
+	versioner storage.Versioner
+
+	ctx        context.Context
+	cancel     context.CancelFunc
+	resultChan chan watch.Event
+
+	lock   sync.Mutex
+	queue  []queuedEvent
+	signal chan struct{}
+}
+
+// watch starts a watch of key from revision rev. Like etcd3, a watch from
+// revision 0 starts with the current objects as created events, and a watch
+// from another revision with the events after it.
+func (b *Backend) watch(ctx context.Context, key string, rev int64, recursive bool, pred storage.SelectionPredicate, codec runtime.Codec, versioner storage.Versioner) watch.Interface {
+	wc := &watchChan{
+		backend:    b,
+		key:        key,
+		recursive:  recursive,
+		pred:       pred,
+		codec:      codec,
+		versioner:  versioner,
+		resultChan: make(chan watch.Event),
+		signal:     make(chan struct{}, 1),
+	}
+	wc.ctx, wc.cancel = context.WithCancel(ctx)
+
+	b.lock.Lock()
+	switch {
+	case rev == 0:
+		keys := make([]string, 0, len(b.kvs))
+		for k := range b.kvs {
+			if wc.matchesKey(k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kv := b.kvs[k]
+			wc.enqueue(&event{key: k, value: kv.value, rev: kv.modRev, isCreated: true}, nil)
+		}
+	case rev < b.compactedRev:
+		wc.enqueue(nil, apierrors.NewResourceExpired("The resourceVersion for the provided watch is too old."))
+	default:
+		for _, e := range b.history {
+			if e.rev > rev && wc.matchesKey(e.key) {
+				wc.enqueue(e, nil)
+			}
+		}
+	}
+	b.watchers[wc] = struct{}{}
+	b.lock.Unlock()
+
+	go wc.run()
+	return wc
+}
+
+// Stop implements watch.Interface.
+func (wc *watchChan) Stop() {
+	wc.cancel()
+}
+
+// ResultChan implements watch.Interface.
+func (wc *watchChan) ResultChan() <-chan watch.Event {
+	return wc.resultChan
+}
+
+func (wc *watchChan) matchesKey(key string) bool {
+	if wc.recursive {
+		return strings.HasPrefix(key, wc.key)
+	}
+	return key == wc.key
+}
+
+// enqueue queues an event or an error for the watch goroutine. It never
+// blocks, so that the backend can call it while holding its lock.
+func (wc *watchChan) enqueue(e *event, err *apierrors.StatusError) {
+	wc.lock.Lock()
+	wc.queue = append(wc.queue, queuedEvent{event: e, err: err})
+	wc.lock.Unlock()
+	select {
+	case wc.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (wc *watchChan) dequeue() []queuedEvent {
+	wc.lock.Lock()
+	defer wc.lock.Unlock()
+	queue := wc.queue
+	wc.queue = nil
+	return queue
+}
+
+func (wc *watchChan) run() {
+	defer func() {
+		wc.backend.lock.Lock()
+		delete(wc.backend.watchers, wc)
+		wc.backend.lock.Unlock()
+		close(wc.resultChan)
+	}()
+
+	for {
+		select {
+		case <-wc.signal:
+		case <-wc.ctx.Done():
+			return
+		}
+		for _, queued := range wc.dequeue() {
+			if queued.err != nil {
+				// Like etcd3, errors end the watch.
+				wc.send(watch.Event{Type: watch.Error, Object: &queued.err.ErrStatus})
+				return
+			}
+			res, err := wc.transform(queued.event)
+			if err != nil {
+				klog.Errorf("failed to prepare current and previous objects: %v", err)
+				wc.send(watch.Event{Type: watch.Error, Object: &apierrors.NewInternalError(err).ErrStatus})
+				return
+			}
+			if res != nil && !wc.send(*res) {
+				return
+			}
+		}
+	}
+}
+
+// send sends res to the result channel and returns false if the watch was
+// stopped first.
+func (wc *watchChan) send(res watch.Event) bool {
+	select {
+	case wc.resultChan <- res:
+		return true
+	case <-wc.ctx.Done():
+		return false
+	}
+}
+
+func (wc *watchChan) filter(obj runtime.Object) bool {
+	if wc.pred.Empty() {
+		return true
+	}
+	matched, err := wc.pred.Matches(obj)
+	return err == nil && matched
+}
+
+func (wc *watchChan) acceptAll() bool {
+	return wc.pred.Empty()
+}
+
+// transform transforms an event into a result for user if not filtered.
+func (wc *watchChan) transform(e *event) (*watch.Event, error) {
+	curObj, oldObj, err := wc.prepareObjs(e)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case e.isDeleted:
+		if !wc.filter(oldObj) {
+			return nil, nil
+		}
+		return &watch.Event{Type: watch.Deleted, Object: oldObj}, nil
+	case e.isCreated:
+		if !wc.filter(curObj) {
+			return nil, nil
+		}
+		return &watch.Event{Type: watch.Added, Object: curObj}, nil
+	case wc.acceptAll():
+		return &watch.Event{Type: watch.Modified, Object: curObj}, nil
+	}
+	curObjPasses := wc.filter(curObj)
+	oldObjPasses := wc.filter(oldObj)
+	switch {
+	case curObjPasses && oldObjPasses:
+		return &watch.Event{Type: watch.Modified, Object: curObj}, nil
+	case curObjPasses && !oldObjPasses:
+		return &watch.Event{Type: watch.Added, Object: curObj}, nil
+	case !curObjPasses && oldObjPasses:
+		return &watch.Event{Type: watch.Deleted, Object: oldObj}, nil
+	}
+	return nil, nil
+}
+
+func (wc *watchChan) prepareObjs(e *event) (curObj runtime.Object, oldObj runtime.Object, err error) {
+	if !e.isDeleted {
+		curObj, err = decodeObj(wc.codec, wc.versioner, e.value, e.rev)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	// The previous object is only needed for deletions, or to find out
+	// whether it was filtered out before.
+	if len(e.prevValue) > 0 && (e.isDeleted || !wc.acceptAll()) {
+		// Note that this sends the *old* object with the revision for the
+		// time at which it gets deleted, like etcd3.
+		oldObj, err = decodeObj(wc.codec, wc.versioner, e.prevValue, e.rev)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return curObj, oldObj, nil
+}
+
+func decodeObj(codec runtime.Codec, versioner storage.Versioner, data []byte, rev int64) (runtime.Object, error) {
+	obj, err := runtime.Decode(codec, data)
+	if err != nil {
+		return nil, err
+	}
+	// ensure resource version is set on the object we load from storage
+	if err := versioner.UpdateObject(obj, uint64(rev)); err != nil {
+		return nil, fmt.Errorf("failure to version api object (%d) %#v: %v", rev, obj, err)
+	}
+	return obj, nil
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultIgnoredFields are the fields the server populates on every write,
+// which are left out of the diffs of a DryRunner unless told otherwise.
+var DefaultIgnoredFields = [][]string{
+	{"metadata", "managedFields"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "generation"},
+	{"metadata", "uid"},
+	{"metadata", "selfLink"},
+	{"metadata", "creationTimestamp"},
+}
+
+// DiffType is the kind of change of a field.
+type DiffType string
+
+const (
+	// FieldAdded means the field isn't set in the live object.
+	FieldAdded DiffType = "Added"
+	// FieldRemoved means the field would be removed from the live object.
+	FieldRemoved DiffType = "Removed"
+	// FieldChanged means the field would get a different value.
+	FieldChanged DiffType = "Changed"
+)
+
+// FieldDiff is a field that differs between two objects.
+type FieldDiff struct {
+	// Path is the path of the field, e.g. ".spec.template.spec.containers[0].image".
+	Path string
+	Type DiffType
+	// From and To are the values of the field in the old and the new object,
+	// or nil if it isn't set in that object.
+	From interface{}
+	To   interface{}
+}
+
+// String implements fmt.Stringer.
+func (d FieldDiff) String() string {
+	switch d.Type {
+	case FieldAdded:
+		return fmt.Sprintf("%s: added %v", d.Path, d.To)
+	case FieldRemoved:
+		return fmt.Sprintf("%s: removed %v", d.Path, d.From)
+	default:
+		return fmt.Sprintf("%s: %v -> %v", d.Path, d.From, d.To)
+	}
+}
+
+// DryRunResult is the outcome of a dry-run write.
+type DryRunResult struct {
+	// Live is the object as stored by the server, or nil if it doesn't
+	// exist.
+	Live *unstructured.Unstructured
+	// DryRun is the object the server would store.
+	DryRun *unstructured.Unstructured
+	// Diff are the fields the write would change, sorted by path.
+	Diff []FieldDiff
+}
+
+// DryRunner previews writes through a ResourceInterface. It sends them with
+// DryRun set to All, so that the server validates, defaults and admits them
+// without storing them, and compares the result with the live object.
+type DryRunner struct {
+	client ResourceInterface
+
+	// IgnoredFields are the paths of fields that are left out of diffs,
+	// DefaultIgnoredFields if nil.
+	IgnoredFields [][]string
+}
+
+// NewDryRunner returns a DryRunner that writes through client.
+func NewDryRunner(client ResourceInterface) *DryRunner {
+	return &DryRunner{client: client}
+}
+
+// Create previews creating obj. Creating an object that exists fails like it
+// would without dry-run.
+func (d *DryRunner) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*DryRunResult, error) {
+	live, err := d.live(ctx, obj.GetName(), subresources...)
+	if err != nil {
+		return nil, err
+	}
+	options.DryRun = []string{metav1.DryRunAll}
+	result, err := d.client.Create(ctx, obj, options, subresources...)
+	if err != nil {
+		return nil, err
+	}
+	return d.result(live, result), nil
+}
+
+// Update previews replacing the live object with obj.
+func (d *DryRunner) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*DryRunResult, error) {
+	live, err := d.live(ctx, obj.GetName(), subresources...)
+	if err != nil {
+		return nil, err
+	}
+	options.DryRun = []string{metav1.DryRunAll}
+	result, err := d.client.Update(ctx, obj, options, subresources...)
+	if err != nil {
+		return nil, err
+	}
+	return d.result(live, result), nil
+}
+
+// Apply previews applying obj with server-side apply. options.FieldManager
+// must be set.
+func (d *DryRunner) Apply(ctx context.Context, obj *unstructured.Unstructured, options metav1.PatchOptions, subresources ...string) (*DryRunResult, error) {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	live, err := d.live(ctx, obj.GetName(), subresources...)
+	if err != nil {
+		return nil, err
+	}
+	options.DryRun = []string{metav1.DryRunAll}
+	result, err := d.client.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, options, subresources...)
+	if err != nil {
+		return nil, err
+	}
+	return d.result(live, result), nil
+}
+
+// live returns the object with the given name, or nil if there is none.
+func (d *DryRunner) live(ctx context.Context, name string, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(name) == 0 {
+		// The server generates the names of objects created with
+		// generateName.
+		return nil, nil
+	}
+	live, err := d.client.Get(ctx, name, metav1.GetOptions{}, subresources...)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return live, err
+}
+
+func (d *DryRunner) result(live, dryRun *unstructured.Unstructured) *DryRunResult {
+	ignored := d.IgnoredFields
+	if ignored == nil {
+		ignored = DefaultIgnoredFields
+	}
+	return &DryRunResult{
+		Live:   live,
+		DryRun: dryRun,
+		Diff:   DiffObjects(live, dryRun, ignored),
+	}
+}
+
+// DiffObjects returns the fields that differ between from and to, sorted by
+// path, except for those in ignoredFields and the fields below them. Either
+// object may be nil, in which case all fields of the other one differ. Lists
+// are compared item by item.
+func DiffObjects(from, to *unstructured.Unstructured, ignoredFields [][]string) []FieldDiff {
+	var fromContent, toContent map[string]interface{}
+	if from != nil {
+		fromContent = withoutFields(from.UnstructuredContent(), ignoredFields)
+	}
+	if to != nil {
+		toContent = withoutFields(to.UnstructuredContent(), ignoredFields)
+	}
+	var diffs []FieldDiff
+	diffValues("", fromContent, toContent, &diffs)
+	return diffs
+}
+
+// withoutFields returns a copy of content without the given fields.
+func withoutFields(content map[string]interface{}, fields [][]string) map[string]interface{} {
+	content = runtime.DeepCopyJSON(content)
+	for _, field := range fields {
+		unstructured.RemoveNestedField(content, field...)
+	}
+	return content
+}
+
+// diffValues appends the differences between from and to at path to diffs.
+func diffValues(path string, from, to interface{}, diffs *[]FieldDiff) {
+	switch {
+	case from == nil && to == nil:
+		return
+	case from == nil:
+		*diffs = append(*diffs, FieldDiff{Path: path, Type: FieldAdded, To: to})
+		return
+	case to == nil:
+		*diffs = append(*diffs, FieldDiff{Path: path, Type: FieldRemoved, From: from})
+		return
+	}
+
+	switch fromValue := from.(type) {
+	case map[string]interface{}:
+		toValue, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(fromValue)+len(toValue))
+		for key := range fromValue {
+			keys = append(keys, key)
+		}
+		for key := range toValue {
+			if _, ok := fromValue[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			diffValues(fieldPath(path, key), fromValue[key], toValue[key], diffs)
+		}
+		return
+	case []interface{}:
+		toValue, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(fromValue) || i < len(toValue); i++ {
+			var fromItem, toItem interface{}
+			if i < len(fromValue) {
+				fromItem = fromValue[i]
+			}
+			if i < len(toValue) {
+				toItem = toValue[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), fromItem, toItem, diffs)
+		}
+		return
+	}
+	if !reflect.DeepEqual(from, to) {
+		*diffs = append(*diffs, FieldDiff{Path: path, Type: FieldChanged, From: from, To: to})
+	}
+}
+
+// fieldPath returns the path of the field key of the object at path.
+func fieldPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]") {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	return path + "." + key
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamic
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// dryRunResourceClient serves a single live object and answers writes with
+// what the server would store, recording their dry-run options.
+type dryRunResourceClient struct {
+	ResourceInterface
+
+	live   *unstructured.Unstructured
+	dryRun [][]string
+	patch  types.PatchType
+}
+
+func (c *dryRunResourceClient) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if c.live == nil || c.live.GetName() != name {
+		return nil, errors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, name)
+	}
+	return c.live.DeepCopy(), nil
+}
+
+func (c *dryRunResourceClient) stored(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	stored := obj.DeepCopy()
+	stored.SetResourceVersion("2")
+	stored.SetUID("uid")
+	stored.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "test"}})
+	return stored
+}
+
+func (c *dryRunResourceClient) Create(ctx context.Context, obj *unstructured.Unstructured, options metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	c.dryRun = append(c.dryRun, options.DryRun)
+	return c.stored(obj), nil
+}
+
+func (c *dryRunResourceClient) Update(ctx context.Context, obj *unstructured.Unstructured, options metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	c.dryRun = append(c.dryRun, options.DryRun)
+	return c.stored(obj), nil
+}
+
+func (c *dryRunResourceClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	c.dryRun = append(c.dryRun, options.DryRun)
+	c.patch = pt
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return c.stored(obj), nil
+}
+
+func newDeployment(replicas int64, image string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"namespace":       "default",
+			"resourceVersion": "1",
+		},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": image},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func TestDryRunnerUpdate(t *testing.T) {
+	client := &dryRunResourceClient{live: newDeployment(1, "nginx:1.18")}
+	desired := newDeployment(3, "nginx:1.19")
+	desired.SetLabels(map[string]string{"app": "web"})
+
+	result, err := NewDryRunner(client).Update(context.TODO(), desired, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := [][]string{{metav1.DryRunAll}}, client.dryRun; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected dry-run options %v, got %v", e, a)
+	}
+	expected := []FieldDiff{
+		{Path: ".metadata.labels", Type: FieldAdded, To: map[string]interface{}{"app": "web"}},
+		{Path: ".spec.replicas", Type: FieldChanged, From: int64(1), To: int64(3)},
+		{Path: ".spec.template.spec.containers[0].image", Type: FieldChanged, From: "nginx:1.18", To: "nginx:1.19"},
+	}
+	if !reflect.DeepEqual(expected, result.Diff) {
+		t.Errorf("expected diff %v, got %v", expected, result.Diff)
+	}
+}
+
+func TestDryRunnerApply(t *testing.T) {
+	client := &dryRunResourceClient{live: newDeployment(1, "nginx:1.18")}
+
+	result, err := NewDryRunner(client).Apply(context.TODO(), newDeployment(1, "nginx:1.18"), metav1.PatchOptions{FieldManager: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.patch != types.ApplyPatchType {
+		t.Errorf("expected an apply patch, got %q", client.patch)
+	}
+	if len(result.Diff) != 0 {
+		t.Errorf("expected server populated fields to be ignored, got %v", result.Diff)
+	}
+}
+
+func TestDryRunnerCreate(t *testing.T) {
+	client := &dryRunResourceClient{}
+	obj := newDeployment(1, "nginx:1.18")
+	obj.SetName("")
+	obj.SetGenerateName("web-")
+
+	result, err := NewDryRunner(client).Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Live != nil {
+		t.Errorf("expected no live object, got %v", result.Live)
+	}
+	var paths []string
+	for _, diff := range result.Diff {
+		if diff.Type != FieldAdded {
+			t.Errorf("expected only added fields, got %v", diff)
+		}
+		paths = append(paths, diff.Path)
+	}
+	if e, a := []string{".apiVersion", ".kind", ".metadata", ".spec"}, paths; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected added fields %v, got %v", e, a)
+	}
+}
+
+func TestDiffObjects(t *testing.T) {
+	from := newDeployment(1, "nginx:1.18")
+	from.SetAnnotations(map[string]string{"example.com/a.b": "x"})
+	to := newDeployment(1, "nginx:1.18")
+	unstructured.SetNestedSlice(to.Object, []interface{}{}, "spec", "template", "spec", "containers")
+
+	expected := []FieldDiff{
+		{Path: `.metadata.annotations`, Type: FieldRemoved, From: map[string]interface{}{"example.com/a.b": "x"}},
+		{Path: `.spec.template.spec.containers[0]`, Type: FieldRemoved, From: map[string]interface{}{"name": "web", "image": "nginx:1.18"}},
+	}
+	if diff := DiffObjects(from, to, nil); !reflect.DeepEqual(expected, diff) {
+		t.Errorf("expected diff %v, got %v", expected, diff)
+	}
+
+	to.SetAnnotations(map[string]string{"example.com/a.b": "y"})
+	diff := DiffObjects(from, to, [][]string{{"spec"}})
+	if len(diff) != 1 || diff[0].Path != `.metadata.annotations["example.com/a.b"]` {
+		t.Errorf("expected a single change of the annotation, got %v", diff)
+	}
+}
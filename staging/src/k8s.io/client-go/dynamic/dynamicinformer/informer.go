@@ -46,6 +46,7 @@ func NewFilteredDynamicSharedInformerFactory(client dynamic.Interface, defaultRe
 		namespace:        namespace,
 		informers:        map[schema.GroupVersionResource]informers.GenericInformer{},
 		startedInformers: make(map[schema.GroupVersionResource]bool),
+		informerStoppers: make(map[schema.GroupVersionResource]func()),
 		tweakListOptions: tweakListOptions,
 	}
 }
@@ -60,6 +61,12 @@ type dynamicSharedInformerFactory struct {
 	// startedInformers is used for tracking which informers have been started.
 	// This allows Start() to be called multiple times safely.
 	startedInformers map[schema.GroupVersionResource]bool
+	// informerStoppers holds, for every started informer, a function that
+	// stops just that informer and waits for it to exit.
+	informerStoppers map[schema.GroupVersionResource]func()
+	// shuttingDown is true when Shutdown has been called. Start is a no-op
+	// from then on.
+	shuttingDown     bool
 	tweakListOptions TweakListOptionsFunc
 }
 
@@ -86,14 +93,88 @@ func (f *dynamicSharedInformerFactory) Start(stopCh <-chan struct{}) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 
+	if f.shuttingDown {
+		return
+	}
+
 	for informerType, informer := range f.informers {
 		if !f.startedInformers[informerType] {
-			go informer.Informer().Run(stopCh)
+			f.informerStoppers[informerType] = runInformer(informer.Informer(), stopCh)
 			f.startedInformers[informerType] = true
 		}
 	}
 }
 
+// runInformer runs informer until either stopCh or the informer's own stop
+// channel is closed. It returns a function that closes the latter and waits
+// for the informer to exit.
+func runInformer(informer cache.SharedIndexInformer, stopCh <-chan struct{}) func() {
+	informerStopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runStopCh := make(chan struct{})
+		go func() {
+			defer close(runStopCh)
+			select {
+			case <-stopCh:
+			case <-informerStopCh:
+			}
+		}()
+		informer.Run(runStopCh)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(informerStopCh) })
+		<-done
+	}
+}
+
+// ShutdownInformer stops the informer for gvr, if one was requested, waits
+// for it to exit and forgets it, so that a later ForResource call followed by
+// Start runs a fresh informer. The other informers keep running. This allows
+// dropping the informer of a CustomResourceDefinition that was deleted.
+func (f *dynamicSharedInformerFactory) ShutdownInformer(gvr schema.GroupVersionResource) {
+	stop := func() func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		if _, exists := f.informers[gvr]; !exists {
+			return nil
+		}
+		stop := f.informerStoppers[gvr]
+		delete(f.informers, gvr)
+		delete(f.startedInformers, gvr)
+		delete(f.informerStoppers, gvr)
+		return stop
+	}()
+
+	if stop != nil {
+		stop()
+	}
+}
+
+// Shutdown stops all informers that have been started and waits for them to
+// exit. Start is a no-op once Shutdown has been called.
+func (f *dynamicSharedInformerFactory) Shutdown() {
+	stoppers := func() []func() {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		f.shuttingDown = true
+		stoppers := make([]func(), 0, len(f.informerStoppers))
+		for _, stop := range f.informerStoppers {
+			stoppers = append(stoppers, stop)
+		}
+		return stoppers
+	}()
+
+	for _, stop := range stoppers {
+		stop()
+	}
+}
+
 // WaitForCacheSync waits for all started informers' cache were synced.
 func (f *dynamicSharedInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool {
 	informers := func() map[schema.GroupVersionResource]cache.SharedIndexInformer {
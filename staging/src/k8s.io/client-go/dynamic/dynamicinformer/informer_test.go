@@ -18,6 +18,7 @@ package dynamicinformer_test
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,8 +28,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 )
 
@@ -238,6 +242,58 @@ func TestDynamicSharedInformerFactory(t *testing.T) {
 	}
 }
 
+func TestDynamicSharedInformerFactoryShutdown(t *testing.T) {
+	crdGVR := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "foos"}
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	fakeClient := fake.NewSimpleDynamicClient(runtime.NewScheme())
+	var lock sync.Mutex
+	watches := map[string]*watch.FakeWatcher{}
+	fakeClient.PrependWatchReactor("*", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		w := watch.NewFake()
+		watches[action.GetResource().Resource] = w
+		return true, w, nil
+	})
+	watchFor := func(resource string) *watch.FakeWatcher {
+		var w *watch.FakeWatcher
+		if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			w = watches[resource]
+			return w != nil, nil
+		}); err != nil {
+			t.Fatalf("no watch of %s was opened", resource)
+		}
+		return w
+	}
+
+	target := dynamicinformer.NewDynamicSharedInformerFactory(fakeClient, 0)
+	crdInformer := target.ForResource(crdGVR).Informer()
+	target.ForResource(deploymentsGVR)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	target.Start(stopCh)
+	crdWatch, deploymentsWatch := watchFor("foos"), watchFor("deployments")
+
+	// The CustomResourceDefinition is deleted: only its informer stops.
+	target.ShutdownInformer(crdGVR)
+	if !crdWatch.IsStopped() {
+		t.Errorf("expected the informer of %v to have exited", crdGVR)
+	}
+	if deploymentsWatch.IsStopped() {
+		t.Errorf("expected the informer of %v to keep running", deploymentsGVR)
+	}
+	if target.ForResource(crdGVR).Informer() == crdInformer {
+		t.Errorf("expected a fresh informer for %v", crdGVR)
+	}
+
+	target.Shutdown()
+	if !deploymentsWatch.IsStopped() {
+		t.Errorf("expected Shutdown to wait for the informers to exit")
+	}
+}
+
 func newUnstructured(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
 		Object: map[string]interface{}{
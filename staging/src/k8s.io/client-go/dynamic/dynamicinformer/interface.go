@@ -27,6 +27,8 @@ type DynamicSharedInformerFactory interface {
 	Start(stopCh <-chan struct{})
 	ForResource(gvr schema.GroupVersionResource) informers.GenericInformer
 	WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool
+	ShutdownInformer(gvr schema.GroupVersionResource)
+	Shutdown()
 }
 
 // TweakListOptionsFunc defines the signature of a helper function
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package informers
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var podsResource = v1.SchemeGroupVersion.WithResource("pods")
+
+// watchRecorder serves fake watches and records them by resource.
+type watchRecorder struct {
+	lock    sync.Mutex
+	watches map[string][]*watch.FakeWatcher
+}
+
+func newFakeClient(objects ...runtime.Object) (*fake.Clientset, *watchRecorder) {
+	client := fake.NewSimpleClientset(objects...)
+	recorder := &watchRecorder{watches: map[string][]*watch.FakeWatcher{}}
+	client.PrependWatchReactor("*", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		recorder.lock.Lock()
+		defer recorder.lock.Unlock()
+		w := watch.NewFake()
+		resource := action.GetResource().Resource
+		recorder.watches[resource] = append(recorder.watches[resource], w)
+		return true, w, nil
+	})
+	return client, recorder
+}
+
+func (r *watchRecorder) get(resource string) []*watch.FakeWatcher {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]*watch.FakeWatcher(nil), r.watches[resource]...)
+}
+
+// waitForWatch waits until the n-th watch of resource is opened and returns it.
+func (r *watchRecorder) waitForWatch(t *testing.T, resource string, n int) *watch.FakeWatcher {
+	var w *watch.FakeWatcher
+	err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		if watches := r.get(resource); len(watches) >= n {
+			w = watches[n-1]
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("watch %d of %s was not opened: %v", n, resource, err)
+	}
+	return w
+}
+
+func TestShutdownInformer(t *testing.T) {
+	client, recorder := newFakeClient(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "pod"}})
+	factory := NewSharedInformerFactory(client, 0)
+	podInformer := factory.Core().V1().Pods().Informer()
+	factory.Core().V1().ConfigMaps().Informer()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	for resource, synced := range factory.WaitForCacheSync(stopCh) {
+		if !synced {
+			t.Fatalf("%v did not sync", resource)
+		}
+	}
+	podWatch := recorder.waitForWatch(t, "pods", 1)
+	configMapWatch := recorder.waitForWatch(t, "configmaps", 1)
+
+	if err := factory.ShutdownInformer(podsResource); err != nil {
+		t.Fatal(err)
+	}
+	if !podWatch.IsStopped() {
+		t.Errorf("expected the pod informer to have exited")
+	}
+	if configMapWatch.IsStopped() {
+		t.Errorf("expected the config map informer to keep running")
+	}
+
+	// A later request gets a fresh informer, which Start runs.
+	genericInformer, err := factory.ForResource(podsResource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genericInformer.Informer() == podInformer {
+		t.Fatalf("expected a fresh pod informer")
+	}
+	factory.Start(stopCh)
+	if synced := factory.WaitForCacheSync(stopCh); len(synced) != 2 {
+		t.Errorf("expected two started informers, got %v", synced)
+	}
+	recorder.waitForWatch(t, "pods", 2)
+	if pods, err := factory.Core().V1().Pods().Lister().List(labels.Everything()); err != nil || len(pods) != 1 {
+		t.Errorf("expected the fresh informer to list the pod, got %v, %v", pods, err)
+	}
+}
+
+func TestShutdownInformerNotRequested(t *testing.T) {
+	client, _ := newFakeClient()
+	factory := NewSharedInformerFactory(client, 0)
+	factory.Core().V1().ConfigMaps().Informer()
+
+	if err := factory.ShutdownInformer(podsResource); err != nil {
+		t.Fatal(err)
+	}
+	f := factory.(*sharedInformerFactory)
+	if len(f.informers) != 1 {
+		t.Errorf("expected only the config map informer to be registered, got %v", f.informers)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	client, recorder := newFakeClient()
+	factory := NewSharedInformerFactory(client, 0)
+	factory.Core().V1().Pods().Informer()
+	factory.Core().V1().ConfigMaps().Informer()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	watches := []*watch.FakeWatcher{
+		recorder.waitForWatch(t, "pods", 1),
+		recorder.waitForWatch(t, "configmaps", 1),
+	}
+
+	factory.Shutdown()
+	for _, w := range watches {
+		if !w.IsStopped() {
+			t.Errorf("expected Shutdown to wait for the informers to exit")
+		}
+	}
+
+	// Start is a no-op after Shutdown.
+	factory.Core().V1().Secrets().Informer()
+	factory.Start(stopCh)
+	if f := factory.(*sharedInformerFactory); f.startedInformers[reflect.TypeOf(&v1.Secret{})] {
+		t.Errorf("expected Start not to run informers after Shutdown")
+	}
+}
@@ -105,7 +105,7 @@ func (c *FakeLeases) Update(ctx context.Context, lease *coordinationv1.Lease, op
 // Delete takes name of the lease and deletes it. Returns an error if one occurs.
 func (c *FakeLeases) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(leasesResource, c.ns, name), &coordinationv1.Lease{})
+		Invokes(testing.NewDeleteActionWithOptions(leasesResource, c.ns, name, opts), &coordinationv1.Lease{})
 
 	return err
 }
@@ -121,7 +121,7 @@ func (c *FakeLeases) DeleteCollection(ctx context.Context, opts v1.DeleteOptions
 // Patch applies the patch and returns the patched lease.
 func (c *FakeLeases) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *coordinationv1.Lease, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(leasesResource, c.ns, name, pt, data, subresources...), &coordinationv1.Lease{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(leasesResource, c.ns, name, pt, data, opts, subresources...), &coordinationv1.Lease{})
 
 	if obj == nil {
 		return nil, err
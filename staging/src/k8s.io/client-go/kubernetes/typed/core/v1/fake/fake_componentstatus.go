@@ -99,7 +99,7 @@ func (c *FakeComponentStatuses) Update(ctx context.Context, componentStatus *cor
 // Delete takes name of the componentStatus and deletes it. Returns an error if one occurs.
 func (c *FakeComponentStatuses) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewRootDeleteAction(componentstatusesResource, name), &corev1.ComponentStatus{})
+		Invokes(testing.NewRootDeleteActionWithOptions(componentstatusesResource, name, opts), &corev1.ComponentStatus{})
 	return err
 }
 
@@ -114,7 +114,7 @@ func (c *FakeComponentStatuses) DeleteCollection(ctx context.Context, opts v1.De
 // Patch applies the patch and returns the patched componentStatus.
 func (c *FakeComponentStatuses) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *corev1.ComponentStatus, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewRootPatchSubresourceAction(componentstatusesResource, name, pt, data, subresources...), &corev1.ComponentStatus{})
+		Invokes(testing.NewRootPatchSubresourceActionWithOptions(componentstatusesResource, name, pt, data, opts, subresources...), &corev1.ComponentStatus{})
 	if obj == nil {
 		return nil, err
 	}
@@ -117,7 +117,7 @@ func (c *FakePods) UpdateStatus(ctx context.Context, pod *corev1.Pod, opts v1.Up
 // Delete takes name of the pod and deletes it. Returns an error if one occurs.
 func (c *FakePods) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(podsResource, c.ns, name), &corev1.Pod{})
+		Invokes(testing.NewDeleteActionWithOptions(podsResource, c.ns, name, opts), &corev1.Pod{})
 
 	return err
 }
@@ -133,7 +133,7 @@ func (c *FakePods) DeleteCollection(ctx context.Context, opts v1.DeleteOptions,
 // Patch applies the patch and returns the patched pod.
 func (c *FakePods) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *corev1.Pod, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(podsResource, c.ns, name, pt, data, subresources...), &corev1.Pod{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(podsResource, c.ns, name, pt, data, opts, subresources...), &corev1.Pod{})
 
 	if obj == nil {
 		return nil, err
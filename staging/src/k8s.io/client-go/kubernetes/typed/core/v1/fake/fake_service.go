@@ -117,7 +117,7 @@ func (c *FakeServices) UpdateStatus(ctx context.Context, service *corev1.Service
 // Delete takes name of the service and deletes it. Returns an error if one occurs.
 func (c *FakeServices) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(servicesResource, c.ns, name), &corev1.Service{})
+		Invokes(testing.NewDeleteActionWithOptions(servicesResource, c.ns, name, opts), &corev1.Service{})
 
 	return err
 }
@@ -125,7 +125,7 @@ func (c *FakeServices) Delete(ctx context.Context, name string, opts v1.DeleteOp
 // Patch applies the patch and returns the patched service.
 func (c *FakeServices) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *corev1.Service, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(servicesResource, c.ns, name, pt, data, subresources...), &corev1.Service{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(servicesResource, c.ns, name, pt, data, opts, subresources...), &corev1.Service{})
 
 	if obj == nil {
 		return nil, err
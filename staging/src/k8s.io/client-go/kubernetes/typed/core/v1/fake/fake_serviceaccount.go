@@ -106,7 +106,7 @@ func (c *FakeServiceAccounts) Update(ctx context.Context, serviceAccount *corev1
 // Delete takes name of the serviceAccount and deletes it. Returns an error if one occurs.
 func (c *FakeServiceAccounts) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(serviceaccountsResource, c.ns, name), &corev1.ServiceAccount{})
+		Invokes(testing.NewDeleteActionWithOptions(serviceaccountsResource, c.ns, name, opts), &corev1.ServiceAccount{})
 
 	return err
 }
@@ -122,7 +122,7 @@ func (c *FakeServiceAccounts) DeleteCollection(ctx context.Context, opts v1.Dele
 // Patch applies the patch and returns the patched serviceAccount.
 func (c *FakeServiceAccounts) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *corev1.ServiceAccount, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(serviceaccountsResource, c.ns, name, pt, data, subresources...), &corev1.ServiceAccount{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(serviceaccountsResource, c.ns, name, pt, data, opts, subresources...), &corev1.ServiceAccount{})
 
 	if obj == nil {
 		return nil, err
@@ -105,7 +105,7 @@ func (c *FakeEndpointSlices) Update(ctx context.Context, endpointSlice *v1alpha1
 // Delete takes name of the endpointSlice and deletes it. Returns an error if one occurs.
 func (c *FakeEndpointSlices) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(endpointslicesResource, c.ns, name), &v1alpha1.EndpointSlice{})
+		Invokes(testing.NewDeleteActionWithOptions(endpointslicesResource, c.ns, name, opts), &v1alpha1.EndpointSlice{})
 
 	return err
 }
@@ -121,7 +121,7 @@ func (c *FakeEndpointSlices) DeleteCollection(ctx context.Context, opts v1.Delet
 // Patch applies the patch and returns the patched endpointSlice.
 func (c *FakeEndpointSlices) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.EndpointSlice, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(endpointslicesResource, c.ns, name, pt, data, subresources...), &v1alpha1.EndpointSlice{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(endpointslicesResource, c.ns, name, pt, data, opts, subresources...), &v1alpha1.EndpointSlice{})
 
 	if obj == nil {
 		return nil, err
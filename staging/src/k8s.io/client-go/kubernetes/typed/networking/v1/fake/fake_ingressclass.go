@@ -99,7 +99,7 @@ func (c *FakeIngressClasses) Update(ctx context.Context, ingressClass *networkin
 // Delete takes name of the ingressClass and deletes it. Returns an error if one occurs.
 func (c *FakeIngressClasses) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewRootDeleteAction(ingressclassesResource, name), &networkingv1.IngressClass{})
+		Invokes(testing.NewRootDeleteActionWithOptions(ingressclassesResource, name, opts), &networkingv1.IngressClass{})
 	return err
 }
 
@@ -114,7 +114,7 @@ func (c *FakeIngressClasses) DeleteCollection(ctx context.Context, opts v1.Delet
 // Patch applies the patch and returns the patched ingressClass.
 func (c *FakeIngressClasses) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *networkingv1.IngressClass, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewRootPatchSubresourceAction(ingressclassesResource, name, pt, data, subresources...), &networkingv1.IngressClass{})
+		Invokes(testing.NewRootPatchSubresourceActionWithOptions(ingressclassesResource, name, pt, data, opts, subresources...), &networkingv1.IngressClass{})
 	if obj == nil {
 		return nil, err
 	}
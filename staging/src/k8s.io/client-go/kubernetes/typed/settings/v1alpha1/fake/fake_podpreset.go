@@ -105,7 +105,7 @@ func (c *FakePodPresets) Update(ctx context.Context, podPreset *v1alpha1.PodPres
 // Delete takes name of the podPreset and deletes it. Returns an error if one occurs.
 func (c *FakePodPresets) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewDeleteAction(podpresetsResource, c.ns, name), &v1alpha1.PodPreset{})
+		Invokes(testing.NewDeleteActionWithOptions(podpresetsResource, c.ns, name, opts), &v1alpha1.PodPreset{})
 
 	return err
 }
@@ -121,7 +121,7 @@ func (c *FakePodPresets) DeleteCollection(ctx context.Context, opts v1.DeleteOpt
 // Patch applies the patch and returns the patched podPreset.
 func (c *FakePodPresets) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.PodPreset, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewPatchSubresourceAction(podpresetsResource, c.ns, name, pt, data, subresources...), &v1alpha1.PodPreset{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(podpresetsResource, c.ns, name, pt, data, opts, subresources...), &v1alpha1.PodPreset{})
 
 	if obj == nil {
 		return nil, err
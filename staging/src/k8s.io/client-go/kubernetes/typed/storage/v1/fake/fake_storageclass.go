@@ -99,7 +99,7 @@ func (c *FakeStorageClasses) Update(ctx context.Context, storageClass *storagev1
 // Delete takes name of the storageClass and deletes it. Returns an error if one occurs.
 func (c *FakeStorageClasses) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewRootDeleteAction(storageclassesResource, name), &storagev1.StorageClass{})
+		Invokes(testing.NewRootDeleteActionWithOptions(storageclassesResource, name, opts), &storagev1.StorageClass{})
 	return err
 }
 
@@ -114,7 +114,7 @@ func (c *FakeStorageClasses) DeleteCollection(ctx context.Context, opts v1.Delet
 // Patch applies the patch and returns the patched storageClass.
 func (c *FakeStorageClasses) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *storagev1.StorageClass, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewRootPatchSubresourceAction(storageclassesResource, name, pt, data, subresources...), &storagev1.StorageClass{})
+		Invokes(testing.NewRootPatchSubresourceActionWithOptions(storageclassesResource, name, pt, data, opts, subresources...), &storagev1.StorageClass{})
 	if obj == nil {
 		return nil, err
 	}
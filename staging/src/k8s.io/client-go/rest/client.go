@@ -98,6 +98,15 @@ type RESTClient struct {
 	// If not set, defaultWarningHandler is used.
 	warningHandler WarningHandler
 
+	// retryPolicy is used by all requests created by this client unless
+	// specifically overridden. If not set, requests are only retried on
+	// Retry-After responses.
+	retryPolicy RetryPolicy
+
+	// tracer records the spans of all requests created by this client unless
+	// specifically overridden.
+	tracer Tracer
+
 	// Set specific behavior of the client.  If not set http.DefaultClient will be used.
 	Client *http.Client
 }
@@ -89,6 +89,13 @@ type Config struct {
 	// Exec-based authentication provider.
 	ExecProvider *clientcmdapi.ExecConfig
 
+	// CredentialProvider supplies a bearer token or client certificate, which
+	// is refreshed before it expires. Use transport.NewChainCredentialProvider
+	// to try several providers in turn. Clients of configs with the same
+	// provider share their transport, so create a provider once rather than
+	// for every client.
+	CredentialProvider transport.CredentialProvider
+
 	// TLSClientConfig contains settings to enable transport layer security
 	TLSClientConfig
 
@@ -127,6 +134,25 @@ type Config struct {
 	// If not set, the default warning handler is used.
 	WarningHandler WarningHandler
 
+	// RetryPolicy decides which failed requests are sent again, in addition
+	// to the requests the server asks to retry through Retry-After.
+	// If not set, no other requests are retried.
+	RetryPolicy RetryPolicy
+
+	// CircuitBreaker, if set, makes the client reject requests to hosts that
+	// keep failing them with 429 or 5xx responses or timeouts, instead of
+	// adding to their load.
+	CircuitBreaker *transport.CircuitBreakerConfig
+
+	// Tracer, if set, records spans for the requests of the client, e.g. the
+	// time spent waiting for the rate limiter, sending requests and decoding
+	// responses.
+	Tracer Tracer
+
+	// Hedging, if set, spreads requests over several endpoints of the server
+	// and sends slow read requests to a second endpoint as well.
+	Hedging *HedgingConfig
+
 	// The maximum length of time to wait before giving up on a server request. A value of zero means no timeout.
 	Timeout time.Duration
 
@@ -159,6 +185,15 @@ func (sanitizedAuthConfigPersister) String() string {
 	return "rest.AuthProviderConfigPersister(--- REDACTED ---)"
 }
 
+type sanitizedCredentialProvider struct{ transport.CredentialProvider }
+
+func (sanitizedCredentialProvider) GoString() string {
+	return "transport.CredentialProvider(--- REDACTED ---)"
+}
+func (sanitizedCredentialProvider) String() string {
+	return "transport.CredentialProvider(--- REDACTED ---)"
+}
+
 // GoString implements fmt.GoStringer and sanitizes sensitive fields of Config
 // to prevent accidental leaking via logs.
 func (c *Config) GoString() string {
@@ -182,6 +217,9 @@ func (c *Config) String() string {
 	if cc.AuthConfigPersister != nil {
 		cc.AuthConfigPersister = sanitizedAuthConfigPersister{cc.AuthConfigPersister}
 	}
+	if cc.CredentialProvider != nil {
+		cc.CredentialProvider = sanitizedCredentialProvider{cc.CredentialProvider}
+	}
 
 	return fmt.Sprintf("%#v", cc)
 }
@@ -347,6 +385,10 @@ func RESTClientFor(config *Config) (*RESTClient, error) {
 	if err == nil && config.WarningHandler != nil {
 		restClient.warningHandler = config.WarningHandler
 	}
+	if err == nil {
+		restClient.retryPolicy = config.RetryPolicy
+		restClient.tracer = config.Tracer
+	}
 	return restClient, err
 }
 
@@ -405,6 +447,10 @@ func UnversionedRESTClientFor(config *Config) (*RESTClient, error) {
 	if err == nil && config.WarningHandler != nil {
 		restClient.warningHandler = config.WarningHandler
 	}
+	if err == nil {
+		restClient.retryPolicy = config.RetryPolicy
+		restClient.tracer = config.Tracer
+	}
 	return restClient, err
 }
 
@@ -559,7 +605,7 @@ func AddUserAgent(config *Config, userAgent string) *Config {
 	return config
 }
 
-// AnonymousClientConfig returns a copy of the given config with all user credentials (cert/key, bearer token, and username/password) and custom transports (WrapTransport, Transport) removed
+// AnonymousClientConfig returns a copy of the given config with all user credentials (cert/key, bearer token, username/password, and credential provider) and custom transports (WrapTransport, Transport) removed
 func AnonymousClientConfig(config *Config) *Config {
 	// copy only known safe fields
 	return &Config{
@@ -575,6 +621,10 @@ func AnonymousClientConfig(config *Config) *Config {
 		},
 		RateLimiter:        config.RateLimiter,
 		WarningHandler:     config.WarningHandler,
+		RetryPolicy:        config.RetryPolicy,
+		CircuitBreaker:     config.CircuitBreaker,
+		Tracer:             config.Tracer,
+		Hedging:            config.Hedging,
 		UserAgent:          config.UserAgent,
 		DisableCompression: config.DisableCompression,
 		QPS:                config.QPS,
@@ -603,6 +653,7 @@ func CopyConfig(config *Config) *Config {
 		AuthProvider:        config.AuthProvider,
 		AuthConfigPersister: config.AuthConfigPersister,
 		ExecProvider:        config.ExecProvider,
+		CredentialProvider:  config.CredentialProvider,
 		TLSClientConfig: TLSClientConfig{
 			Insecure:   config.TLSClientConfig.Insecure,
 			ServerName: config.TLSClientConfig.ServerName,
@@ -622,6 +673,10 @@ func CopyConfig(config *Config) *Config {
 		Burst:              config.Burst,
 		RateLimiter:        config.RateLimiter,
 		WarningHandler:     config.WarningHandler,
+		RetryPolicy:        config.RetryPolicy,
+		CircuitBreaker:     config.CircuitBreaker,
+		Tracer:             config.Tracer,
+		Hedging:            config.Hedging,
 		Timeout:            config.Timeout,
 		Dial:               config.Dial,
 		Proxy:              config.Proxy,
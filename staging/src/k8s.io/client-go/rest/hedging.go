@@ -0,0 +1,387 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/transport"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultHedgingPercentile = 0.95
+	defaultHedgingMinDelay   = 10 * time.Millisecond
+	defaultHedgingMaxDelay   = time.Second
+
+	// hedgingLatencyWindow is the number of recent latencies the hedge
+	// delay is computed from.
+	hedgingLatencyWindow = 100
+	// hedgingMinSamples is the number of latencies that need to be observed
+	// before the hedge delay is computed from them rather than MaxDelay.
+	hedgingMinSamples = 10
+)
+
+// HedgingConfig configures a client to spread its requests over several
+// endpoints of the same API server, and to send a second, hedged request to
+// another endpoint when a read request takes longer than most.
+type HedgingConfig struct {
+	// Hosts are further endpoints of the API server at Config.Host, in the
+	// same form. They must serve the same paths as Config.Host; hosts
+	// without a scheme use the scheme of Config.Host.
+	Hosts []string
+
+	// Percentile of the latencies of recent read requests after which a
+	// hedged request is sent, between 0 and 1. Defaults to 0.95.
+	Percentile float64
+
+	// MinDelay and MaxDelay bound the time before a hedged request is sent.
+	// MaxDelay is used until enough requests were observed. They default to
+	// 10ms and 1s.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// newHedgingWrapper returns a wrapper that adds hedging as configured by
+// config to a transport.
+func newHedgingWrapper(config *HedgingConfig) (transport.WrapperFunc, error) {
+	endpoints := make([]*url.URL, 0, len(config.Hosts))
+	for _, host := range config.Hosts {
+		u, err := parseHedgingHost(host)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, u)
+	}
+	backoff := &URLBackoff{Backoff: flowcontrol.NewBackOff(time.Second, 30*time.Second)}
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return newHedgingRoundTripper(config, endpoints, backoff, clock.RealClock{}, rt)
+	}, nil
+}
+
+// parseHedgingHost parses a host given as a URL or as host:port.
+func parseHedgingHost(host string) (*url.URL, error) {
+	if !strings.Contains(host, "://") {
+		host = "//" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hedging host %q: %v", host, err)
+	}
+	if len(u.Host) == 0 {
+		return nil, fmt.Errorf("hedging host %q has no host name", host)
+	}
+	return u, nil
+}
+
+// hedgingRoundTripper sends requests to the healthiest of several endpoints,
+// and hedges read requests by sending them to a second endpoint if the first
+// takes too long. Endpoints are unhealthy while they are backed off by a
+// URLBackoff for failing requests.
+type hedgingRoundTripper struct {
+	// endpoints are the endpoints besides the one of the request.
+	endpoints  []*url.URL
+	backoff    *URLBackoff
+	clock      clock.Clock
+	percentile float64
+	minDelay   time.Duration
+	maxDelay   time.Duration
+	rt         http.RoundTripper
+
+	lock sync.Mutex
+	// latencies is a ring of the latencies of recent read requests.
+	latencies []time.Duration
+	next      int
+}
+
+func newHedgingRoundTripper(config *HedgingConfig, endpoints []*url.URL, backoff *URLBackoff, c clock.Clock, rt http.RoundTripper) *hedgingRoundTripper {
+	h := &hedgingRoundTripper{
+		endpoints:  endpoints,
+		backoff:    backoff,
+		clock:      c,
+		percentile: config.Percentile,
+		minDelay:   config.MinDelay,
+		maxDelay:   config.MaxDelay,
+		rt:         rt,
+	}
+	if h.percentile <= 0 || h.percentile > 1 {
+		h.percentile = defaultHedgingPercentile
+	}
+	if h.minDelay <= 0 {
+		h.minDelay = defaultHedgingMinDelay
+	}
+	if h.maxDelay <= 0 {
+		h.maxDelay = defaultHedgingMaxDelay
+	}
+	if h.maxDelay < h.minDelay {
+		h.maxDelay = h.minDelay
+	}
+	return h
+}
+
+func (h *hedgingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	targets := h.targets(req.URL)
+	if len(targets) < 2 || !isHedgeable(req) {
+		return h.send(req.Clone(req.Context()), targets[0])
+	}
+	return h.hedge(req, targets[0], targets[1])
+}
+
+// CancelRequest cancels req in the wrapped round tripper. The requests sent
+// for req are also cancelled with the context of req.
+func (h *hedgingRoundTripper) CancelRequest(req *http.Request) {
+	tryCancelRequest(h.rt, req)
+}
+
+func (h *hedgingRoundTripper) WrappedRoundTripper() http.RoundTripper { return h.rt }
+
+// tryCancelRequest cancels req in the first round tripper of the chain
+// starting with rt that supports it.
+func tryCancelRequest(rt http.RoundTripper, req *http.Request) {
+	type canceler interface {
+		CancelRequest(*http.Request)
+	}
+	switch rt := rt.(type) {
+	case canceler:
+		rt.CancelRequest(req)
+	case utilnet.RoundTripperWrapper:
+		tryCancelRequest(rt.WrappedRoundTripper(), req)
+	default:
+		klog.Warningf("Unable to cancel request for %T", rt)
+	}
+}
+
+// targets returns the endpoints for a request to u, the healthy ones first.
+// Endpoints with the same scheme and host as u are left out, u stands for
+// them.
+func (h *hedgingRoundTripper) targets(u *url.URL) []*url.URL {
+	now := h.backoff.Backoff.Clock.Now()
+	var healthy, unhealthy []*url.URL
+	for i, endpoint := range append([]*url.URL{u}, h.endpoints...) {
+		if i > 0 && endpoint.Host == u.Host && (len(endpoint.Scheme) == 0 || endpoint.Scheme == u.Scheme) {
+			continue
+		}
+		if h.backoff.Backoff.IsInBackOffSinceUpdate(h.backoff.baseUrlKey(endpoint), now) {
+			unhealthy = append(unhealthy, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+
+// isHedgeable returns true for requests that can be sent twice without
+// harm, and whose latency is that of a single response.
+func isHedgeable(req *http.Request) bool {
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	if len(req.Header.Get("Upgrade")) > 0 {
+		return false
+	}
+	switch req.URL.Query().Get("watch") {
+	case "true", "1":
+		return false
+	}
+	return !strings.Contains(req.URL.Path, "/watch/")
+}
+
+// send sends req to target and records the outcome in the health of target.
+// req must be a copy the caller owns.
+func (h *hedgingRoundTripper) send(req *http.Request, target *url.URL) (*http.Response, error) {
+	if len(target.Scheme) > 0 {
+		req.URL.Scheme = target.Scheme
+	}
+	req.URL.Host = target.Host
+	req.Host = ""
+
+	resp, err := h.rt.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// Requests that were cancelled say nothing about the endpoint.
+	case err != nil:
+		h.backoff.Backoff.Next(h.backoff.baseUrlKey(target), h.backoff.Backoff.Clock.Now())
+	default:
+		h.backoff.UpdateBackoff(target, nil, resp.StatusCode)
+	}
+	return resp, err
+}
+
+// hedgeResult is the outcome of one of the requests of a hedged request.
+type hedgeResult struct {
+	// attempt is the index of the request, 0 for the primary one.
+	attempt int
+	resp    *http.Response
+	err     error
+	cancel  context.CancelFunc
+	latency time.Duration
+}
+
+// failed returns true if the response of another endpoint is worth waiting
+// for.
+func (r hedgeResult) failed() bool {
+	return r.err != nil || r.resp.StatusCode >= http.StatusInternalServerError || r.resp.StatusCode == http.StatusTooManyRequests
+}
+
+// hedge sends req to primary, and to secondary as well if primary doesn't
+// respond within the hedge delay or fails before it. The first successful
+// response is returned and the other request is cancelled.
+func (h *hedgingRoundTripper) hedge(req *http.Request, primary, secondary *url.URL) (*http.Response, error) {
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	attempt := func(target *url.URL) {
+		ctx, cancel := context.WithCancel(req.Context())
+		i := len(cancels)
+		cancels = append(cancels, cancel)
+		r := req.Clone(ctx)
+		go func() {
+			start := h.clock.Now()
+			resp, err := h.send(r, target)
+			results <- hedgeResult{attempt: i, resp: resp, err: err, cancel: cancel, latency: h.clock.Since(start)}
+		}()
+	}
+
+	attempt(primary)
+	timer := h.clock.NewTimer(h.delay())
+	defer timer.Stop()
+
+	pending := 1
+	var failure *hedgeResult
+	for {
+		select {
+		case result := <-results:
+			pending--
+			if !result.failed() {
+				h.observe(result.latency)
+				// Cancelling the winner is deferred until its body is closed.
+				for i, cancel := range cancels {
+					if i != result.attempt {
+						cancel()
+					}
+				}
+				go discardHedgeResults(results, pending)
+				if failure != nil {
+					discardHedgeResult(*failure)
+				}
+				result.resp.Body = &cancelOnCloseBody{ReadCloser: result.resp.Body, cancel: result.cancel}
+				return result.resp, nil
+			}
+			if failure == nil {
+				failure = &result
+			} else {
+				discardHedgeResult(result)
+			}
+			if pending == 0 && len(cancels) == 1 && req.Context().Err() == nil {
+				// The primary failed before the hedge delay, so there is
+				// no reason to wait for it.
+				pending++
+				klog.V(4).Infof("Sending %s %s to %s after the primary failed", req.Method, req.URL.Path, secondary.Host)
+				attempt(secondary)
+				continue
+			}
+			if pending == 0 {
+				if failure.err != nil {
+					failure.cancel()
+					return nil, failure.err
+				}
+				failure.resp.Body = &cancelOnCloseBody{ReadCloser: failure.resp.Body, cancel: failure.cancel}
+				return failure.resp, nil
+			}
+		case <-timer.C():
+			if len(cancels) > 1 || pending == 0 || req.Context().Err() != nil {
+				continue
+			}
+			pending++
+			klog.V(4).Infof("Hedging %s %s to %s", req.Method, req.URL.Path, secondary.Host)
+			attempt(secondary)
+		}
+	}
+}
+
+// discardHedgeResults discards the responses of the requests that lost the
+// race.
+func discardHedgeResults(results <-chan hedgeResult, pending int) {
+	for i := 0; i < pending; i++ {
+		discardHedgeResult(<-results)
+	}
+}
+
+func discardHedgeResult(result hedgeResult) {
+	result.cancel()
+	if result.resp != nil {
+		result.resp.Body.Close()
+	}
+}
+
+// cancelOnCloseBody cancels the context of its request once it is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// observe records the latency of a read request.
+func (h *hedgingRoundTripper) observe(latency time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.latencies) < hedgingLatencyWindow {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % hedgingLatencyWindow
+}
+
+// delay returns how long to wait for a response before hedging a request.
+func (h *hedgingRoundTripper) delay() time.Duration {
+	h.lock.Lock()
+	if len(h.latencies) < hedgingMinSamples {
+		h.lock.Unlock()
+		return h.maxDelay
+	}
+	latencies := append([]time.Duration(nil), h.latencies...)
+	h.lock.Unlock()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	delay := latencies[int(h.percentile*float64(len(latencies)-1))]
+	if delay < h.minDelay {
+		return h.minDelay
+	}
+	if delay > h.maxDelay {
+		return h.maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,355 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// endpointRoundTripper answers requests to the hosts in responses, and
+// blocks requests to the hosts in slow until they are cancelled.
+type endpointRoundTripper struct {
+	slow map[string]bool
+	errs map[string]error
+
+	lock      sync.Mutex
+	hosts     []string
+	cancelled []string
+}
+
+func (rt *endpointRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	rt.lock.Lock()
+	rt.hosts = append(rt.hosts, host)
+	rt.lock.Unlock()
+
+	if rt.slow[host] {
+		<-req.Context().Done()
+		rt.lock.Lock()
+		rt.cancelled = append(rt.cancelled, host)
+		rt.lock.Unlock()
+		return nil, req.Context().Err()
+	}
+	if err := rt.errs[host]; err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(host)),
+		Request:    req,
+	}, nil
+}
+
+func (rt *endpointRoundTripper) sent() []string {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	return append([]string(nil), rt.hosts...)
+}
+
+func newTestHedgingRoundTripper(t *testing.T, fakeClock *clock.FakeClock, rt http.RoundTripper) *hedgingRoundTripper {
+	endpoint, err := parseHedgingHost("server-2:6443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backoff := &URLBackoff{Backoff: flowcontrol.NewFakeBackOff(time.Second, time.Minute, fakeClock)}
+	return newHedgingRoundTripper(&HedgingConfig{MaxDelay: 100 * time.Millisecond}, []*url.URL{endpoint}, backoff, fakeClock, rt)
+}
+
+func responseHost(t *testing.T, resp *http.Response, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestHedgingRoundTripperHedgesSlowReads(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	delegate := &endpointRoundTripper{slow: map[string]bool{"server-1:6443": true}}
+	rt := newTestHedgingRoundTripper(t, fakeClock, delegate)
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		req, _ := http.NewRequest("GET", "https://server-1:6443/api/v1/pods", nil)
+		resp, err := rt.RoundTrip(req)
+		done <- result{resp, err}
+	}()
+
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return fakeClock.HasWaiters(), nil
+	}); err != nil {
+		t.Fatalf("expected the request to wait for the hedge delay")
+	}
+	fakeClock.Step(100 * time.Millisecond)
+
+	r := <-done
+	if host := responseHost(t, r.resp, r.err); host != "server-2:6443" {
+		t.Errorf("expected the response of the second endpoint, got %q", host)
+	}
+	if e, a := "https://server-2:6443/api/v1/pods", r.resp.Request.URL.String(); e != a {
+		t.Errorf("expected the hedged request to go to %q, got %q", e, a)
+	}
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		delegate.lock.Lock()
+		defer delegate.lock.Unlock()
+		return len(delegate.cancelled) == 1, nil
+	}); err != nil {
+		t.Errorf("expected the slow request to be cancelled")
+	}
+}
+
+func TestHedgingRoundTripperDoesNotHedgeFastReads(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	delegate := &endpointRoundTripper{}
+	rt := newTestHedgingRoundTripper(t, fakeClock, delegate)
+
+	req, _ := http.NewRequest("GET", "https://server-1:6443/api/v1/pods", nil)
+	resp, err := rt.RoundTrip(req)
+	if host := responseHost(t, resp, err); host != "server-1:6443" {
+		t.Errorf("expected the response of the first endpoint, got %q", host)
+	}
+	if sent := delegate.sent(); len(sent) != 1 {
+		t.Errorf("expected a single request, got %v", sent)
+	}
+}
+
+func TestHedgingRoundTripperAvoidsUnhealthyEndpoints(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	delegate := &endpointRoundTripper{errs: map[string]error{"server-1:6443": errors.New("connection refused")}}
+	rt := newTestHedgingRoundTripper(t, fakeClock, delegate)
+
+	post := func() (*http.Response, error) {
+		req, _ := http.NewRequest("POST", "https://server-1:6443/api/v1/namespaces/default/pods", strings.NewReader("{}"))
+		return rt.RoundTrip(req)
+	}
+	if _, err := post(); err == nil {
+		t.Fatalf("expected the error of the first endpoint")
+	}
+	resp, err := post()
+	if host := responseHost(t, resp, err); host != "server-2:6443" {
+		t.Errorf("expected the request to go to the healthy endpoint, got %q", host)
+	}
+
+	// The endpoint is tried again once its backoff expired.
+	delegate.errs = nil
+	fakeClock.Step(2 * time.Second)
+	resp, err = post()
+	if host := responseHost(t, resp, err); host != "server-1:6443" {
+		t.Errorf("expected the request to go to the first endpoint again, got %q", host)
+	}
+}
+
+func TestHedgingDelay(t *testing.T) {
+	rt := newHedgingRoundTripper(&HedgingConfig{MinDelay: 5 * time.Millisecond, MaxDelay: time.Second}, nil, nil, clock.RealClock{}, nil)
+	if e, a := time.Second, rt.delay(); e != a {
+		t.Errorf("expected MaxDelay %v without enough samples, got %v", e, a)
+	}
+	for i := 1; i <= 200; i++ {
+		rt.observe(time.Duration(i%100+1) * time.Millisecond)
+	}
+	if e, a := 95*time.Millisecond, rt.delay(); e != a {
+		t.Errorf("expected the 95th percentile %v, got %v", e, a)
+	}
+	for i := 0; i < 100; i++ {
+		rt.observe(time.Millisecond)
+	}
+	if e, a := 5*time.Millisecond, rt.delay(); e != a {
+		t.Errorf("expected MinDelay %v, got %v", e, a)
+	}
+}
+
+func TestIsHedgeable(t *testing.T) {
+	testCases := []struct {
+		method string
+		url    string
+		header http.Header
+		expect bool
+	}{
+		{method: "GET", url: "https://server/api/v1/pods", expect: true},
+		{method: "GET", url: "https://server/api/v1/pods?watch=true"},
+		{method: "GET", url: "https://server/api/v1/watch/pods"},
+		{method: "GET", url: "https://server/api/v1/namespaces/a/pods/b/exec", header: http.Header{"Upgrade": []string{"SPDY/3.1"}}},
+		{method: "POST", url: "https://server/api/v1/pods"},
+	}
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(tc.method, tc.url, nil)
+		if tc.header != nil {
+			req.Header = tc.header
+		}
+		if e, a := tc.expect, isHedgeable(req); e != a {
+			t.Errorf("%s %s: expected hedgeable %v, got %v", tc.method, tc.url, e, a)
+		}
+	}
+}
+
+// trackedBody records whether it was closed.
+type trackedBody struct {
+	io.Reader
+	lock   sync.Mutex
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.closed = true
+	return nil
+}
+
+func (b *trackedBody) isClosed() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.closed
+}
+
+func TestHedgingRoundTripperClosesFailedResponse(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	failedBody := &trackedBody{Reader: strings.NewReader("failed")}
+	releasePrimary, releaseSecondary := make(chan struct{}), make(chan struct{})
+	delegate := clientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "server-1:6443" {
+			<-releasePrimary
+			return &http.Response{StatusCode: http.StatusInternalServerError, Body: failedBody, Request: req}, nil
+		}
+		<-releaseSecondary
+		return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(req.URL.Host)), Request: req}, nil
+	})
+	rt := newTestHedgingRoundTripper(t, fakeClock, delegate)
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	done := make(chan result)
+	go func() {
+		req, _ := http.NewRequest("GET", "https://server-1:6443/api/v1/pods", nil)
+		resp, err := rt.RoundTrip(req)
+		done <- result{resp, err}
+	}()
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return fakeClock.HasWaiters(), nil
+	}); err != nil {
+		t.Fatalf("expected the request to wait for the hedge delay")
+	}
+	fakeClock.Step(100 * time.Millisecond)
+
+	// The primary request fails first, then the hedged request succeeds.
+	close(releasePrimary)
+	time.Sleep(100 * time.Millisecond)
+	close(releaseSecondary)
+
+	r := <-done
+	if host := responseHost(t, r.resp, r.err); host != "server-2:6443" {
+		t.Errorf("expected the response of the second endpoint, got %q", host)
+	}
+	if !failedBody.isClosed() {
+		t.Errorf("expected the body of the failed response to be closed")
+	}
+}
+
+func TestHedgingRoundTripperHedgesFailedPrimaryImmediately(t *testing.T) {
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("secondary"))
+	}))
+	defer secondary.Close()
+	// The primary refuses connections.
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refused.Close()
+
+	endpoint, err := url.Parse(secondary.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backoff := &URLBackoff{Backoff: flowcontrol.NewBackOff(time.Second, time.Minute)}
+	rt := newHedgingRoundTripper(&HedgingConfig{MinDelay: time.Minute, MaxDelay: time.Minute}, []*url.URL{endpoint}, backoff, clock.RealClock{}, &http.Transport{})
+
+	req, _ := http.NewRequest("GET", refused.URL+"/api/v1/pods", nil)
+	start := time.Now()
+	resp, err := rt.RoundTrip(req)
+	if host := responseHost(t, resp, err); host != "secondary" {
+		t.Errorf("expected the response of the secondary endpoint, got %q", host)
+	}
+	if elapsed := time.Since(start); elapsed >= wait.ForeverTestTimeout {
+		t.Errorf("expected the request to go to the secondary endpoint without waiting for the hedge delay, took %v", elapsed)
+	}
+}
+
+// cancelingRoundTripper records the requests it was asked to cancel.
+type cancelingRoundTripper struct {
+	http.RoundTripper
+	cancelled []*http.Request
+}
+
+func (rt *cancelingRoundTripper) CancelRequest(req *http.Request) {
+	rt.cancelled = append(rt.cancelled, req)
+}
+
+func TestHedgingRoundTripperCancelRequest(t *testing.T) {
+	delegate := &cancelingRoundTripper{RoundTripper: &endpointRoundTripper{}}
+	rt := newTestHedgingRoundTripper(t, clock.NewFakeClock(time.Now()), delegate)
+	req, _ := http.NewRequest("GET", "https://server-1:6443/api/v1/pods", nil)
+	rt.CancelRequest(req)
+	if len(delegate.cancelled) != 1 || delegate.cancelled[0] != req {
+		t.Errorf("expected the request to be cancelled by the wrapped round tripper, got %v", delegate.cancelled)
+	}
+}
+
+func TestHedgingRoundTripperTargetsSkipRequestHost(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	var endpoints []*url.URL
+	for _, host := range []string{"server-1:6443", "https://server-2:6443", "http://server-1:6443"} {
+		endpoint, err := parseHedgingHost(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	backoff := &URLBackoff{Backoff: flowcontrol.NewFakeBackOff(time.Second, time.Minute, fakeClock)}
+	rt := newHedgingRoundTripper(&HedgingConfig{}, endpoints, backoff, fakeClock, &endpointRoundTripper{})
+
+	u, _ := url.Parse("https://server-1:6443/api")
+	var got []string
+	for _, target := range rt.targets(u) {
+		got = append(got, target.String())
+	}
+	if e := []string{"https://server-1:6443/api", "https://server-2:6443", "http://server-1:6443"}; !reflect.DeepEqual(e, got) {
+		t.Errorf("expected targets %v, got %v", e, got)
+	}
+}
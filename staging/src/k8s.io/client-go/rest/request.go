@@ -89,6 +89,8 @@ type Request struct {
 	c *RESTClient
 
 	warningHandler WarningHandler
+	retryPolicy    RetryPolicy
+	tracer         Tracer
 
 	rateLimiter flowcontrol.RateLimiter
 	backoff     BackoffManager
@@ -144,6 +146,8 @@ func NewRequest(c *RESTClient) *Request {
 		pathPrefix:     pathPrefix,
 		maxRetries:     10,
 		warningHandler: c.warningHandler,
+		retryPolicy:    c.retryPolicy,
+		tracer:         c.tracer,
 	}
 
 	switch {
@@ -228,6 +232,20 @@ func (r *Request) WarningHandler(handler WarningHandler) *Request {
 	return r
 }
 
+// RetryPolicy sets the policy that decides which failed requests are sent
+// again. If set to nil, requests are only retried on Retry-After responses.
+func (r *Request) RetryPolicy(policy RetryPolicy) *Request {
+	r.retryPolicy = policy
+	return r
+}
+
+// Tracer sets the tracer that records the spans of this request. If set to
+// nil, no spans are recorded.
+func (r *Request) Tracer(tracer Tracer) *Request {
+	r.tracer = tracer
+	return r
+}
+
 // Throttle receives a rate-limiter and sets or replaces an existing request limiter
 func (r *Request) Throttle(limiter flowcontrol.RateLimiter) *Request {
 	r.rateLimiter = limiter
@@ -574,7 +592,9 @@ func (r *Request) tryThrottle(ctx context.Context) error {
 
 	now := time.Now()
 
+	ctx, span := r.startSpan(ctx, SpanThrottle)
 	err := r.rateLimiter.Wait(ctx)
+	span.End(err)
 
 	latency := time.Since(now)
 	if latency > longThrottleLatency {
@@ -655,6 +675,13 @@ func (r *Request) Watch(ctx context.Context) (watch.Interface, error) {
 		return nil, r.err
 	}
 
+	ctx, span := r.startSpan(ctx, SpanWatch)
+	w, err := r.watch(ctx)
+	span.End(err)
+	return w, err
+}
+
+func (r *Request) watch(ctx context.Context) (watch.Interface, error) {
 	url := r.URL().String()
 	req, err := http.NewRequest(r.verb, url, r.body)
 	if err != nil {
@@ -667,7 +694,7 @@ func (r *Request) Watch(ctx context.Context) (watch.Interface, error) {
 		client = http.DefaultClient
 	}
 	r.backoff.Sleep(r.backoff.CalculateBackoff(r.URL()))
-	resp, err := client.Do(req)
+	resp, err := r.roundTrip(ctx, client, req, 0)
 	updateURLMetrics(r, resp, err)
 	if r.c.base != nil {
 		if err != nil {
@@ -742,6 +769,13 @@ func (r *Request) Stream(ctx context.Context) (io.ReadCloser, error) {
 		return nil, r.err
 	}
 
+	ctx, span := r.startSpan(ctx, SpanStream)
+	stream, err := r.stream(ctx)
+	span.End(err)
+	return stream, err
+}
+
+func (r *Request) stream(ctx context.Context) (io.ReadCloser, error) {
 	if err := r.tryThrottle(ctx); err != nil {
 		return nil, err
 	}
@@ -761,7 +795,7 @@ func (r *Request) Stream(ctx context.Context) (io.ReadCloser, error) {
 		client = http.DefaultClient
 	}
 	r.backoff.Sleep(r.backoff.CalculateBackoff(r.URL()))
-	resp, err := client.Do(req)
+	resp, err := r.roundTrip(ctx, client, req, 0)
 	updateURLMetrics(r, resp, err)
 	if r.c.base != nil {
 		if err != nil {
@@ -858,7 +892,9 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 	}
 
 	// Right now we make about ten retry attempts if we get a Retry-After response.
-	retries := 0
+	// Retries decided by the retry policy are counted separately, so that
+	// they don't use up the Retry-After retries and vice versa.
+	retries, policyRetries := 0, 0
 	for {
 
 		url := r.URL().String()
@@ -870,7 +906,7 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 		req.Header = r.headers
 
 		r.backoff.Sleep(r.backoff.CalculateBackoff(r.URL()))
-		if retries > 0 {
+		if retries+policyRetries > 0 {
 			// We are retrying the request that we already send to apiserver
 			// at least once before.
 			// This request should also be throttled with the client-internal rate limiter.
@@ -878,13 +914,17 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 				return err
 			}
 		}
-		resp, err := client.Do(req)
+		resp, err := r.roundTrip(ctx, client, req, retries+policyRetries)
 		updateURLMetrics(r, resp, err)
 		if err != nil {
 			r.backoff.UpdateBackoff(r.URL(), err, 0)
 		} else {
 			r.backoff.UpdateBackoff(r.URL(), err, resp.StatusCode)
 		}
+		if err != nil && r.retryWithPolicy(ctx, req, nil, err, policyRetries) {
+			policyRetries++
+			continue
+		}
 		if err != nil {
 			// "Connection reset by peer" or "apiserver is shutting down" are usually a transient errors.
 			// Thus in case of "GET" operations, we simply retry it.
@@ -919,8 +959,8 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 				resp.Body.Close()
 			}()
 
-			retries++
-			if seconds, wait := checkWait(resp); wait && retries <= r.maxRetries {
+			if seconds, wait := checkWait(resp); wait && retries < r.maxRetries {
+				retries++
 				if seeker, ok := r.body.(io.Seeker); ok && r.body != nil {
 					_, err := seeker.Seek(0, 0)
 					if err != nil {
@@ -931,7 +971,11 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 				}
 
 				klog.V(4).Infof("Got a Retry-After %ds response for attempt %d to %v", seconds, retries, url)
-				r.backoff.Sleep(time.Duration(seconds) * time.Second)
+				r.sleepBeforeRetry(ctx, retries, time.Duration(seconds)*time.Second)
+				return false
+			}
+			if r.retryWithPolicy(ctx, req, resp, nil, policyRetries) {
+				policyRetries++
 				return false
 			}
 			fn(req, resp)
@@ -943,6 +987,68 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 	}
 }
 
+// retryWithPolicy asks the retry policy whether req should be sent again
+// after the given outcome, and if so rewinds the body and waits before
+// returning true. retries is the number of times req was sent again already.
+func (r *Request) retryWithPolicy(ctx context.Context, req *http.Request, resp *http.Response, err error, retries int) bool {
+	if r.retryPolicy == nil || !r.isReplayable() {
+		return false
+	}
+	retry, delay := r.retryPolicy.ShouldRetry(req, resp, err, retries)
+	if !retry {
+		return false
+	}
+	if seeker, ok := r.body.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, 0); err != nil {
+			klog.V(4).Infof("Could not retry request, can't Seek() back to beginning of body for %T", r.body)
+			return false
+		}
+	}
+	if err != nil {
+		klog.V(4).Infof("Retrying request to %v after error %v in %v", req.URL, err, delay)
+	} else {
+		klog.V(4).Infof("Retrying request to %v after %d response in %v", req.URL, resp.StatusCode, delay)
+	}
+	r.sleepBeforeRetry(ctx, retries+1, delay)
+	return true
+}
+
+// sleepBeforeRetry waits for delay before the request is sent again for the
+// given time.
+func (r *Request) sleepBeforeRetry(ctx context.Context, retry int, delay time.Duration) {
+	_, span := r.startSpan(ctx, SpanRetryWait)
+	span.SetAttribute("retry", retry)
+	r.backoff.Sleep(delay)
+	span.End(nil)
+}
+
+// roundTrip sends req through client. retries is the number of times req
+// was sent before.
+func (r *Request) roundTrip(ctx context.Context, client *http.Client, req *http.Request, retries int) (*http.Response, error) {
+	ctx, span := r.startSpan(ctx, SpanRoundTrip)
+	span.SetAttribute("retries", retries)
+	resp, err := client.Do(req.WithContext(ctx))
+	if resp != nil {
+		span.SetAttribute("statusCode", resp.StatusCode)
+	}
+	if limiter, ok := r.rateLimiter.(flowcontrol.AdaptiveRateLimiter); ok {
+		limiter.Observe(resp, err)
+	}
+	span.End(err)
+	return resp, err
+}
+
+// isReplayable returns true if the request can be sent again as it was sent
+// before: it has no body, or its body can be rewound. This holds for every
+// verb, a body read by the first attempt would be missing from the next.
+func (r *Request) isReplayable() bool {
+	if r.body == nil {
+		return true
+	}
+	_, ok := r.body.(io.Seeker)
+	return ok
+}
+
 // Do formats and executes the request. Returns a Result object for easy response
 // processing.
 //
@@ -950,20 +1056,30 @@ func (r *Request) request(ctx context.Context, fn func(*http.Request, *http.Resp
 //  * If the server responds with a status: *errors.StatusError or *errors.UnexpectedObjectError
 //  * http.Client.Do errors are returned directly.
 func (r *Request) Do(ctx context.Context) Result {
+	ctx, span := r.startSpan(ctx, SpanDo)
 	var result Result
 	err := r.request(ctx, func(req *http.Request, resp *http.Response) {
 		result = r.transformResponse(resp, req)
 	})
 	if err != nil {
+		span.End(err)
 		return Result{err: err}
 	}
+	span.End(result.err)
+	if r.tracer != nil {
+		result.ctx = ctx
+		result.tracer = r.tracer
+	}
 	return result
 }
 
 // DoRaw executes the request but does not process the response body.
-func (r *Request) DoRaw(ctx context.Context) ([]byte, error) {
+func (r *Request) DoRaw(ctx context.Context) (body []byte, err error) {
+	ctx, span := r.startSpan(ctx, SpanDo)
+	defer func() { span.End(err) }()
+
 	var result Result
-	err := r.request(ctx, func(req *http.Request, resp *http.Response) {
+	err = r.request(ctx, func(req *http.Request, resp *http.Response) {
 		result.body, result.err = ioutil.ReadAll(resp.Body)
 		glogBody("Response Body", result.body)
 		if resp.StatusCode < http.StatusOK || resp.StatusCode > http.StatusPartialContent {
@@ -1203,6 +1319,10 @@ type Result struct {
 	statusCode  int
 
 	decoder runtime.Decoder
+
+	// ctx holds the span of the request, if it was traced by tracer.
+	ctx    context.Context
+	tracer Tracer
 }
 
 // Raw returns the raw result.
@@ -1223,7 +1343,7 @@ func (r Result) Get() (runtime.Object, error) {
 	}
 
 	// decode, but if the result is Status return that as an error instead.
-	out, _, err := r.decoder.Decode(r.body, nil, nil)
+	out, err := r.decode(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1237,6 +1357,19 @@ func (r Result) Get() (runtime.Object, error) {
 	return out, nil
 }
 
+// decode decodes the body into obj, if not nil, in a span.
+func (r Result) decode(obj runtime.Object) (runtime.Object, error) {
+	if r.tracer == nil {
+		out, _, err := r.decoder.Decode(r.body, nil, obj)
+		return out, err
+	}
+	_, span := r.tracer.Start(r.ctx, SpanDecode)
+	span.SetAttribute("bytes", len(r.body))
+	out, _, err := r.decoder.Decode(r.body, nil, obj)
+	span.End(err)
+	return out, err
+}
+
 // StatusCode returns the HTTP status code of the request. (Only valid if no
 // error was returned.)
 func (r Result) StatusCode(statusCode *int) Result {
@@ -1260,7 +1393,7 @@ func (r Result) Into(obj runtime.Object) error {
 			r.statusCode, r.contentType)
 	}
 
-	out, _, err := r.decoder.Decode(r.body, nil, obj)
+	out, err := r.decode(obj)
 	if err != nil || out == obj {
 		return err
 	}
@@ -2278,3 +2278,40 @@ func TestRequestMaxRetries(t *testing.T) {
 		})
 	}
 }
+
+type fakeAdaptiveRateLimiter struct {
+	flowcontrol.RateLimiter
+	observed []int
+}
+
+func (f *fakeAdaptiveRateLimiter) Observe(resp *http.Response, err error) {
+	f.observed = append(f.observed, resp.StatusCode)
+}
+
+func TestRequestObservesAdaptiveRateLimiter(t *testing.T) {
+	count := 0
+	limiter := &fakeAdaptiveRateLimiter{RateLimiter: flowcontrol.NewFakeAlwaysRateLimiter()}
+	c := &RESTClient{
+		Client: clientForFunc(func(req *http.Request) (*http.Response, error) {
+			count++
+			status := http.StatusOK
+			header := http.Header{}
+			if count == 1 {
+				status = http.StatusTooManyRequests
+				header.Set("Retry-After", "0")
+			}
+			return &http.Response{
+				StatusCode: status,
+				Header:     header,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte{})),
+			}, nil
+		}),
+		rateLimiter: limiter,
+	}
+	if _, err := NewRequest(c).Verb("GET").DoRaw(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []int{http.StatusTooManyRequests, http.StatusOK}, limiter.observed; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// RetryPolicy decides whether a request that failed is sent again.
+//
+// Whatever the policy decides, a request is only ever sent again if its body
+// can be rewound, i.e. it has no body or a body that implements io.Seeker,
+// as bodies set with Body from a byte slice, a file or an object do. Policy
+// retries are counted separately from the retries after Retry-After
+// responses, which are limited by MaxRetries.
+type RetryPolicy interface {
+	// ShouldRetry classifies the outcome of sending req, which is either
+	// the response or the error returned by the transport, and returns
+	// whether req should be sent again and how long to wait before doing
+	// so. retries is the number of times req has been sent again already.
+	ShouldRetry(req *http.Request, resp *http.Response, err error, retries int) (bool, time.Duration)
+}
+
+// NewTransientErrorRetryPolicy returns a RetryPolicy that retries requests
+// up to maxRetries times after transient errors. Requests with a safe verb are
+// retried after connection resets, unexpected EOFs and 5xx responses other
+// than 501 Not Implemented. Requests with other verbs might have been
+// processed by the server in those cases, so they are only retried after 503
+// Service Unavailable responses.
+//
+// The delays grow with every retry of a request, independently of the other
+// requests: the first retry waits backoff.Duration, and every further retry
+// waits backoff.Factor times longer, up to backoff.Cap, with backoff.Jitter
+// applied. backoff.Steps is ignored. If backoff.Duration is zero, retries
+// start after one second and double up to ten seconds.
+func NewTransientErrorRetryPolicy(maxRetries int, backoff wait.Backoff) RetryPolicy {
+	if backoff.Duration <= 0 {
+		backoff = wait.Backoff{
+			Duration: time.Second,
+			Factor:   2,
+			Jitter:   0.1,
+			Cap:      10 * time.Second,
+		}
+	}
+	return &transientErrorRetryPolicy{
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}
+}
+
+type transientErrorRetryPolicy struct {
+	maxRetries int
+	backoff    wait.Backoff
+}
+
+func (p *transientErrorRetryPolicy) ShouldRetry(req *http.Request, resp *http.Response, err error, retries int) (bool, time.Duration) {
+	if retries >= p.maxRetries || !isTransientError(req, resp, err) {
+		return false, 0
+	}
+	return true, p.delay(retries)
+}
+
+// delay returns how long to wait before sending a request again that was
+// sent again retries times already.
+func (p *transientErrorRetryPolicy) delay(retries int) time.Duration {
+	delay := p.backoff.Duration
+	for i := 0; i < retries && p.backoff.Factor > 0; i++ {
+		delay = time.Duration(float64(delay) * p.backoff.Factor)
+		if p.backoff.Cap > 0 && delay > p.backoff.Cap {
+			delay = p.backoff.Cap
+			break
+		}
+	}
+	if p.backoff.Jitter > 0 {
+		delay = wait.Jitter(delay, p.backoff.Jitter)
+	}
+	return delay
+}
+
+// isTransientError returns true if the outcome of sending req is a failure
+// that is worth retrying.
+func isTransientError(req *http.Request, resp *http.Response, err error) bool {
+	safe := isSafeVerb(req.Method)
+	if err != nil {
+		return safe && (net.IsConnectionReset(err) || net.IsProbableEOF(err))
+	}
+	switch {
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		return true
+	case resp.StatusCode == http.StatusNotImplemented:
+		return false
+	default:
+		return safe && resp.StatusCode >= http.StatusInternalServerError
+	}
+}
+
+// isSafeVerb returns true for the HTTP methods that don't change anything on
+// the server.
+func isSafeVerb(verb string) bool {
+	switch verb {
+	case "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// testRetryBackoff keeps the retries of the request tests fast.
+var testRetryBackoff = wait.Backoff{Duration: time.Millisecond, Factor: 2}
+
+func TestTransientErrorRetryPolicy(t *testing.T) {
+	connReset := &net.OpError{Err: syscall.ECONNRESET}
+	testCases := []struct {
+		name    string
+		verb    string
+		status  int
+		err     error
+		retries int
+		retry   bool
+	}{
+		{name: "get 500", verb: "GET", status: http.StatusInternalServerError, retry: true},
+		{name: "get 501", verb: "GET", status: http.StatusNotImplemented, retry: false},
+		{name: "get 404", verb: "GET", status: http.StatusNotFound, retry: false},
+		{name: "get conn reset", verb: "GET", err: connReset, retry: true},
+		{name: "get unknown error", verb: "GET", err: io.ErrClosedPipe, retry: false},
+		{name: "get too many retries", verb: "GET", status: http.StatusServiceUnavailable, retries: 3, retry: false},
+		{name: "post 500", verb: "POST", status: http.StatusInternalServerError, retry: false},
+		{name: "post 503", verb: "POST", status: http.StatusServiceUnavailable, retry: true},
+		{name: "post conn reset", verb: "POST", err: connReset, retry: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := NewTransientErrorRetryPolicy(3, wait.Backoff{})
+			req, _ := http.NewRequest(tc.verb, "http://localhost/api", nil)
+			var resp *http.Response
+			if tc.err == nil {
+				resp = &http.Response{StatusCode: tc.status}
+			}
+			if retry, _ := policy.ShouldRetry(req, resp, tc.err, tc.retries); retry != tc.retry {
+				t.Errorf("expected retry %v, got %v", tc.retry, retry)
+			}
+		})
+	}
+}
+
+func TestTransientErrorRetryPolicyDelays(t *testing.T) {
+	policy := NewTransientErrorRetryPolicy(10, wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Cap: 50 * time.Millisecond})
+	req, _ := http.NewRequest("GET", "http://localhost/api", nil)
+	other, _ := http.NewRequest("GET", "http://localhost/api/other", nil)
+	connReset := &net.OpError{Err: syscall.ECONNRESET}
+
+	var delays []time.Duration
+	for retries := 0; retries < 5; retries++ {
+		retry, delay := policy.ShouldRetry(req, nil, connReset, retries)
+		if !retry {
+			t.Fatalf("expected retry %d after a connection reset", retries)
+		}
+		delays = append(delays, delay)
+		// The success of another request doesn't shorten the delays.
+		policy.ShouldRetry(other, &http.Response{StatusCode: http.StatusOK}, nil, 0)
+	}
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 50 * time.Millisecond, 50 * time.Millisecond}
+	if !reflect.DeepEqual(expected, delays) {
+		t.Errorf("expected delays %v, got %v", expected, delays)
+	}
+
+	// The default delays start at one second and grow.
+	policy = NewTransientErrorRetryPolicy(10, wait.Backoff{})
+	_, first := policy.ShouldRetry(req, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, 0)
+	_, second := policy.ShouldRetry(req, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, 1)
+	if first < time.Second || second <= first {
+		t.Errorf("expected growing delays of at least a second, got %v and %v", first, second)
+	}
+}
+
+type nonSeekableReader struct {
+	io.Reader
+}
+
+func TestRequestRetryPolicy(t *testing.T) {
+	body := strings.Repeat("abcd", 100)
+	testCases := []struct {
+		name          string
+		verb          string
+		body          interface{}
+		expectedCount int
+		expectErr     bool
+	}{
+		{name: "get", verb: "GET", expectedCount: 3},
+		{name: "post with rewindable body", verb: "POST", body: []byte(body), expectedCount: 3},
+		{name: "post with other body", verb: "POST", body: nonSeekableReader{strings.NewReader(body)}, expectedCount: 1, expectErr: true},
+		{name: "get with other body", verb: "GET", body: nonSeekableReader{strings.NewReader(body)}, expectedCount: 1, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count := 0
+			backoff := &testBackoffManager{}
+			c := &RESTClient{
+				Client: clientForFunc(func(req *http.Request) (*http.Response, error) {
+					count++
+					if req.Body != nil {
+						data, _ := ioutil.ReadAll(req.Body)
+						if string(data) != body {
+							t.Errorf("request %d did not send a complete body: %s", count, data)
+						}
+					}
+					status := http.StatusOK
+					if count < 3 {
+						status = http.StatusServiceUnavailable
+					}
+					return &http.Response{
+						StatusCode: status,
+						Header:     http.Header{},
+						Body:       ioutil.NopCloser(bytes.NewReader([]byte{})),
+					}, nil
+				}),
+				createBackoffMgr: func() BackoffManager { return backoff },
+				retryPolicy:      NewTransientErrorRetryPolicy(5, testRetryBackoff),
+			}
+			r := NewRequest(c).Verb(tc.verb)
+			if tc.body != nil {
+				r.Body(tc.body)
+			}
+			err := r.Do(context.Background()).Error()
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %v, got %v", tc.expectErr, err)
+			}
+			if count != tc.expectedCount {
+				t.Errorf("expected %d requests, got %d", tc.expectedCount, count)
+			}
+		})
+	}
+
+	// Without a policy, 503 responses without Retry-After are not retried.
+	count := 0
+	c := &RESTClient{
+		Client: clientForFunc(func(req *http.Request) (*http.Response, error) {
+			count++
+			return &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte{})),
+			}, nil
+		}),
+	}
+	NewRequest(c).Verb("GET").Do(context.Background())
+	if count != 1 {
+		t.Errorf("expected 1 request, got %d", count)
+	}
+}
+
+func TestRequestRetryPolicyCountsSeparately(t *testing.T) {
+	// A policy retry is followed by a Retry-After retry: neither uses up the
+	// single retry the other allows.
+	responses := []*http.Response{
+		{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}},
+		{StatusCode: http.StatusServiceUnavailable, Header: http.Header{"Retry-After": []string{"1"}}},
+		{StatusCode: http.StatusOK, Header: http.Header{}},
+	}
+	count := 0
+	c := &RESTClient{
+		Client: clientForFunc(func(req *http.Request) (*http.Response, error) {
+			resp := responses[count]
+			resp.Body = ioutil.NopCloser(bytes.NewReader([]byte{}))
+			count++
+			return resp, nil
+		}),
+		createBackoffMgr: func() BackoffManager { return &testBackoffManager{} },
+		retryPolicy:      NewTransientErrorRetryPolicy(1, testRetryBackoff),
+	}
+	if err := NewRequest(c).Verb("GET").MaxRetries(1).Do(context.Background()).Error(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if count != len(responses) {
+		t.Errorf("expected %d requests, got %d", len(responses), count)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The names of the spans requests record.
+const (
+	// SpanDo covers Request.Do and Request.DoRaw.
+	SpanDo = "Do"
+	// SpanWatch covers Request.Watch until the watch is established.
+	SpanWatch = "Watch"
+	// SpanStream covers Request.Stream until the response is received.
+	SpanStream = "Stream"
+	// SpanThrottle covers the wait for the client side rate limiter.
+	SpanThrottle = "Throttle"
+	// SpanRoundTrip covers sending a request and receiving the headers of
+	// its response. It is recorded for every attempt.
+	SpanRoundTrip = "RoundTrip"
+	// SpanRetryWait covers the wait before a request is sent again.
+	SpanRetryWait = "RetryWait"
+	// SpanDecode covers decoding a response into an object.
+	SpanDecode = "Decode"
+)
+
+// Tracer records spans that describe where the time of requests goes.
+// Spans started by a request are children of the span in the context the
+// request is made with, and the context of the round trip span is passed on
+// to the transport.
+type Tracer interface {
+	// Start starts a span with the given name as a child of the span in
+	// ctx, if any, and returns a context that holds the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a timed operation started by a Tracer.
+type Span interface {
+	// SetAttribute adds information about the operation to the span.
+	SetAttribute(key string, value interface{})
+	// End ends the span. err is the error the operation failed with, if any.
+	End(err error)
+}
+
+// startSpan starts a span with the request's tracer, or returns a span that
+// does nothing if there is no tracer.
+func (r *Request) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if r.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return r.tracer.Start(ctx, name)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) End(error)                        {}
+
+// SpanData describes a span that ended.
+type SpanData struct {
+	// TraceID identifies the tree of spans this span is part of. It is the
+	// SpanID of the root span.
+	TraceID uint64
+	SpanID  uint64
+	// ParentID is the SpanID of the parent span, or zero for root spans.
+	ParentID   uint64
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Err        error
+}
+
+// Duration returns how long the span took.
+func (s SpanData) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SpanExporter receives the spans of a tracer created by NewTracer.
+type SpanExporter interface {
+	// ExportSpan is called with every span that ends.
+	ExportSpan(span SpanData)
+}
+
+// NewTracer returns a Tracer that passes spans to exporter when they end.
+func NewTracer(exporter SpanExporter) Tracer {
+	return &tracer{exporter: exporter}
+}
+
+type tracer struct {
+	exporter SpanExporter
+	lastID   uint64
+}
+
+type spanContextKey struct{}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &span{
+		tracer: t,
+		data: SpanData{
+			SpanID: atomic.AddUint64(&t.lastID, 1),
+			Name:   name,
+			Start:  time.Now(),
+		},
+	}
+	if parent, ok := ctx.Value(spanContextKey{}).(*span); ok && parent.tracer == t {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentID = parent.data.SpanID
+	} else {
+		s.data.TraceID = s.data.SpanID
+	}
+	return context.WithValue(ctx, spanContextKey{}, s), s
+}
+
+type span struct {
+	tracer *tracer
+
+	lock  sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = map[string]interface{}{}
+	}
+	s.data.Attributes[key] = value
+}
+
+func (s *span) End(err error) {
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	s.data.Err = err
+	data := s.data
+	s.lock.Unlock()
+
+	s.tracer.exporter.ExportSpan(data)
+}
+
+// InMemoryExporter is a SpanExporter that keeps spans in memory, e.g. to
+// check them in tests.
+type InMemoryExporter struct {
+	lock  sync.Mutex
+	spans []SpanData
+}
+
+// ExportSpan keeps span.
+func (e *InMemoryExporter) ExportSpan(span SpanData) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the spans exported so far, in the order they ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
+
+// Reset forgets the spans exported so far.
+func (e *InMemoryExporter) Reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+func TestRequestTracing(t *testing.T) {
+	count := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		count++
+		if count == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", runtime.ContentTypeJSON)
+		w.Write([]byte(runtime.EncodeOrDie(scheme.Codecs.LegacyCodec(v1.SchemeGroupVersion), &v1.Pod{})))
+	}))
+	defer testServer.Close()
+
+	exporter := &InMemoryExporter{}
+	tracer := NewTracer(exporter)
+	ctx, parent := tracer.Start(context.Background(), "Reconcile")
+
+	c := testRESTClient(t, testServer)
+	c.tracer = tracer
+	c.rateLimiter = flowcontrol.NewFakeAlwaysRateLimiter()
+	pod := &v1.Pod{}
+	if err := c.Get().Resource("pods").Name("foo").Do(ctx).Into(pod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parent.End(nil)
+
+	spans := exporter.Spans()
+	var names []string
+	byID := map[uint64]SpanData{}
+	for _, span := range spans {
+		names = append(names, span.Name)
+		byID[span.SpanID] = span
+	}
+	expected := []string{SpanThrottle, SpanRoundTrip, SpanRetryWait, SpanThrottle, SpanRoundTrip, SpanDo, SpanDecode, "Reconcile"}
+	if !reflect.DeepEqual(expected, names) {
+		t.Fatalf("expected spans %v, got %v", expected, names)
+	}
+
+	parentNames := map[string]string{}
+	for _, span := range spans {
+		if span.TraceID != spans[len(spans)-1].SpanID {
+			t.Errorf("span %s is not part of the trace", span.Name)
+		}
+		if span.ParentID != 0 {
+			parentNames[span.Name] = byID[span.ParentID].Name
+		}
+	}
+	expectedParents := map[string]string{
+		SpanThrottle:  SpanDo,
+		SpanRoundTrip: SpanDo,
+		SpanRetryWait: SpanDo,
+		SpanDecode:    SpanDo,
+		SpanDo:        "Reconcile",
+	}
+	if !reflect.DeepEqual(expectedParents, parentNames) {
+		t.Errorf("expected parents %v, got %v", expectedParents, parentNames)
+	}
+	if e, a := http.StatusServiceUnavailable, spans[1].Attributes["statusCode"]; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 1, spans[4].Attributes["retries"]; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
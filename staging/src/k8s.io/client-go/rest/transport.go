@@ -65,6 +65,7 @@ func (c *Config) TransportConfig() (*transport.Config, error) {
 		Transport:          c.Transport,
 		WrapTransport:      c.WrapTransport,
 		DisableCompression: c.DisableCompression,
+		CircuitBreaker:     c.CircuitBreaker,
 		TLS: transport.TLSConfig{
 			Insecure:   c.Insecure,
 			ServerName: c.ServerName,
@@ -89,6 +90,18 @@ func (c *Config) TransportConfig() (*transport.Config, error) {
 		Proxy: c.Proxy,
 	}
 
+	if c.Hedging != nil {
+		wrapper, err := newHedgingWrapper(c.Hedging)
+		if err != nil {
+			return nil, err
+		}
+		conf.Wrap(wrapper)
+	}
+
+	if c.CredentialProvider != nil {
+		conf.CredentialProvider = transport.NewCachingCredentialProvider(c.CredentialProvider, 0)
+	}
+
 	if c.ExecProvider != nil && c.AuthProvider != nil {
 		return nil, errors.New("execProvider and authProvider cannot be used in combination")
 	}
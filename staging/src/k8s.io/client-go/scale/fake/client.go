@@ -71,7 +71,7 @@ func (f *fakeNamespacedScaleClient) Update(ctx context.Context, resource schema.
 
 func (f *fakeNamespacedScaleClient) Patch(ctx context.Context, gvr schema.GroupVersionResource, name string, pt types.PatchType, patch []byte, opts metav1.PatchOptions) (*autoscalingapi.Scale, error) {
 	obj, err := f.fake.
-		Invokes(testing.NewPatchSubresourceAction(gvr, f.namespace, name, pt, patch, "scale"), &autoscalingapi.Scale{})
+		Invokes(testing.NewPatchSubresourceActionWithOptions(gvr, f.namespace, name, pt, patch, opts, "scale"), &autoscalingapi.Scale{})
 
 	if err != nil {
 		return nil, err
@@ -76,6 +76,9 @@ func NewRootListAction(resource schema.GroupVersionResource, kind schema.GroupVe
 	action.Kind = kind
 	labelSelector, fieldSelector, _ := ExtractFromListOptions(opts)
 	action.ListRestrictions = ListRestrictions{labelSelector, fieldSelector}
+	if listOptions, ok := opts.(metav1.ListOptions); ok {
+		action.ListOptions = listOptions
+	}
 
 	return action
 }
@@ -88,6 +91,9 @@ func NewListAction(resource schema.GroupVersionResource, kind schema.GroupVersio
 	action.Namespace = namespace
 	labelSelector, fieldSelector, _ := ExtractFromListOptions(opts)
 	action.ListRestrictions = ListRestrictions{labelSelector, fieldSelector}
+	if listOptions, ok := opts.(metav1.ListOptions); ok {
+		action.ListOptions = listOptions
+	}
 
 	return action
 }
@@ -201,6 +207,45 @@ func NewPatchSubresourceAction(resource schema.GroupVersionResource, namespace,
 	return action
 }
 
+// NewRootPatchActionWithOptions returns a patch action for a cluster scoped
+// resource that records the options of the request, e.g. the field manager
+// of an apply patch.
+func NewRootPatchActionWithOptions(resource schema.GroupVersionResource, name string, pt types.PatchType, patch []byte, opts metav1.PatchOptions) PatchActionImpl {
+	action := NewRootPatchAction(resource, name, pt, patch)
+	action.PatchOptions = opts
+
+	return action
+}
+
+// NewPatchActionWithOptions returns a patch action for a namespaced resource
+// that records the options of the request.
+func NewPatchActionWithOptions(resource schema.GroupVersionResource, namespace string, name string, pt types.PatchType, patch []byte, opts metav1.PatchOptions) PatchActionImpl {
+	action := NewPatchAction(resource, namespace, name, pt, patch)
+	action.PatchOptions = opts
+
+	return action
+}
+
+// NewRootPatchSubresourceActionWithOptions returns a patch action for a
+// subresource of a cluster scoped resource that records the options of the
+// request.
+func NewRootPatchSubresourceActionWithOptions(resource schema.GroupVersionResource, name string, pt types.PatchType, patch []byte, opts metav1.PatchOptions, subresources ...string) PatchActionImpl {
+	action := NewRootPatchSubresourceAction(resource, name, pt, patch, subresources...)
+	action.PatchOptions = opts
+
+	return action
+}
+
+// NewPatchSubresourceActionWithOptions returns a patch action for a
+// subresource of a namespaced resource that records the options of the
+// request.
+func NewPatchSubresourceActionWithOptions(resource schema.GroupVersionResource, namespace, name string, pt types.PatchType, patch []byte, opts metav1.PatchOptions, subresources ...string) PatchActionImpl {
+	action := NewPatchSubresourceAction(resource, namespace, name, pt, patch, subresources...)
+	action.PatchOptions = opts
+
+	return action
+}
+
 func NewRootUpdateSubresourceAction(resource schema.GroupVersionResource, subresource string, object runtime.Object) UpdateActionImpl {
 	action := UpdateActionImpl{}
 	action.Verb = "update"
@@ -250,6 +295,25 @@ func NewDeleteAction(resource schema.GroupVersionResource, namespace, name strin
 	return action
 }
 
+// NewRootDeleteActionWithOptions returns a delete action for a cluster
+// scoped resource that records the options of the request, e.g. its
+// preconditions.
+func NewRootDeleteActionWithOptions(resource schema.GroupVersionResource, name string, opts metav1.DeleteOptions) DeleteActionImpl {
+	action := NewRootDeleteAction(resource, name)
+	action.DeleteOptions = opts
+
+	return action
+}
+
+// NewDeleteActionWithOptions returns a delete action for a namespaced
+// resource that records the options of the request.
+func NewDeleteActionWithOptions(resource schema.GroupVersionResource, namespace, name string, opts metav1.DeleteOptions) DeleteActionImpl {
+	action := NewDeleteAction(resource, namespace, name)
+	action.DeleteOptions = opts
+
+	return action
+}
+
 func NewDeleteSubresourceAction(resource schema.GroupVersionResource, subresource, namespace, name string) DeleteActionImpl {
 	action := DeleteActionImpl{}
 	action.Verb = "delete"
@@ -288,6 +352,9 @@ func NewRootWatchAction(resource schema.GroupVersionResource, opts interface{})
 	action.Resource = resource
 	labelSelector, fieldSelector, resourceVersion := ExtractFromListOptions(opts)
 	action.WatchRestrictions = WatchRestrictions{labelSelector, fieldSelector, resourceVersion}
+	if listOptions, ok := opts.(metav1.ListOptions); ok {
+		action.ListOptions = listOptions
+	}
 
 	return action
 }
@@ -324,6 +391,9 @@ func NewWatchAction(resource schema.GroupVersionResource, namespace string, opts
 	action.Namespace = namespace
 	labelSelector, fieldSelector, resourceVersion := ExtractFromListOptions(opts)
 	action.WatchRestrictions = WatchRestrictions{labelSelector, fieldSelector, resourceVersion}
+	if listOptions, ok := opts.(metav1.ListOptions); ok {
+		action.ListOptions = listOptions
+	}
 
 	return action
 }
@@ -495,6 +565,9 @@ type ListActionImpl struct {
 	Kind             schema.GroupVersionKind
 	Name             string
 	ListRestrictions ListRestrictions
+	// ListOptions are the options of the request, if they were given as
+	// metav1.ListOptions.
+	ListOptions metav1.ListOptions
 }
 
 func (a ListActionImpl) GetKind() schema.GroupVersionKind {
@@ -514,6 +587,7 @@ func (a ListActionImpl) DeepCopy() Action {
 			Labels: a.ListRestrictions.Labels.DeepCopySelector(),
 			Fields: a.ListRestrictions.Fields.DeepCopySelector(),
 		},
+		ListOptions: *a.ListOptions.DeepCopy(),
 	}
 }
 
@@ -556,6 +630,9 @@ type PatchActionImpl struct {
 	Name      string
 	PatchType types.PatchType
 	Patch     []byte
+	// PatchOptions are the options of the request, if the client passed
+	// them on.
+	PatchOptions metav1.PatchOptions
 }
 
 func (a PatchActionImpl) GetName() string {
@@ -574,16 +651,20 @@ func (a PatchActionImpl) DeepCopy() Action {
 	patch := make([]byte, len(a.Patch))
 	copy(patch, a.Patch)
 	return PatchActionImpl{
-		ActionImpl: a.ActionImpl.DeepCopy().(ActionImpl),
-		Name:       a.Name,
-		PatchType:  a.PatchType,
-		Patch:      patch,
+		ActionImpl:   a.ActionImpl.DeepCopy().(ActionImpl),
+		Name:         a.Name,
+		PatchType:    a.PatchType,
+		Patch:        patch,
+		PatchOptions: *a.PatchOptions.DeepCopy(),
 	}
 }
 
 type DeleteActionImpl struct {
 	ActionImpl
 	Name string
+	// DeleteOptions are the options of the request, if the client passed
+	// them on.
+	DeleteOptions metav1.DeleteOptions
 }
 
 func (a DeleteActionImpl) GetName() string {
@@ -592,8 +673,9 @@ func (a DeleteActionImpl) GetName() string {
 
 func (a DeleteActionImpl) DeepCopy() Action {
 	return DeleteActionImpl{
-		ActionImpl: a.ActionImpl.DeepCopy().(ActionImpl),
-		Name:       a.Name,
+		ActionImpl:    a.ActionImpl.DeepCopy().(ActionImpl),
+		Name:          a.Name,
+		DeleteOptions: *a.DeleteOptions.DeepCopy(),
 	}
 }
 
@@ -619,6 +701,9 @@ func (a DeleteCollectionActionImpl) DeepCopy() Action {
 type WatchActionImpl struct {
 	ActionImpl
 	WatchRestrictions WatchRestrictions
+	// ListOptions are the options of the request, if they were given as
+	// metav1.ListOptions.
+	ListOptions metav1.ListOptions
 }
 
 func (a WatchActionImpl) GetWatchRestrictions() WatchRestrictions {
@@ -633,6 +718,7 @@ func (a WatchActionImpl) DeepCopy() Action {
 			Fields:          a.WatchRestrictions.Fields.DeepCopySelector(),
 			ResourceVersion: a.WatchRestrictions.ResourceVersion,
 		},
+		ListOptions: *a.ListOptions.DeepCopy(),
 	}
 }
 
@@ -0,0 +1,385 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// maxEvents is the number of events the tracker keeps for watches starting
+// from an earlier resourceVersion.
+var maxEvents = int(watch.DefaultChanSize)
+
+const (
+	// maxGeneratedNameLength is the maximum length of names generated from
+	// generateName, like the apiserver.
+	maxGeneratedNameLength = 63
+	randomNameLength       = 5
+)
+
+// ObjectTrackerOption configures an ObjectTracker created by
+// NewObjectTracker.
+type ObjectTrackerOption func(*tracker)
+
+// WithAPIServerSemantics makes the tracker populate and check the fields
+// the apiserver is responsible for:
+//
+//   - every write is assigned a monotonically increasing resourceVersion, and
+//     lists carry the latest one.
+//   - updates with a stale resourceVersion or a different uid fail with a
+//     Conflict, like deletes whose preconditions don't match.
+//   - created objects get a uid, a creationTimestamp and generation 1, and a
+//     name from their generateName. Updates that change more than the metadata
+//     or the status bump the generation.
+//   - deleting an object with finalizers only sets its deletionTimestamp. It
+//     is removed once an update removes the last finalizer.
+//   - watches with a resourceVersion receive the events after it, or all
+//     objects as added if it is unset or "0". Watches from a resourceVersion
+//     older than the retained events fail with an Expired error.
+//   - creates, updates and patches record their field manager in the
+//     managedFields of the object, so that apply requests conflict with
+//     them. Without this option, they are only recorded for objects that
+//     already have managedFields.
+//
+// Objects passed to Create and Update are populated with the server fields
+// of the stored object.
+func WithAPIServerSemantics() ObjectTrackerOption {
+	return func(t *tracker) {
+		t.serverSemantics = true
+	}
+}
+
+// trackerEvent is an event recorded for watches starting from an earlier
+// resourceVersion.
+type trackerEvent struct {
+	gvr             schema.GroupVersionResource
+	ns              string
+	eventType       watch.EventType
+	oldObject       runtime.Object
+	object          runtime.Object
+	resourceVersion uint64
+}
+
+// nextResourceVersion increments the resourceVersion of the tracker and
+// returns it.
+func (t *tracker) nextResourceVersion() string {
+	t.resourceVersion++
+	return strconv.FormatUint(t.resourceVersion, 10)
+}
+
+// generateName sets the name of an object to be created from its
+// generateName unless it already has a name.
+func (t *tracker) generateName(gvr schema.GroupVersionResource, objMeta metav1.Object) error {
+	if len(objMeta.GetName()) > 0 {
+		return nil
+	}
+	base := objMeta.GetGenerateName()
+	if len(base) == 0 {
+		return errors.NewBadRequest("name or generateName is required")
+	}
+	if len(base) > maxGeneratedNameLength-randomNameLength {
+		base = base[:maxGeneratedNameLength-randomNameLength]
+	}
+	for {
+		name := base + utilrand.String(randomNameLength)
+		if _, exists := t.objects[gvr][types.NamespacedName{Namespace: objMeta.GetNamespace(), Name: name}]; !exists {
+			objMeta.SetName(name)
+			return nil
+		}
+	}
+}
+
+// createServerFields sets the server fields of an object being created.
+func (t *tracker) createServerFields(objMeta metav1.Object) {
+	if len(objMeta.GetUID()) == 0 {
+		objMeta.SetUID(uuid.NewUUID())
+	}
+	if creationTimestamp := objMeta.GetCreationTimestamp(); creationTimestamp.IsZero() {
+		objMeta.SetCreationTimestamp(metav1.Now())
+	}
+	objMeta.SetGeneration(1)
+	objMeta.SetResourceVersion(t.nextResourceVersion())
+}
+
+// updateServerFields checks that obj may replace the existing object, and
+// sets its server fields.
+func (t *tracker) updateServerFields(gr schema.GroupResource, existing, obj runtime.Object) error {
+	existingMeta, err := meta.Accessor(existing)
+	if err != nil {
+		return err
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if rv := objMeta.GetResourceVersion(); len(rv) > 0 && rv != existingMeta.GetResourceVersion() {
+		return errors.NewConflict(gr, objMeta.GetName(), fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+	if uid := objMeta.GetUID(); len(uid) > 0 && uid != existingMeta.GetUID() {
+		return errors.NewConflict(gr, objMeta.GetName(), fmt.Errorf("Precondition failed: UID in precondition: %v, UID in object meta: %v", uid, existingMeta.GetUID()))
+	}
+
+	generation := existingMeta.GetGeneration()
+	changed, err := specChanged(existing, obj)
+	if err != nil {
+		return err
+	}
+	if changed {
+		generation++
+	}
+	objMeta.SetUID(existingMeta.GetUID())
+	objMeta.SetCreationTimestamp(existingMeta.GetCreationTimestamp())
+	objMeta.SetGeneration(generation)
+	if existingMeta.GetDeletionTimestamp() != nil {
+		objMeta.SetDeletionTimestamp(existingMeta.GetDeletionTimestamp())
+		objMeta.SetDeletionGracePeriodSeconds(existingMeta.GetDeletionGracePeriodSeconds())
+	}
+	objMeta.SetResourceVersion(t.nextResourceVersion())
+	return nil
+}
+
+// markDeleted sets the deletionTimestamp of an object with finalizers.
+func (t *tracker) markDeleted(objMeta metav1.Object) {
+	now := metav1.Now()
+	var gracePeriod int64
+	objMeta.SetDeletionTimestamp(&now)
+	objMeta.SetDeletionGracePeriodSeconds(&gracePeriod)
+	objMeta.SetGeneration(objMeta.GetGeneration() + 1)
+	objMeta.SetResourceVersion(t.nextResourceVersion())
+}
+
+// recordEvent records an event for watches starting from an earlier
+// resourceVersion, dropping the oldest event if there are too many.
+func (t *tracker) recordEvent(gvr schema.GroupVersionResource, ns string, eventType watch.EventType, old, obj runtime.Object) {
+	if len(t.events) == maxEvents {
+		t.events = t.events[1:]
+	}
+	t.events = append(t.events, trackerEvent{
+		gvr:             gvr,
+		ns:              ns,
+		eventType:       eventType,
+		oldObject:       old,
+		object:          obj,
+		resourceVersion: t.resourceVersion,
+	})
+}
+
+// replay sends the events a watch of gvr in namespace ns starting from
+// resourceVersion missed to w: all objects as added if resourceVersion is
+// unset or "0", and the recorded events after it otherwise.
+func (t *tracker) replay(w *trackerWatcher, gvr schema.GroupVersionResource, ns, resourceVersion string) error {
+	if len(resourceVersion) == 0 || resourceVersion == "0" {
+		objs, err := filterByNamespace(t.objects[gvr], ns)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			w.send(watch.Added, nil, obj)
+		}
+		return nil
+	}
+
+	from, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return errors.NewBadRequest(fmt.Sprintf("invalid resource version %q", resourceVersion))
+	}
+	if len(t.events) > 0 && from+1 < t.events[0].resourceVersion {
+		return errors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", from, t.events[0].resourceVersion-1))
+	}
+	for _, event := range t.events {
+		if event.resourceVersion <= from || event.gvr != gvr {
+			continue
+		}
+		if ns != metav1.NamespaceAll && event.ns != ns {
+			continue
+		}
+		w.send(event.eventType, event.oldObject, event.object)
+	}
+	return nil
+}
+
+// queueWatcher is a watch.Interface that queues events until they are
+// received, so that sending any number of events neither blocks nor fails
+// like it does with a fake watcher once its channel is full.
+type queueWatcher struct {
+	result chan watch.Event
+	stopCh chan struct{}
+
+	lock    sync.Mutex
+	cond    *sync.Cond
+	queue   []watch.Event
+	stopped bool
+}
+
+func newQueueWatcher() *queueWatcher {
+	w := &queueWatcher{
+		result: make(chan watch.Event),
+		stopCh: make(chan struct{}),
+	}
+	w.cond = sync.NewCond(&w.lock)
+	go w.run()
+	return w
+}
+
+// Action queues an event, unless the watcher is stopped.
+func (w *queueWatcher) Action(eventType watch.EventType, obj runtime.Object) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.stopped {
+		return
+	}
+	w.queue = append(w.queue, watch.Event{Type: eventType, Object: obj})
+	w.cond.Signal()
+}
+
+func (w *queueWatcher) run() {
+	defer close(w.result)
+	for {
+		w.lock.Lock()
+		for len(w.queue) == 0 && !w.stopped {
+			w.cond.Wait()
+		}
+		if w.stopped {
+			w.lock.Unlock()
+			return
+		}
+		event := w.queue[0]
+		w.queue = w.queue[1:]
+		w.lock.Unlock()
+
+		select {
+		case w.result <- event:
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+// ResultChan implements watch.Interface.
+func (w *queueWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface.
+func (w *queueWatcher) Stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if !w.stopped {
+		w.stopped = true
+		w.queue = nil
+		close(w.stopCh)
+		w.cond.Signal()
+	}
+}
+
+// checkDeletePreconditions returns a Conflict if the preconditions in opts
+// don't match obj.
+func checkDeletePreconditions(gr schema.GroupResource, obj runtime.Object, opts []metav1.DeleteOptions) error {
+	if len(opts) == 0 || opts[0].Preconditions == nil {
+		return nil
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	preconditions := opts[0].Preconditions
+	if preconditions.UID != nil && *preconditions.UID != objMeta.GetUID() {
+		return errors.NewConflict(gr, objMeta.GetName(), fmt.Errorf("Precondition failed: UID in precondition: %v, UID in object meta: %v", *preconditions.UID, objMeta.GetUID()))
+	}
+	if preconditions.ResourceVersion != nil && *preconditions.ResourceVersion != objMeta.GetResourceVersion() {
+		return errors.NewConflict(gr, objMeta.GetName(), fmt.Errorf("Precondition failed: ResourceVersion in precondition: %v, ResourceVersion in object meta: %v", *preconditions.ResourceVersion, objMeta.GetResourceVersion()))
+	}
+	return nil
+}
+
+// copyServerFields copies the server fields of the stored object to obj.
+func copyServerFields(stored, obj runtime.Object) error {
+	storedMeta, err := meta.Accessor(stored)
+	if err != nil {
+		return err
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	objMeta.SetName(storedMeta.GetName())
+	objMeta.SetNamespace(storedMeta.GetNamespace())
+	objMeta.SetUID(storedMeta.GetUID())
+	objMeta.SetResourceVersion(storedMeta.GetResourceVersion())
+	objMeta.SetGeneration(storedMeta.GetGeneration())
+	objMeta.SetCreationTimestamp(storedMeta.GetCreationTimestamp())
+	objMeta.SetDeletionTimestamp(storedMeta.GetDeletionTimestamp())
+	objMeta.SetDeletionGracePeriodSeconds(storedMeta.GetDeletionGracePeriodSeconds())
+	objMeta.SetManagedFields(storedMeta.GetManagedFields())
+	return nil
+}
+
+// copyManagedFields copies the managedFields of the stored object to obj, if
+// it has any.
+func copyManagedFields(stored, obj runtime.Object) error {
+	storedMeta, err := meta.Accessor(stored)
+	if err != nil {
+		return err
+	}
+	if len(storedMeta.GetManagedFields()) == 0 {
+		return nil
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	objMeta.SetManagedFields(storedMeta.GetManagedFields())
+	return nil
+}
+
+// specChanged returns true if the fields of the objects other than their
+// type, metadata and status differ.
+func specChanged(existing, obj runtime.Object) (bool, error) {
+	existingContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(existing)
+	if err != nil {
+		return false, err
+	}
+	objContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, err
+	}
+	ignored := map[string]bool{"apiVersion": true, "kind": true, "metadata": true, "status": true}
+	for key, value := range existingContent {
+		if !ignored[key] && !reflect.DeepEqual(value, objContent[key]) {
+			return true, nil
+		}
+	}
+	for key := range objContent {
+		if _, ok := existingContent[key]; !ok && !ignored[key] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
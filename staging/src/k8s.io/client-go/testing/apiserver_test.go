@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var widgetsResource = schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+func newServerTracker() ObjectTracker {
+	scheme := runtime.NewScheme()
+	scheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "WidgetList"}, &unstructured.UnstructuredList{})
+	codecs := serializer.NewCodecFactory(scheme)
+	return NewObjectTracker(scheme, codecs.UniversalDecoder(), WithAPIServerSemantics())
+}
+
+func newWidget(name, color string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec":       map[string]interface{}{"color": color},
+	}}
+}
+
+func TestServerSemanticsCreateAndUpdate(t *testing.T) {
+	o := newServerTracker()
+	widget := newWidget("", "red")
+	widget.SetGenerateName("widget-")
+	if err := o.Create(widgetsResource, widget, "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(widget.GetName(), "widget-") || len(widget.GetName()) != len("widget-")+randomNameLength {
+		t.Errorf("expected a name generated from widget-, got %q", widget.GetName())
+	}
+	if creationTimestamp := widget.GetCreationTimestamp(); len(widget.GetUID()) == 0 || creationTimestamp.IsZero() {
+		t.Errorf("expected a uid and a creationTimestamp, got %q and %v", widget.GetUID(), creationTimestamp)
+	}
+	if widget.GetResourceVersion() != "1" || widget.GetGeneration() != 1 {
+		t.Errorf("expected resourceVersion 1 and generation 1, got %q and %d", widget.GetResourceVersion(), widget.GetGeneration())
+	}
+
+	stale := widget.DeepCopy()
+	widget.SetLabels(map[string]string{"a": "b"})
+	if err := o.Update(widgetsResource, widget, "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if widget.GetResourceVersion() != "2" || widget.GetGeneration() != 1 {
+		t.Errorf("expected resourceVersion 2 and an unchanged generation, got %q and %d", widget.GetResourceVersion(), widget.GetGeneration())
+	}
+	unstructured.SetNestedField(stale.Object, "blue", "spec", "color")
+	if err := o.Update(widgetsResource, stale, "default"); !errors.IsConflict(err) {
+		t.Fatalf("expected a conflict for a stale update, got %v", err)
+	}
+
+	// Updates without a resourceVersion aren't checked.
+	stale.SetResourceVersion("")
+	if err := o.Update(widgetsResource, stale, "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stale.GetResourceVersion() != "3" || stale.GetGeneration() != 2 {
+		t.Errorf("expected resourceVersion 3 and generation 2, got %q and %d", stale.GetResourceVersion(), stale.GetGeneration())
+	}
+
+	list, err := o.List(widgetsResource, schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rv := list.(*unstructured.UnstructuredList).GetResourceVersion(); rv != "3" {
+		t.Errorf("expected list resourceVersion 3, got %q", rv)
+	}
+}
+
+func TestServerSemanticsDelete(t *testing.T) {
+	o := newServerTracker()
+	reaction := ObjectReaction(o)
+	widget := newWidget("w", "red")
+	widget.SetFinalizers([]string{"example.com/cleanup"})
+	if err := o.Create(widgetsResource, widget, "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	otherUID := types.UID("other")
+	_, _, err := reaction(NewDeleteActionWithOptions(widgetsResource, "default", "w", metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &otherUID}}))
+	if !errors.IsConflict(err) {
+		t.Fatalf("expected a conflict for a mismatching uid precondition, got %v", err)
+	}
+
+	if _, _, err := reaction(NewDeleteAction(widgetsResource, "default", "w")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj, err := o.Get(widgetsResource, "default", "w")
+	if err != nil {
+		t.Fatalf("expected the object with finalizers to be kept, got %v", err)
+	}
+	deleting := obj.(*unstructured.Unstructured)
+	if deleting.GetDeletionTimestamp() == nil {
+		t.Fatalf("expected a deletionTimestamp")
+	}
+
+	deleting.SetFinalizers(nil)
+	_, obj, err = reaction(NewUpdateAction(widgetsResource, "default", deleting))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if obj.(*unstructured.Unstructured).GetDeletionTimestamp() == nil {
+		t.Errorf("expected the last state of the deleted object, got %v", obj)
+	}
+	if _, err := o.Get(widgetsResource, "default", "w"); !errors.IsNotFound(err) {
+		t.Errorf("expected the object to be removed with its last finalizer, got %v", err)
+	}
+}
+
+func TestServerSemanticsWatchResume(t *testing.T) {
+	o := newServerTracker()
+	for _, name := range []string{"a", "b"} {
+		if err := o.Create(widgetsResource, newWidget(name, "red"), "default"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := o.Delete(widgetsResource, "default", "a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w, err := o.Watch(widgetsResource, "default", metav1.ListOptions{ResourceVersion: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	type event struct {
+		eventType       watch.EventType
+		name            string
+		resourceVersion string
+	}
+	var events []event
+	for i := 0; i < 2; i++ {
+		e := <-w.ResultChan()
+		widget := e.Object.(*unstructured.Unstructured)
+		events = append(events, event{e.Type, widget.GetName(), widget.GetResourceVersion()})
+	}
+	expected := []event{{watch.Added, "b", "2"}, {watch.Deleted, "a", "3"}}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+	w.Stop()
+
+	w, err = o.Watch(widgetsResource, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := <-w.ResultChan(); e.Type != watch.Added || e.Object.(*unstructured.Unstructured).GetName() != "b" {
+		t.Errorf("expected the existing object to be added, got %v", e)
+	}
+	w.Stop()
+
+	for i := 0; i < maxEvents; i++ {
+		widget := newWidget("b", "red")
+		if err := o.Update(widgetsResource, widget, "default"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := o.Watch(widgetsResource, "default", metav1.ListOptions{ResourceVersion: "1"}); !errors.IsResourceExpired(err) {
+		t.Errorf("expected an expired resourceVersion, got %v", err)
+	}
+}
+
+func TestServerSemanticsWatchManyObjects(t *testing.T) {
+	o := newServerTracker()
+	count := int(watch.DefaultChanSize) + 50
+	for i := 0; i < count; i++ {
+		if err := o.Create(widgetsResource, newWidget(fmt.Sprintf("widget-%d", i), "red"), "default"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Watches get all objects, or all recorded events, whatever their
+	// number, followed by the later events.
+	initial, err := o.Watch(widgetsResource, "default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer initial.Stop()
+	resumed, err := o.Watch(widgetsResource, "default", metav1.ListOptions{ResourceVersion: strconv.Itoa(count - maxEvents)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resumed.Stop()
+	if err := o.Create(widgetsResource, newWidget("last", "red"), "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		w        watch.Interface
+		expected int
+	}{
+		{"initial", initial, count + 1},
+		{"resumed", resumed, maxEvents + 1},
+	} {
+		var last string
+		for i := 0; i < tc.expected; i++ {
+			select {
+			case e := <-tc.w.ResultChan():
+				if e.Type != watch.Added {
+					t.Fatalf("%s: expected an added event, got %v", tc.name, e)
+				}
+				last = e.Object.(*unstructured.Unstructured).GetName()
+			case <-time.After(wait.ForeverTestTimeout):
+				t.Fatalf("%s: timed out after %d events", tc.name, i)
+			}
+		}
+		if last != "last" {
+			t.Errorf("%s: expected the last event to be for the new object, got %q", tc.name, last)
+		}
+	}
+}
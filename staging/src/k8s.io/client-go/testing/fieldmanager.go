@@ -0,0 +1,473 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"bytes"
+	encodingjson "encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/structured-merge-diff/v4/fieldpath"
+	"sigs.k8s.io/structured-merge-diff/v4/merge"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultFieldManager is the manager of the changes made without one. The
+// apiserver names such managers after the user agent, which client-go
+// starts with the name of the command by default.
+var defaultFieldManager = filepath.Base(os.Args[0])
+
+// strippedFields are the fields that are never owned by a manager, as the
+// server sets them.
+var strippedFields = fieldpath.NewSet(
+	fieldpath.MakePathOrDie("apiVersion"),
+	fieldpath.MakePathOrDie("kind"),
+	fieldpath.MakePathOrDie("metadata", "name"),
+	fieldpath.MakePathOrDie("metadata", "namespace"),
+	fieldpath.MakePathOrDie("metadata", "creationTimestamp"),
+	fieldpath.MakePathOrDie("metadata", "selfLink"),
+	fieldpath.MakePathOrDie("metadata", "uid"),
+	fieldpath.MakePathOrDie("metadata", "clusterName"),
+	fieldpath.MakePathOrDie("metadata", "generation"),
+	fieldpath.MakePathOrDie("metadata", "managedFields"),
+	fieldpath.MakePathOrDie("metadata", "resourceVersion"),
+)
+
+// fieldManager performs server-side apply for a tracker, and records which
+// field manager owns which fields of its objects in their managedFields,
+// both for apply requests and for the changes made by other requests.
+// It follows the field manager of the apiserver, which can't be used here
+// as k8s.io/apiserver depends on client-go, and is built on the same
+// structured-merge-diff library. The types of objects are derived from
+// their Go types in the scheme, see schemaBuilder; unstructured objects
+// have types deduced from their content, so all of their lists are atomic.
+type fieldManager struct {
+	scheme  ObjectScheme
+	types   typeCache
+	updater merge.Updater
+}
+
+func newFieldManager(scheme ObjectScheme) *fieldManager {
+	return &fieldManager{
+		scheme:  scheme,
+		updater: merge.Updater{Converter: versionConverter{}},
+	}
+}
+
+// apply applies the apply configuration patch to live, which is nil if the
+// object doesn't exist yet, and returns the resulting object.
+func (f *fieldManager) apply(live runtime.Object, patch []byte, name string, opts metav1.PatchOptions) (runtime.Object, error) {
+	if len(opts.FieldManager) == 0 {
+		return nil, errors.NewBadRequest("PatchOptions.fieldManager is required for apply requests")
+	}
+	patchJSON, err := yaml.YAMLToJSON(patch)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("error decoding apply configuration: %v", err))
+	}
+	config := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patchJSON, &config.Object); err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("error decoding apply configuration: %v", err))
+	}
+	if len(config.GetAPIVersion()) == 0 || len(config.GetKind()) == 0 {
+		return nil, errors.NewBadRequest("apiVersion and kind must be set in apply configurations")
+	}
+	if len(config.GetName()) == 0 {
+		config.SetName(name)
+	}
+	if config.GetName() != name {
+		return nil, errors.NewBadRequest(fmt.Sprintf("the name of the object (%s) does not match the name on the URL (%s)", config.GetName(), name))
+	}
+	if live == nil {
+		live = f.newObject(config.GroupVersionKind())
+	}
+
+	liveMeta, err := meta.Accessor(live)
+	if err != nil {
+		return nil, err
+	}
+	managed, err := decodeManagedFields(liveMeta.GetManagedFields())
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	liveContent, err := objectContent(live)
+	if err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(config.Object, "metadata", "managedFields")
+
+	pt := f.parseableType(live)
+	liveTyped, err := pt.FromUnstructured(liveContent)
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Errorf("failed to create typed live object: %v", err))
+	}
+	configTyped, err := pt.FromUnstructured(config.Object)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("failed to create typed apply configuration: %v", err))
+	}
+
+	manager, err := buildManagerIdentifier(&metav1.ManagedFieldsEntry{
+		Manager:   opts.FieldManager,
+		Operation: metav1.ManagedFieldsOperationApply,
+	})
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	force := opts.Force != nil && *opts.Force
+	newTyped, newFields, err := f.updater.Apply(liveTyped, configTyped, fieldpath.APIVersion(config.GetAPIVersion()), managed.fields, manager, force)
+	if err != nil {
+		if conflicts, ok := err.(merge.Conflicts); ok {
+			return nil, newConflictError(conflicts)
+		}
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	if newTyped == nil {
+		// The object is unchanged, only its managers may have changed.
+		newTyped = liveTyped
+	}
+	managed.fields = stripFields(newFields)
+	now := metav1.NewTime(time.Now().UTC().Truncate(time.Second))
+	managed.times[manager] = &now
+
+	newContent, ok := newTyped.AsValue().Unstructured().(map[string]interface{})
+	if !ok {
+		return nil, errors.NewInternalError(fmt.Errorf("applied object is not a map: %v", newTyped.AsValue()))
+	}
+	obj, err := objectFromContent(live, newContent)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	encoded, err := encodeManagedFields(managed)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	objMeta.SetManagedFields(encoded)
+	return obj, nil
+}
+
+// update records the fields obj changes compared to live, which is nil if
+// obj is being created, as owned by manager, with an Update operation. Like
+// in the apiserver, the managers are those in the managedFields of obj, or
+// those of live if obj has none. The managedFields of obj are set in place.
+func (f *fieldManager) update(live, obj runtime.Object, manager string) error {
+	gvk, err := f.kindOf(obj)
+	if err != nil {
+		return err
+	}
+	if live == nil {
+		live = f.newObject(gvk)
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	entries := objMeta.GetManagedFields()
+	if len(entries) == 0 {
+		liveMeta, err := meta.Accessor(live)
+		if err != nil {
+			return err
+		}
+		entries = liveMeta.GetManagedFields()
+	}
+	managed, err := decodeManagedFields(entries)
+	if err != nil {
+		return err
+	}
+	liveContent, err := objectContent(live)
+	if err != nil {
+		return err
+	}
+	objContent, err := objectContent(obj)
+	if err != nil {
+		return err
+	}
+
+	pt := f.parseableType(obj)
+	liveTyped, err := pt.FromUnstructured(liveContent)
+	if err != nil {
+		return fmt.Errorf("failed to create typed live object: %v", err)
+	}
+	newTyped, err := pt.FromUnstructured(objContent)
+	if err != nil {
+		return fmt.Errorf("failed to create typed new object: %v", err)
+	}
+
+	apiVersion := gvk.GroupVersion().String()
+	managerID, err := buildManagerIdentifier(&metav1.ManagedFieldsEntry{
+		Manager:    manager,
+		Operation:  metav1.ManagedFieldsOperationUpdate,
+		APIVersion: apiVersion,
+	})
+	if err != nil {
+		return err
+	}
+	previous, existed := managed.fields[managerID]
+	_, newFields, err := f.updater.Update(liveTyped, newTyped, fieldpath.APIVersion(apiVersion), managed.fields, managerID)
+	if err != nil {
+		return err
+	}
+	managed.fields = stripFields(newFields)
+	if current, ok := managed.fields[managerID]; ok && (!existed || !current.Set().Equals(previous.Set())) {
+		now := metav1.NewTime(time.Now().UTC().Truncate(time.Second))
+		managed.times[managerID] = &now
+	}
+	encoded, err := encodeManagedFields(managed)
+	if err != nil {
+		return err
+	}
+	objMeta.SetManagedFields(encoded)
+	return nil
+}
+
+// kindOf returns the kind of obj, from its type meta or the scheme.
+func (f *fieldManager) kindOf(obj runtime.Object) (schema.GroupVersionKind, error) {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); !gvk.Empty() {
+		return gvk, nil
+	}
+	gvks, _, err := f.scheme.ObjectKinds(obj)
+	if err != nil {
+		return schema.GroupVersionKind{}, err
+	}
+	return gvks[0], nil
+}
+
+// newObject returns an empty object of kind gvk, which is unstructured if the
+// scheme doesn't know the kind.
+func (f *fieldManager) newObject(gvk schema.GroupVersionKind) runtime.Object {
+	if obj, err := f.scheme.New(gvk); err == nil {
+		return obj
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	return obj
+}
+
+// parseableType returns the structured-merge-diff type of obj.
+func (f *fieldManager) parseableType(obj runtime.Object) typed.ParseableType {
+	if _, ok := obj.(runtime.Unstructured); ok {
+		return typed.DeducedParseableType
+	}
+	return f.types.parseableType(reflect.TypeOf(obj).Elem())
+}
+
+// objectContent returns the content of obj without its managedFields.
+func objectContent(obj runtime.Object) (map[string]interface{}, error) {
+	var content map[string]interface{}
+	if u, ok := obj.(runtime.Unstructured); ok {
+		content = runtime.DeepCopyJSON(u.UnstructuredContent())
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+	unstructured.RemoveNestedField(content, "metadata", "managedFields")
+	return content, nil
+}
+
+// objectFromContent returns a new object of the same type as obj with the
+// given content.
+func objectFromContent(obj runtime.Object, content map[string]interface{}) (runtime.Object, error) {
+	out := obj.DeepCopyObject()
+	if u, ok := out.(runtime.Unstructured); ok {
+		u.SetUnstructuredContent(content)
+		return out, nil
+	}
+	value := reflect.ValueOf(out)
+	value.Elem().Set(reflect.New(value.Type().Elem()).Elem())
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// stripFields removes the fields set by the server from the fields of all
+// managers, and the managers that are left without fields.
+func stripFields(managers fieldpath.ManagedFields) fieldpath.ManagedFields {
+	stripped := make(fieldpath.ManagedFields, len(managers))
+	for manager, versionedSet := range managers {
+		set := versionedSet.Set().Difference(strippedFields)
+		if set.Empty() {
+			continue
+		}
+		stripped[manager] = fieldpath.NewVersionedSet(set, versionedSet.APIVersion(), versionedSet.Applied())
+	}
+	return stripped
+}
+
+// versionConverter converts objects between versions of their kind. The
+// tracker stores each object in a single version, so it leaves them as they
+// are.
+type versionConverter struct{}
+
+var _ merge.Converter = versionConverter{}
+
+func (versionConverter) Convert(object *typed.TypedValue, version fieldpath.APIVersion) (*typed.TypedValue, error) {
+	return object, nil
+}
+
+func (versionConverter) IsMissingVersionError(err error) bool {
+	return false
+}
+
+// managedFields are the fields owned by each manager of an object, and when
+// each manager last changed the object.
+type managedFields struct {
+	fields fieldpath.ManagedFields
+	times  map[string]*metav1.Time
+}
+
+// decodeManagedFields converts managedFields from the API format to the one
+// of structured-merge-diff.
+func decodeManagedFields(entries []metav1.ManagedFieldsEntry) (managedFields, error) {
+	managed := managedFields{
+		fields: make(fieldpath.ManagedFields, len(entries)),
+		times:  make(map[string]*metav1.Time, len(entries)),
+	}
+	for i := range entries {
+		entry := &entries[i]
+		if entry.FieldsType != "FieldsV1" {
+			return managedFields{}, fmt.Errorf("invalid fieldsType %q in managed fields entry %d", entry.FieldsType, i)
+		}
+		manager, err := buildManagerIdentifier(entry)
+		if err != nil {
+			return managedFields{}, err
+		}
+		set := &fieldpath.Set{}
+		if entry.FieldsV1 != nil {
+			if err := set.FromJSON(bytes.NewReader(entry.FieldsV1.Raw)); err != nil {
+				return managedFields{}, fmt.Errorf("error decoding fields of managed fields entry %d: %v", i, err)
+			}
+		}
+		managed.fields[manager] = fieldpath.NewVersionedSet(set, fieldpath.APIVersion(entry.APIVersion), entry.Operation == metav1.ManagedFieldsOperationApply)
+		managed.times[manager] = entry.Time
+	}
+	return managed, nil
+}
+
+// encodeManagedFields converts managedFields to the API format, sorted like
+// the apiserver sorts them.
+func encodeManagedFields(managed managedFields) ([]metav1.ManagedFieldsEntry, error) {
+	if len(managed.fields) == 0 {
+		return nil, nil
+	}
+	entries := make([]metav1.ManagedFieldsEntry, 0, len(managed.fields))
+	for manager, versionedSet := range managed.fields {
+		entry := metav1.ManagedFieldsEntry{}
+		if err := encodingjson.Unmarshal([]byte(manager), &entry); err != nil {
+			return nil, fmt.Errorf("error decoding manager identifier %v: %v", manager, err)
+		}
+		entry.APIVersion = string(versionedSet.APIVersion())
+		if versionedSet.Applied() {
+			entry.Operation = metav1.ManagedFieldsOperationApply
+		}
+		fields, err := versionedSet.Set().ToJSON()
+		if err != nil {
+			return nil, fmt.Errorf("error encoding fields of %v: %v", manager, err)
+		}
+		entry.FieldsType = "FieldsV1"
+		entry.FieldsV1 = &metav1.FieldsV1{Raw: fields}
+		entry.Time = managed.times[manager]
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		p, q := entries[i], entries[j]
+		if p.Operation != q.Operation {
+			return p.Operation < q.Operation
+		}
+		var pSeconds, qSeconds int64
+		if p.Time != nil {
+			pSeconds = p.Time.Unix()
+		}
+		if q.Time != nil {
+			qSeconds = q.Time.Unix()
+		}
+		if pSeconds != qSeconds {
+			return pSeconds < qSeconds
+		}
+		if p.Manager != q.Manager {
+			return p.Manager < q.Manager
+		}
+		return p.APIVersion < q.APIVersion
+	})
+	return entries, nil
+}
+
+// buildManagerIdentifier returns the identifier of the manager of entry.
+// Appliers are identified regardless of the version they applied.
+func buildManagerIdentifier(entry *metav1.ManagedFieldsEntry) (string, error) {
+	id := *entry
+	id.FieldsType = ""
+	id.FieldsV1 = nil
+	id.Time = nil
+	if entry.Operation == metav1.ManagedFieldsOperationApply {
+		id.APIVersion = ""
+	}
+	b, err := encodingjson.Marshal(&id)
+	if err != nil {
+		return "", fmt.Errorf("error encoding manager identifier: %v", err)
+	}
+	return string(b), nil
+}
+
+// newConflictError returns the error of an apply request that conflicts
+// with other managers, as the apiserver does.
+func newConflictError(conflicts merge.Conflicts) *errors.StatusError {
+	causes := make([]metav1.StatusCause, 0, len(conflicts))
+	messages := make([]string, 0, len(conflicts))
+	for _, conflict := range conflicts {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: fmt.Sprintf("conflict with %v", printManager(conflict.Manager)),
+			Field:   conflict.Path.String(),
+		})
+		messages = append(messages, fmt.Sprintf("conflict with %v: %v", printManager(conflict.Manager), conflict.Path))
+	}
+	msg := fmt.Sprintf("Apply failed with %d conflicts: %s", len(conflicts), strings.Join(messages, ", "))
+	if len(conflicts) == 1 {
+		msg = fmt.Sprintf("Apply failed with 1 conflict: %s", messages[0])
+	}
+	return errors.NewApplyConflict(causes, msg)
+}
+
+func printManager(manager string) string {
+	entry := &metav1.ManagedFieldsEntry{}
+	if err := encodingjson.Unmarshal([]byte(manager), entry); err != nil {
+		return fmt.Sprintf("%q", manager)
+	}
+	if entry.Operation == metav1.ManagedFieldsOperationUpdate {
+		return fmt.Sprintf("%q using %v", entry.Manager, entry.APIVersion)
+	}
+	return fmt.Sprintf("%q", entry.Manager)
+}
@@ -0,0 +1,327 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var deploymentsResource = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+func newApplyReaction(t *testing.T) ReactionFunc {
+	scheme := runtime.NewScheme()
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+	return ObjectReaction(NewObjectTracker(scheme, codecs.UniversalDecoder()))
+}
+
+func applyDeployment(reaction ReactionFunc, manager string, force bool, patch string) (*appsv1.Deployment, error) {
+	opts := metav1.PatchOptions{FieldManager: manager}
+	if force {
+		opts.Force = &force
+	}
+	action := NewPatchActionWithOptions(deploymentsResource, "default", "web", types.ApplyPatchType, []byte(patch), opts)
+	_, obj, err := reaction(action)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*appsv1.Deployment), nil
+}
+
+func containerImages(deployment *appsv1.Deployment) map[string]string {
+	images := map[string]string{}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		images[container.Name] = container.Image
+	}
+	return images
+}
+
+func appliedManagers(obj metav1.Object) []string {
+	var managers []string
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Operation == metav1.ManagedFieldsOperationApply {
+			managers = append(managers, entry.Manager)
+		}
+	}
+	return managers
+}
+
+func TestApplyMergesByKey(t *testing.T) {
+	reaction := newApplyReaction(t)
+
+	_, err := applyDeployment(reaction, "controller", false, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.19
+`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deployment, err := applyDeployment(reaction, "injector", false, `{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {"name": "web", "labels": {"injected": "true"}},
+		"spec": {"template": {"spec": {"containers": [{"name": "sidecar", "image": "proxy:1.0"}]}}}
+	}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e, a := map[string]string{"web": "nginx:1.19", "sidecar": "proxy:1.0"}, containerImages(deployment); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected containers %v, got %v", e, a)
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != 1 {
+		t.Errorf("expected the replicas of the first manager to be kept, got %v", deployment.Spec.Replicas)
+	}
+	if e, a := []string{"controller", "injector"}, appliedManagers(deployment); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected managers %v, got %v", e, a)
+	}
+
+	// Fields a manager stops applying are removed.
+	deployment, err = applyDeployment(reaction, "injector", false, `{
+		"apiVersion": "apps/v1",
+		"kind": "Deployment",
+		"metadata": {"name": "web"}
+	}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := map[string]string{"web": "nginx:1.19"}, containerImages(deployment); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected containers %v, got %v", e, a)
+	}
+	if len(deployment.Labels) != 0 {
+		t.Errorf("expected the labels to be removed, got %v", deployment.Labels)
+	}
+}
+
+func TestApplyConflicts(t *testing.T) {
+	reaction := newApplyReaction(t)
+	if _, err := applyDeployment(reaction, "controller", false, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 1}}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err := applyDeployment(reaction, "autoscaler", false, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 3}}`)
+	if !errors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	causes := err.(errors.APIStatus).Status().Details.Causes
+	expected := []metav1.StatusCause{{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "controller"`,
+		Field:   ".spec.replicas",
+	}}
+	if !reflect.DeepEqual(expected, causes) {
+		t.Errorf("expected causes %v, got %v", expected, causes)
+	}
+
+	// Applying the same value doesn't conflict.
+	if _, err := applyDeployment(reaction, "autoscaler", false, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 1}}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deployment, err := applyDeployment(reaction, "autoscaler", true, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 3}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *deployment.Spec.Replicas != 3 {
+		t.Errorf("expected the forced replicas, got %d", *deployment.Spec.Replicas)
+	}
+	if e, a := []string{"autoscaler"}, appliedManagers(deployment); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected the forced manager to take over all fields, got managers %v", a)
+	}
+}
+
+func TestApplyRequiresFieldManager(t *testing.T) {
+	reaction := newApplyReaction(t)
+	_, err := applyDeployment(reaction, "", false, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}}`)
+	if !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request, got %v", err)
+	}
+	_, err = applyDeployment(reaction, "controller", false, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "other"}}`)
+	if !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request for a mismatching name, got %v", err)
+	}
+}
+
+func TestApplyUnstructured(t *testing.T) {
+	scheme := runtime.NewScheme()
+	codecs := serializer.NewCodecFactory(scheme)
+	reaction := ObjectReaction(NewObjectTracker(scheme, codecs.UniversalDecoder()))
+	widgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	apply := func(manager, patch string) (*unstructured.Unstructured, error) {
+		action := NewPatchActionWithOptions(widgets, "default", "w", types.ApplyPatchType, []byte(patch), metav1.PatchOptions{FieldManager: manager})
+		_, obj, err := reaction(action)
+		if err != nil {
+			return nil, err
+		}
+		return obj.(*unstructured.Unstructured), nil
+	}
+
+	if _, err := apply("a", `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w"}, "spec": {"color": "red"}}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj, err := apply("b", `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w"}, "spec": {"size": 2}}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]interface{}{"color": "red", "size": int64(2)}
+	if spec, _, _ := unstructured.NestedMap(obj.Object, "spec"); !reflect.DeepEqual(expected, spec) {
+		t.Errorf("expected spec %v, got %v", expected, spec)
+	}
+	if e, a := []string{"a", "b"}, appliedManagers(obj); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected managers %v, got %v", e, a)
+	}
+	if _, err := apply("b", `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w"}, "spec": {"color": "blue"}}`); !errors.IsConflict(err) {
+		t.Errorf("expected a conflict, got %v", err)
+	}
+}
+
+func managersByOperation(obj metav1.Object, operation metav1.ManagedFieldsOperationType) []string {
+	var managers []string
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Operation == operation {
+			managers = append(managers, entry.Manager)
+		}
+	}
+	return managers
+}
+
+func TestUpdateConflictsWithApply(t *testing.T) {
+	reaction := newApplyReaction(t)
+	if _, err := applyDeployment(reaction, "controller", false, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 1}}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// An update of the replicas makes the updater their owner.
+	_, obj, err := reaction(NewGetAction(deploymentsResource, "default", "web"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	deployment := obj.(*appsv1.Deployment)
+	replicas := int32(3)
+	deployment.Spec.Replicas = &replicas
+	if _, obj, err = reaction(NewUpdateAction(deploymentsResource, "default", deployment)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []string{defaultFieldManager}, managersByOperation(obj.(*appsv1.Deployment), metav1.ManagedFieldsOperationUpdate); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected update managers %v, got %v", e, a)
+	}
+
+	_, err = applyDeployment(reaction, "controller", false, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 1}}`)
+	if !errors.IsConflict(err) {
+		t.Fatalf("expected a conflict with the updater, got %v", err)
+	}
+	causes := err.(errors.APIStatus).Status().Details.Causes
+	expected := []metav1.StatusCause{{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "` + defaultFieldManager + `" using apps/v1`,
+		Field:   ".spec.replicas",
+	}}
+	if !reflect.DeepEqual(expected, causes) {
+		t.Errorf("expected causes %v, got %v", expected, causes)
+	}
+
+	// A patch records the manager it names.
+	patch := NewPatchActionWithOptions(deploymentsResource, "default", "web", types.MergePatchType, []byte(`{"spec": {"paused": true}}`), metav1.PatchOptions{FieldManager: "patcher"})
+	if _, obj, err = reaction(patch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	managers := managersByOperation(obj.(*appsv1.Deployment), metav1.ManagedFieldsOperationUpdate)
+	sort.Strings(managers)
+	if e, a := []string{"patcher", defaultFieldManager}, managers; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected update managers %v, got %v", e, a)
+	}
+}
+
+func TestCreateRecordsManagerWithAPIServerSemantics(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+
+	// Without server semantics, objects without managers don't get any.
+	reaction := ObjectReaction(NewObjectTracker(scheme, codecs.UniversalDecoder()))
+	_, obj, err := reaction(NewCreateAction(deploymentsResource, "default", deployment))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if managed := obj.(*appsv1.Deployment).ManagedFields; len(managed) != 0 {
+		t.Errorf("expected no managed fields, got %v", managed)
+	}
+
+	reaction = ObjectReaction(NewObjectTracker(scheme, codecs.UniversalDecoder(), WithAPIServerSemantics()))
+	_, obj, err = reaction(NewCreateAction(deploymentsResource, "default", deployment))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []string{defaultFieldManager}, managersByOperation(obj.(*appsv1.Deployment), metav1.ManagedFieldsOperationUpdate); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected update managers %v, got %v", e, a)
+	}
+}
+
+func TestConcurrentApplies(t *testing.T) {
+	reaction := newApplyReaction(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			manager := fmt.Sprintf("manager-%d", i)
+			patch := fmt.Sprintf(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "labels": {%q: "true"}}}`, manager)
+			if _, err := applyDeployment(reaction, manager, false, patch); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	_, obj, err := reaction(NewGetAction(deploymentsResource, "default", "web"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if labels := obj.(*appsv1.Deployment).Labels; len(labels) != 10 {
+		t.Errorf("expected the labels of all 10 managers, got %v", labels)
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// fieldLabelConverter is implemented by schemes that convert field selector
+// labels per type, like runtime.Scheme.
+type fieldLabelConverter interface {
+	ConvertFieldLabel(gvk schema.GroupVersionKind, label, value string) (string, string, error)
+}
+
+// listFilter selects the objects of a list or watch request by the label
+// and field selectors of its options.
+type listFilter struct {
+	scheme ObjectScheme
+	labels labels.Selector
+	fields fields.Selector
+}
+
+func newListFilter(scheme ObjectScheme, opts metav1.ListOptions) (*listFilter, error) {
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid label selector %q: %v", opts.LabelSelector, err))
+	}
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid field selector %q: %v", opts.FieldSelector, err))
+	}
+	return &listFilter{scheme: scheme, labels: labelSelector, fields: fieldSelector}, nil
+}
+
+// matches returns true if obj is selected by the filter. Field selector
+// labels are converted with the conversion the scheme has for the type of
+// obj, and looked up as paths of the fields of obj. Labels the scheme can't
+// convert are looked up as they are, since the schemes of clients usually
+// don't have the conversions of the apiserver.
+func (f *listFilter) matches(obj runtime.Object) bool {
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if !f.labels.Matches(labels.Set(objMeta.GetLabels())) {
+		return false
+	}
+	if f.fields.Empty() {
+		return true
+	}
+
+	converter, canConvert := f.scheme.(fieldLabelConverter)
+	gvk := f.objectKind(obj)
+	selector, err := f.fields.Transform(func(label, value string) (string, string, error) {
+		if canConvert {
+			if newLabel, newValue, err := converter.ConvertFieldLabel(gvk, label, value); err == nil {
+				return newLabel, newValue, nil
+			}
+		}
+		return label, value, nil
+	})
+	if err != nil {
+		return false
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false
+	}
+	set := fields.Set{}
+	for _, requirement := range selector.Requirements() {
+		set[requirement.Field] = fieldValue(content, requirement.Field)
+	}
+	return selector.Matches(set)
+}
+
+// objectKind returns the kind of obj, from its type meta if it is set.
+func (f *listFilter) objectKind(obj runtime.Object) schema.GroupVersionKind {
+	if gvk := obj.GetObjectKind().GroupVersionKind(); !gvk.Empty() {
+		return gvk
+	}
+	if gvks, _, err := f.scheme.ObjectKinds(obj); err == nil && len(gvks) > 0 {
+		return gvks[0]
+	}
+	return schema.GroupVersionKind{}
+}
+
+// fieldValue returns the value of the field at the dotted path in content
+// as a field selector compares it. Missing fields are empty.
+func fieldValue(content map[string]interface{}, path string) string {
+	value, found, err := unstructured.NestedFieldNoCopy(content, strings.Split(path, ".")...)
+	if err != nil || !found || value == nil {
+		return ""
+	}
+	switch value := value.(type) {
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// trackerWatcher is a watch of the tracker with the filter of its request.
+type trackerWatcher struct {
+	eventSink
+	filter *listFilter
+}
+
+// eventSink is a watch.Interface that events can be sent to.
+type eventSink interface {
+	watch.Interface
+	Action(eventType watch.EventType, obj runtime.Object)
+}
+
+// send sends an event for obj, which replaced old if it was modified, if
+// the watcher selects either of them. Like the apiserver, modifications
+// that make an object match the filter are sent as additions and those
+// that make it stop matching as deletions.
+func (w *trackerWatcher) send(eventType watch.EventType, old, obj runtime.Object) {
+	if eventType != watch.Modified || old == nil {
+		if w.filter.matches(obj) {
+			w.Action(eventType, obj)
+		}
+		return
+	}
+	oldMatches, newMatches := w.filter.matches(old), w.filter.matches(obj)
+	switch {
+	case oldMatches && newMatches:
+		w.Action(watch.Modified, obj)
+	case newMatches:
+		w.Action(watch.Added, obj)
+	case oldMatches:
+		// The last state the watcher selected is deleted at the
+		// resourceVersion of the modification.
+		old = old.DeepCopyObject()
+		if oldMeta, err := meta.Accessor(old); err == nil {
+			if objMeta, err := meta.Accessor(obj); err == nil {
+				oldMeta.SetResourceVersion(objMeta.GetResourceVersion())
+			}
+		}
+		w.Action(watch.Deleted, old)
+	}
+}
+
+// continueToken is the position of the next page of a list request, encoded
+// like the apiserver encodes it.
+type continueToken struct {
+	APIVersion      string `json:"v"`
+	ResourceVersion uint64 `json:"rv"`
+	StartNamespace  string `json:"ns,omitempty"`
+	StartName       string `json:"start"`
+}
+
+func encodeContinue(resourceVersion uint64, last types.NamespacedName) (string, error) {
+	out, err := json.Marshal(&continueToken{
+		APIVersion:      "meta.k8s.io/v1",
+		ResourceVersion: resourceVersion,
+		StartNamespace:  last.Namespace,
+		StartName:       last.Name,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+func decodeContinue(token string) (*continueToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("continue key is not valid: %v", err))
+	}
+	c := &continueToken{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("continue key is not valid: %v", err))
+	}
+	if c.APIVersion != "meta.k8s.io/v1" {
+		return nil, errors.NewBadRequest(fmt.Sprintf("continue key is not valid: server does not recognize this encoded key version %q", c.APIVersion))
+	}
+	return c, nil
+}
+
+// paginate returns the page of the sorted objs the limit and continue token
+// in opts ask for, and sets the continue token and remaining item count of
+// listMeta.
+func (t *tracker) paginate(objs []runtime.Object, opts metav1.ListOptions, listMeta metav1.ListInterface) ([]runtime.Object, error) {
+	if len(opts.Continue) > 0 {
+		if len(opts.ResourceVersion) > 0 && opts.ResourceVersion != "0" {
+			return nil, errors.NewBadRequest("specifying resource version is not allowed when using continue")
+		}
+		token, err := decodeContinue(opts.Continue)
+		if err != nil {
+			return nil, err
+		}
+		start := 0
+		for ; start < len(objs); start++ {
+			objMeta, err := meta.Accessor(objs[start])
+			if err != nil {
+				return nil, err
+			}
+			if objMeta.GetNamespace() > token.StartNamespace ||
+				(objMeta.GetNamespace() == token.StartNamespace && objMeta.GetName() > token.StartName) {
+				break
+			}
+		}
+		objs = objs[start:]
+	}
+	if opts.Limit <= 0 || int64(len(objs)) <= opts.Limit {
+		return objs, nil
+	}
+
+	last, err := meta.Accessor(objs[opts.Limit-1])
+	if err != nil {
+		return nil, err
+	}
+	token, err := encodeContinue(t.resourceVersion, types.NamespacedName{Namespace: last.GetNamespace(), Name: last.GetName()})
+	if err != nil {
+		return nil, err
+	}
+	remaining := int64(len(objs)) - opts.Limit
+	listMeta.SetContinue(token)
+	listMeta.SetRemainingItemCount(&remaining)
+	return objs[:opts.Limit], nil
+}
+
+// checkListResourceVersion returns an error if a list with the
+// resourceVersion in opts can't be served from the current state.
+func (t *tracker) checkListResourceVersion(opts metav1.ListOptions) error {
+	if len(opts.ResourceVersion) == 0 {
+		if len(opts.ResourceVersionMatch) > 0 {
+			return errors.NewBadRequest("resourceVersionMatch is forbidden unless resourceVersion is provided")
+		}
+		return nil
+	}
+	resourceVersion, err := strconv.ParseUint(opts.ResourceVersion, 10, 64)
+	if err != nil {
+		return errors.NewBadRequest(fmt.Sprintf("invalid resource version %q", opts.ResourceVersion))
+	}
+	switch {
+	case resourceVersion > t.resourceVersion:
+		err := errors.NewTimeoutError(fmt.Sprintf("Too large resource version: %d, current: %d", resourceVersion, t.resourceVersion), 1)
+		err.ErrStatus.Details.Causes = []metav1.StatusCause{{Type: metav1.CauseTypeResourceVersionTooLarge, Message: "Too large resource version"}}
+		return err
+	case opts.ResourceVersionMatch == metav1.ResourceVersionMatchExact && resourceVersion < t.resourceVersion:
+		return errors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", resourceVersion, t.resourceVersion))
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var podsKind = corev1.SchemeGroupVersion.WithKind("Pod")
+var podsResource = corev1.SchemeGroupVersion.WithResource("pods")
+
+func newPodTracker(t *testing.T, opts ...ObjectTrackerOption) ObjectTracker {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	err := scheme.AddFieldLabelConversionFunc(podsKind, func(label, value string) (string, string, error) {
+		if label == "spec.host" {
+			return "spec.nodeName", value, nil
+		}
+		return label, value, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+	return NewObjectTracker(scheme, codecs.UniversalDecoder(), opts...)
+}
+
+func newPod(name, node string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: node},
+	}
+}
+
+func listPodNames(t *testing.T, o ObjectTracker, opts metav1.ListOptions) ([]string, *corev1.PodList) {
+	obj, err := o.List(podsResource, podsKind, "default", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list := obj.(*corev1.PodList)
+	var names []string
+	for _, pod := range list.Items {
+		names = append(names, pod.Name)
+	}
+	return names, list
+}
+
+func TestListSelectors(t *testing.T) {
+	o := newPodTracker(t)
+	for _, pod := range []*corev1.Pod{
+		newPod("a", "node-1", map[string]string{"app": "web"}),
+		newPod("b", "node-2", map[string]string{"app": "web"}),
+		newPod("c", "", map[string]string{"app": "db"}),
+	} {
+		if err := o.Add(pod); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		opts     metav1.ListOptions
+		expected []string
+	}{
+		{name: "everything", expected: []string{"a", "b", "c"}},
+		{name: "labels", opts: metav1.ListOptions{LabelSelector: "app=web"}, expected: []string{"a", "b"}},
+		{name: "fields", opts: metav1.ListOptions{FieldSelector: "spec.nodeName=node-2"}, expected: []string{"b"}},
+		{name: "unset field", opts: metav1.ListOptions{FieldSelector: "spec.nodeName="}, expected: []string{"c"}},
+		{name: "converted field", opts: metav1.ListOptions{FieldSelector: "spec.host!=node-1"}, expected: []string{"b", "c"}},
+		{name: "labels and fields", opts: metav1.ListOptions{LabelSelector: "app=web", FieldSelector: "metadata.name!=a"}, expected: []string{"b"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if names, _ := listPodNames(t, o, tc.opts); !reflect.DeepEqual(tc.expected, names) {
+				t.Errorf("expected %v, got %v", tc.expected, names)
+			}
+		})
+	}
+
+	if _, err := o.List(podsResource, podsKind, "default", metav1.ListOptions{LabelSelector: "app in web"}); !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request for an invalid selector, got %v", err)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	o := newPodTracker(t)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		if err := o.Add(newPod(name, "", map[string]string{"app": "web"})); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var pages [][]string
+	opts := metav1.ListOptions{Limit: 2}
+	for {
+		names, list := listPodNames(t, o, opts)
+		pages = append(pages, names)
+		if len(list.Continue) == 0 {
+			if list.RemainingItemCount != nil {
+				t.Errorf("expected no remaining item count on the last page, got %d", *list.RemainingItemCount)
+			}
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	if e, a := [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, pages; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected pages %v, got %v", e, a)
+	}
+
+	if _, err := o.List(podsResource, podsKind, "default", metav1.ListOptions{Continue: "invalid"}); !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request for an invalid continue token, got %v", err)
+	}
+}
+
+func TestListResourceVersion(t *testing.T) {
+	o := newPodTracker(t, WithAPIServerSemantics())
+	for _, name := range []string{"a", "b"} {
+		if err := o.Add(newPod(name, "", nil)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if _, list := listPodNames(t, o, metav1.ListOptions{ResourceVersion: "1"}); list.ResourceVersion != "2" {
+		t.Errorf("expected the latest resourceVersion, got %q", list.ResourceVersion)
+	}
+	_, err := o.List(podsResource, podsKind, "default", metav1.ListOptions{ResourceVersion: "3"})
+	if !errors.IsTimeout(err) || !errors.HasStatusCause(err, metav1.CauseTypeResourceVersionTooLarge) {
+		t.Errorf("expected a too large resourceVersion, got %v", err)
+	}
+	_, err = o.List(podsResource, podsKind, "default", metav1.ListOptions{ResourceVersion: "1", ResourceVersionMatch: metav1.ResourceVersionMatchExact})
+	if !errors.IsResourceExpired(err) {
+		t.Errorf("expected an expired resourceVersion, got %v", err)
+	}
+	_, err = o.List(podsResource, podsKind, "default", metav1.ListOptions{ResourceVersion: "2", Continue: "token"})
+	if !errors.IsBadRequest(err) {
+		t.Errorf("expected a bad request for a resourceVersion with a continue token, got %v", err)
+	}
+}
+
+func TestWatchSelectors(t *testing.T) {
+	o := newPodTracker(t)
+	w, err := o.Watch(podsResource, "default", metav1.ListOptions{FieldSelector: "spec.nodeName=node-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pod := newPod("a", "", nil)
+	if err := o.Create(podsResource, pod, "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, node := range []string{"node-1", "node-2"} {
+		pod.Spec.NodeName = node
+		if err := o.Update(podsResource, pod, "default"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := o.Create(podsResource, newPod("b", "node-1", nil), "default"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type event struct {
+		eventType watch.EventType
+		name      string
+		node      string
+	}
+	var events []event
+	for i := 0; i < 3; i++ {
+		e := <-w.ResultChan()
+		pod := e.Object.(*corev1.Pod)
+		events = append(events, event{e.Type, pod.Name, pod.Spec.NodeName})
+	}
+	expected := []event{
+		{watch.Added, "a", "node-1"},
+		{watch.Deleted, "a", "node-1"},
+		{watch.Added, "b", "node-1"},
+	}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
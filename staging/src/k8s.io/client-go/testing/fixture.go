@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strconv"
 	"sync"
 
 	jsonpatch "github.com/evanphx/json-patch"
@@ -31,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/apimachinery/pkg/watch"
 	restclient "k8s.io/client-go/rest"
@@ -54,17 +56,32 @@ type ObjectTracker interface {
 	Update(gvr schema.GroupVersionResource, obj runtime.Object, ns string) error
 
 	// List retrieves all objects of a given kind in the given
-	// namespace. Only non-List kinds are accepted.
-	List(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string) (runtime.Object, error)
+	// namespace. Only non-List kinds are accepted. The objects are
+	// filtered by the label and field selectors in opts and paginated
+	// by its limit and continue token.
+	List(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string, opts ...metav1.ListOptions) (runtime.Object, error)
 
 	// Delete deletes an existing object from the tracker. If object
 	// didn't exist in the tracker prior to deletion, Delete returns
-	// no error.
-	Delete(gvr schema.GroupVersionResource, ns, name string) error
+	// no error. Trackers with apiserver semantics check the
+	// preconditions in opts.
+	Delete(gvr schema.GroupVersionResource, ns, name string, opts ...metav1.DeleteOptions) error
 
 	// Watch watches objects from the tracker. Watch returns a channel
-	// which will push added / modified / deleted object.
-	Watch(gvr schema.GroupVersionResource, ns string) (watch.Interface, error)
+	// which will push added / modified / deleted object, filtered by
+	// the label and field selectors in opts. Trackers with apiserver
+	// semantics start the watch from the resourceVersion in opts.
+	Watch(gvr schema.GroupVersionResource, ns string, opts ...metav1.ListOptions) (watch.Interface, error)
+}
+
+// ObjectApplier is implemented by ObjectTrackers that support server-side
+// apply. ObjectReaction uses it to handle apply patches.
+type ObjectApplier interface {
+	// Apply applies the apply configuration patch to the object with the
+	// given name as the field manager in opts, creating the object if it
+	// doesn't exist, and returns the result. It fails with a Conflict if the
+	// patch changes fields owned by other managers, unless opts.Force is set.
+	Apply(gvr schema.GroupVersionResource, patch []byte, ns, name string, opts metav1.PatchOptions) (runtime.Object, error)
 }
 
 // ObjectScheme abstracts the implementation of common operations on objects.
@@ -86,7 +103,7 @@ func ObjectReaction(tracker ObjectTracker) ReactionFunc {
 		switch action := action.(type) {
 
 		case ListActionImpl:
-			obj, err := tracker.List(gvr, action.GetKind(), ns)
+			obj, err := tracker.List(gvr, action.GetKind(), ns, action.ListOptions)
 			return true, obj, err
 
 		case GetActionImpl:
@@ -94,44 +111,62 @@ func ObjectReaction(tracker ObjectTracker) ReactionFunc {
 			return true, obj, err
 
 		case CreateActionImpl:
-			objMeta, err := meta.Accessor(action.GetObject())
+			// The tracker may set the name and other server populated
+			// fields, so work on a copy of the object of the action.
+			obj := action.GetObject().DeepCopyObject()
+			objMeta, err := meta.Accessor(obj)
 			if err != nil {
 				return true, nil, err
 			}
 			if action.GetSubresource() == "" {
-				err = tracker.Create(gvr, action.GetObject(), ns)
+				err = tracker.Create(gvr, obj, ns)
 			} else {
 				// TODO: Currently we're handling subresource creation as an update
 				// on the enclosing resource. This works for some subresources but
 				// might not be generic enough.
-				err = tracker.Update(gvr, action.GetObject(), ns)
+				err = tracker.Update(gvr, obj, ns)
 			}
 			if err != nil {
 				return true, nil, err
 			}
-			obj, err := tracker.Get(gvr, ns, objMeta.GetName())
+			obj, err = tracker.Get(gvr, ns, objMeta.GetName())
 			return true, obj, err
 
 		case UpdateActionImpl:
-			objMeta, err := meta.Accessor(action.GetObject())
+			obj := action.GetObject().DeepCopyObject()
+			objMeta, err := meta.Accessor(obj)
 			if err != nil {
 				return true, nil, err
 			}
-			err = tracker.Update(gvr, action.GetObject(), ns)
+			err = tracker.Update(gvr, obj, ns)
 			if err != nil {
 				return true, nil, err
 			}
-			obj, err := tracker.Get(gvr, ns, objMeta.GetName())
-			return true, obj, err
+			stored, err := tracker.Get(gvr, ns, objMeta.GetName())
+			if errors.IsNotFound(err) && objMeta.GetDeletionTimestamp() != nil {
+				// The update removed the last finalizer of an object
+				// being deleted, so the object is gone.
+				return true, obj, nil
+			}
+			return true, stored, err
 
 		case DeleteActionImpl:
-			err := tracker.Delete(gvr, ns, action.GetName())
+			err := tracker.Delete(gvr, ns, action.GetName(), action.DeleteOptions)
 			if err != nil {
 				return true, nil, err
 			}
 			return true, nil, nil
 
 		case PatchActionImpl:
+			if action.GetPatchType() == types.ApplyPatchType {
+				applier, ok := tracker.(ObjectApplier)
+				if !ok {
+					return true, nil, fmt.Errorf("PatchType is not supported")
+				}
+				obj, err := applier.Apply(gvr, action.GetPatch(), ns, action.GetName(), action.PatchOptions)
+				return true, obj, err
+			}
+
 			obj, err := tracker.Get(gvr, ns, action.GetName())
 			if err != nil {
 				return true, nil, err
@@ -182,7 +217,12 @@ func ObjectReaction(tracker ObjectTracker) ReactionFunc {
 				return true, nil, fmt.Errorf("PatchType is not supported")
 			}
 
-			if err = tracker.Update(gvr, obj, ns); err != nil {
+			if updater, ok := tracker.(managedUpdater); ok && len(action.PatchOptions.FieldManager) > 0 {
+				err = updater.updateAs(gvr, obj, ns, action.PatchOptions.FieldManager)
+			} else {
+				err = tracker.Update(gvr, obj, ns)
+			}
+			if err != nil {
 				return true, nil, err
 			}
 
@@ -195,32 +235,56 @@ func ObjectReaction(tracker ObjectTracker) ReactionFunc {
 }
 
 type tracker struct {
-	scheme  ObjectScheme
-	decoder runtime.Decoder
-	lock    sync.RWMutex
-	objects map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object
+	scheme       ObjectScheme
+	decoder      runtime.Decoder
+	fieldManager *fieldManager
+	lock         sync.RWMutex
+	objects      map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object
 	// The value type of watchers is a map of which the key is either a namespace or
 	// all/non namespace aka "" and its value is list of fake watchers.
 	// Manipulations on resources will broadcast the notification events into the
 	// watchers' channel. Note that too many unhandled events (currently 100,
-	// see apimachinery/pkg/watch.DefaultChanSize) will cause a panic.
-	watchers map[schema.GroupVersionResource]map[string][]*watch.RaceFreeFakeWatcher
+	// see apimachinery/pkg/watch.DefaultChanSize) will cause a panic, unless
+	// the tracker has WithAPIServerSemantics, whose watchers queue events.
+	watchers map[schema.GroupVersionResource]map[string][]*trackerWatcher
+
+	// serverSemantics is set if the tracker populates and checks server
+	// fields like the apiserver, see WithAPIServerSemantics.
+	serverSemantics bool
+	// resourceVersion is the resourceVersion of the latest write.
+	resourceVersion uint64
+	// events holds the latest events, oldest first, so that watches can
+	// start from an earlier resourceVersion.
+	events []trackerEvent
 }
 
 var _ ObjectTracker = &tracker{}
+var _ ObjectApplier = &tracker{}
+var _ managedUpdater = &tracker{}
+
+// managedUpdater is implemented by trackers that record the field manager
+// of an update, for patches that name one.
+type managedUpdater interface {
+	updateAs(gvr schema.GroupVersionResource, obj runtime.Object, ns, manager string) error
+}
 
 // NewObjectTracker returns an ObjectTracker that can be used to keep track
 // of objects for the fake clientset. Mostly useful for unit tests.
-func NewObjectTracker(scheme ObjectScheme, decoder runtime.Decoder) ObjectTracker {
-	return &tracker{
-		scheme:   scheme,
-		decoder:  decoder,
-		objects:  make(map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object),
-		watchers: make(map[schema.GroupVersionResource]map[string][]*watch.RaceFreeFakeWatcher),
-	}
+func NewObjectTracker(scheme ObjectScheme, decoder runtime.Decoder, opts ...ObjectTrackerOption) ObjectTracker {
+	t := &tracker{
+		scheme:       scheme,
+		decoder:      decoder,
+		fieldManager: newFieldManager(scheme),
+		objects:      make(map[schema.GroupVersionResource]map[types.NamespacedName]runtime.Object),
+		watchers:     make(map[schema.GroupVersionResource]map[string][]*trackerWatcher),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
-func (t *tracker) List(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string) (runtime.Object, error) {
+func (t *tracker) List(gvr schema.GroupVersionResource, gvk schema.GroupVersionKind, ns string, opts ...metav1.ListOptions) (runtime.Object, error) {
 	// Heuristic for list kind: original kind + List suffix. Might
 	// not always be true but this tracker has a pretty limited
 	// understanding of the actual API model.
@@ -240,10 +304,32 @@ func (t *tracker) List(gvr schema.GroupVersionResource, gvk schema.GroupVersionK
 	if !meta.IsListType(list) {
 		return nil, fmt.Errorf("%q is not a list type", listGVK.Kind)
 	}
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return nil, err
+	}
+
+	var listOpts metav1.ListOptions
+	if len(opts) > 0 {
+		listOpts = opts[0]
+	}
+	filter, err := newListFilter(t.scheme, listOpts)
+	if err != nil {
+		return nil, err
+	}
 
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
+	if t.serverSemantics {
+		if len(listOpts.Continue) == 0 {
+			if err := t.checkListResourceVersion(listOpts); err != nil {
+				return nil, err
+			}
+		}
+		listMeta.SetResourceVersion(strconv.FormatUint(t.resourceVersion, 10))
+	}
+
 	objs, ok := t.objects[gvr]
 	if !ok {
 		return list, nil
@@ -253,23 +339,50 @@ func (t *tracker) List(gvr schema.GroupVersionResource, gvk schema.GroupVersionK
 	if err != nil {
 		return nil, err
 	}
-	if err := meta.SetList(list, matchingObjs); err != nil {
+	selectedObjs := []runtime.Object{}
+	for _, obj := range matchingObjs {
+		if filter.matches(obj) {
+			selectedObjs = append(selectedObjs, obj)
+		}
+	}
+	selectedObjs, err = t.paginate(selectedObjs, listOpts, listMeta)
+	if err != nil {
+		return nil, err
+	}
+	if err := meta.SetList(list, selectedObjs); err != nil {
 		return nil, err
 	}
 	return list.DeepCopyObject(), nil
 }
 
-func (t *tracker) Watch(gvr schema.GroupVersionResource, ns string) (watch.Interface, error) {
+func (t *tracker) Watch(gvr schema.GroupVersionResource, ns string, opts ...metav1.ListOptions) (watch.Interface, error) {
+	var listOpts metav1.ListOptions
+	if len(opts) > 0 {
+		listOpts = opts[0]
+	}
+	filter, err := newListFilter(t.scheme, listOpts)
+	if err != nil {
+		return nil, err
+	}
+
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	fakewatcher := watch.NewRaceFreeFake()
+	fakewatcher := &trackerWatcher{eventSink: watch.NewRaceFreeFake(), filter: filter}
+	if t.serverSemantics {
+		// Replays can be longer than the channel of a fake watcher.
+		fakewatcher.eventSink = newQueueWatcher()
+		if err := t.replay(fakewatcher, gvr, ns, listOpts.ResourceVersion); err != nil {
+			fakewatcher.Stop()
+			return nil, err
+		}
+	}
 
 	if _, exists := t.watchers[gvr]; !exists {
-		t.watchers[gvr] = make(map[string][]*watch.RaceFreeFakeWatcher)
+		t.watchers[gvr] = make(map[string][]*trackerWatcher)
 	}
 	t.watchers[gvr][ns] = append(t.watchers[gvr][ns], fakewatcher)
-	return fakewatcher, nil
+	return fakewatcher.eventSink, nil
 }
 
 func (t *tracker) Get(gvr schema.GroupVersionResource, ns, name string) (runtime.Object, error) {
@@ -333,8 +446,7 @@ func (t *tracker) Add(obj runtime.Object) error {
 			gvr.Version = ""
 		}
 
-		err := t.add(gvr, obj, objMeta.GetNamespace(), false)
-		if err != nil {
+		if _, err := t.add(gvr, obj, objMeta.GetNamespace(), false, ""); err != nil {
 			return err
 		}
 	}
@@ -342,15 +454,51 @@ func (t *tracker) Add(obj runtime.Object) error {
 }
 
 func (t *tracker) Create(gvr schema.GroupVersionResource, obj runtime.Object, ns string) error {
-	return t.add(gvr, obj, ns, false)
+	stored, err := t.add(gvr, obj, ns, false, defaultFieldManager)
+	if err != nil {
+		return err
+	}
+	if t.serverSemantics {
+		return copyServerFields(stored, obj)
+	}
+	return copyManagedFields(stored, obj)
 }
 
 func (t *tracker) Update(gvr schema.GroupVersionResource, obj runtime.Object, ns string) error {
-	return t.add(gvr, obj, ns, true)
+	return t.updateAs(gvr, obj, ns, defaultFieldManager)
+}
+
+func (t *tracker) updateAs(gvr schema.GroupVersionResource, obj runtime.Object, ns, manager string) error {
+	stored, err := t.add(gvr, obj, ns, true, manager)
+	if err != nil {
+		return err
+	}
+	if t.serverSemantics {
+		return copyServerFields(stored, obj)
+	}
+	return copyManagedFields(stored, obj)
+}
+
+func (t *tracker) Apply(gvr schema.GroupVersionResource, patch []byte, ns, name string, opts metav1.PatchOptions) (runtime.Object, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// The object is read and written under the same lock, so that no other
+	// write can come in between and be lost.
+	live, exists := t.objects[gvr][types.NamespacedName{Namespace: ns, Name: name}]
+	obj, err := t.fieldManager.apply(live, patch, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := t.addLocked(gvr, obj, ns, exists, "")
+	if err != nil {
+		return nil, err
+	}
+	return stored.DeepCopyObject(), nil
 }
 
-func (t *tracker) getWatches(gvr schema.GroupVersionResource, ns string) []*watch.RaceFreeFakeWatcher {
-	watches := []*watch.RaceFreeFakeWatcher{}
+func (t *tracker) getWatches(gvr schema.GroupVersionResource, ns string) []*trackerWatcher {
+	watches := []*trackerWatcher{}
 	if t.watchers[gvr] != nil {
 		if w := t.watchers[gvr][ns]; w != nil {
 			watches = append(watches, w...)
@@ -364,10 +512,18 @@ func (t *tracker) getWatches(gvr schema.GroupVersionResource, ns string) []*watc
 	return watches
 }
 
-func (t *tracker) add(gvr schema.GroupVersionResource, obj runtime.Object, ns string, replaceExisting bool) error {
+// add stores a copy of obj and returns it. manager is the field manager of
+// the change, or empty if no manager is recorded for it. Managers are only
+// recorded with WithAPIServerSemantics, or for objects that already have
+// managedFields, e.g. because they were applied.
+func (t *tracker) add(gvr schema.GroupVersionResource, obj runtime.Object, ns string, replaceExisting bool, manager string) (runtime.Object, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
+	return t.addLocked(gvr, obj, ns, replaceExisting, manager)
+}
 
+// addLocked is add for callers that hold the lock.
+func (t *tracker) addLocked(gvr schema.GroupVersionResource, obj runtime.Object, ns string, replaceExisting bool, manager string) (runtime.Object, error) {
 	gr := gvr.GroupResource()
 
 	// To avoid the object from being accidentally modified by caller
@@ -377,7 +533,7 @@ func (t *tracker) add(gvr schema.GroupVersionResource, obj runtime.Object, ns st
 
 	newMeta, err := meta.Accessor(obj)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Propagate namespace to the new object if hasn't already been set.
@@ -387,7 +543,7 @@ func (t *tracker) add(gvr schema.GroupVersionResource, obj runtime.Object, ns st
 
 	if ns != newMeta.GetNamespace() {
 		msg := fmt.Sprintf("request namespace does not match object namespace, request: %q object: %q", ns, newMeta.GetNamespace())
-		return errors.NewBadRequest(msg)
+		return nil, errors.NewBadRequest(msg)
 	}
 
 	_, ok := t.objects[gvr]
@@ -395,30 +551,68 @@ func (t *tracker) add(gvr schema.GroupVersionResource, obj runtime.Object, ns st
 		t.objects[gvr] = make(map[types.NamespacedName]runtime.Object)
 	}
 
+	if t.serverSemantics && !replaceExisting {
+		if err := t.generateName(gvr, newMeta); err != nil {
+			return nil, err
+		}
+	}
+
 	namespacedName := types.NamespacedName{Namespace: newMeta.GetNamespace(), Name: newMeta.GetName()}
-	if _, ok = t.objects[gvr][namespacedName]; ok {
-		if replaceExisting {
-			for _, w := range t.getWatches(gvr, ns) {
-				w.Modify(obj)
+	if existing, ok := t.objects[gvr][namespacedName]; ok {
+		if !replaceExisting {
+			return nil, errors.NewAlreadyExists(gr, newMeta.GetName())
+		}
+		if t.serverSemantics {
+			if err := t.updateServerFields(gr, existing, obj); err != nil {
+				return nil, err
 			}
-			t.objects[gvr][namespacedName] = obj
-			return nil
 		}
-		return errors.NewAlreadyExists(gr, newMeta.GetName())
+		t.recordManager(existing, obj, manager)
+		if t.serverSemantics && newMeta.GetDeletionTimestamp() != nil && len(newMeta.GetFinalizers()) == 0 {
+			// The last finalizer of an object being deleted was removed.
+			delete(t.objects[gvr], namespacedName)
+			t.notify(gvr, ns, watch.Deleted, existing, obj)
+			return obj, nil
+		}
+		t.objects[gvr][namespacedName] = obj
+		t.notify(gvr, ns, watch.Modified, existing, obj)
+		return obj, nil
 	}
 
 	if replaceExisting {
 		// Tried to update but no matching object was found.
-		return errors.NewNotFound(gr, newMeta.GetName())
+		return nil, errors.NewNotFound(gr, newMeta.GetName())
 	}
 
+	if t.serverSemantics {
+		t.createServerFields(newMeta)
+	}
+	t.recordManager(nil, obj, manager)
 	t.objects[gvr][namespacedName] = obj
+	t.notify(gvr, ns, watch.Added, nil, obj)
 
-	for _, w := range t.getWatches(gvr, ns) {
-		w.Add(obj)
+	return obj, nil
+}
+
+// recordManager records the fields obj changes compared to existing, which
+// is nil if obj is being created, as owned by manager, see add. As in the
+// apiserver, failing to record them doesn't fail the write.
+func (t *tracker) recordManager(existing, obj runtime.Object, manager string) {
+	if len(manager) == 0 || !(t.serverSemantics || hasManagedFields(existing) || hasManagedFields(obj)) {
+		return
 	}
+	if err := t.fieldManager.update(existing, obj, manager); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to record the fields managed by %q: %v", manager, err))
+	}
+}
 
-	return nil
+// hasManagedFields returns true if obj is an object with managedFields.
+func hasManagedFields(obj runtime.Object) bool {
+	if obj == nil {
+		return false
+	}
+	objMeta, err := meta.Accessor(obj)
+	return err == nil && len(objMeta.GetManagedFields()) > 0
 }
 
 func (t *tracker) addList(obj runtime.Object, replaceExisting bool) error {
@@ -438,7 +632,7 @@ func (t *tracker) addList(obj runtime.Object, replaceExisting bool) error {
 	return nil
 }
 
-func (t *tracker) Delete(gvr schema.GroupVersionResource, ns, name string) error {
+func (t *tracker) Delete(gvr schema.GroupVersionResource, ns, name string, opts ...metav1.DeleteOptions) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
@@ -453,11 +647,43 @@ func (t *tracker) Delete(gvr schema.GroupVersionResource, ns, name string) error
 		return errors.NewNotFound(gvr.GroupResource(), name)
 	}
 
+	if t.serverSemantics {
+		if err := checkDeletePreconditions(gvr.GroupResource(), obj, opts); err != nil {
+			return err
+		}
+		existing := obj
+		obj = obj.DeepCopyObject()
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		if len(objMeta.GetFinalizers()) > 0 {
+			// Objects with finalizers are only marked as being deleted,
+			// and go away once the finalizers are removed.
+			if objMeta.GetDeletionTimestamp() == nil {
+				t.markDeleted(objMeta)
+				objs[namespacedName] = obj
+				t.notify(gvr, ns, watch.Modified, existing, obj)
+			}
+			return nil
+		}
+		objMeta.SetResourceVersion(t.nextResourceVersion())
+	}
+
 	delete(objs, namespacedName)
+	t.notify(gvr, ns, watch.Deleted, nil, obj)
+	return nil
+}
+
+// notify sends an event for obj, which replaced old if it was modified, to
+// the watchers of gvr in namespace ns.
+func (t *tracker) notify(gvr schema.GroupVersionResource, ns string, eventType watch.EventType, old, obj runtime.Object) {
 	for _, w := range t.getWatches(gvr, ns) {
-		w.Delete(obj)
+		w.send(eventType, old, obj)
+	}
+	if t.serverSemantics {
+		t.recordEvent(gvr, ns, eventType, old, obj)
 	}
-	return nil
 }
 
 // filterByNamespace returns all objects in the collection that
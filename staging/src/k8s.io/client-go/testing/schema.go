@@ -0,0 +1,216 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/structured-merge-diff/v4/schema"
+	"sigs.k8s.io/structured-merge-diff/v4/typed"
+)
+
+// untypedAtomic is the name of the type of values whose structure isn't
+// known, which are treated as a single value. The deduced type of
+// structured-merge-diff uses the same name for it.
+const untypedAtomic = "__untyped_atomic_"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeCache holds the structured-merge-diff types of Go types.
+type typeCache struct {
+	lock  sync.Mutex
+	types map[reflect.Type]typed.ParseableType
+}
+
+// parseableType returns the structured-merge-diff type of objects of the Go
+// type t, which must be a struct.
+func (c *typeCache) parseableType(t reflect.Type) typed.ParseableType {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if pt, ok := c.types[t]; ok {
+		return pt
+	}
+	if c.types == nil {
+		c.types = map[reflect.Type]typed.ParseableType{}
+	}
+	b := &schemaBuilder{names: map[reflect.Type]string{}}
+	b.addType(untypedAtomic, schema.Atom{
+		Scalar: scalarPtr("untyped"),
+		List: &schema.List{
+			ElementType:         namedTypeRef(untypedAtomic),
+			ElementRelationship: schema.Atomic,
+		},
+		Map: &schema.Map{
+			ElementType:         namedTypeRef(untypedAtomic),
+			ElementRelationship: schema.Atomic,
+		},
+	})
+	pt := typed.ParseableType{TypeRef: b.typeRef(t), Schema: &schema.Schema{Types: b.types}}
+	c.types[t] = pt
+	return pt
+}
+
+// schemaBuilder derives structured-merge-diff types from Go types, following
+// the strategic merge patch tags of their fields like the OpenAPI schemas of
+// the apiserver do. Lists with a patchMergeKey are merged by that key, lists
+// of scalars with the merge patch strategy are merged as sets, and all other
+// lists are replaced as a whole. Types with custom JSON encodings, like
+// metav1.Time or resource.Quantity, are treated as a single value.
+type schemaBuilder struct {
+	types []schema.TypeDef
+	names map[reflect.Type]string
+}
+
+func (b *schemaBuilder) addType(name string, atom schema.Atom) int {
+	b.types = append(b.types, schema.TypeDef{Name: name, Atom: atom})
+	return len(b.types) - 1
+}
+
+func (b *schemaBuilder) typeRef(t reflect.Type) schema.TypeRef {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if hasCustomEncoding(t) {
+		return namedTypeRef(untypedAtomic)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return schema.TypeRef{Inlined: schema.Atom{Scalar: scalarPtr(schema.Boolean)}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return schema.TypeRef{Inlined: schema.Atom{Scalar: scalarPtr(schema.Numeric)}}
+	case reflect.String:
+		return schema.TypeRef{Inlined: schema.Atom{Scalar: scalarPtr(schema.String)}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Byte slices are encoded as base64 strings.
+			return schema.TypeRef{Inlined: schema.Atom{Scalar: scalarPtr(schema.String)}}
+		}
+		return schema.TypeRef{Inlined: schema.Atom{List: &schema.List{
+			ElementType:         b.typeRef(t.Elem()),
+			ElementRelationship: schema.Atomic,
+		}}}
+	case reflect.Map:
+		return schema.TypeRef{Inlined: schema.Atom{Map: &schema.Map{ElementType: b.typeRef(t.Elem())}}}
+	case reflect.Struct:
+		return namedTypeRef(b.structType(t))
+	default:
+		return namedTypeRef(untypedAtomic)
+	}
+}
+
+// structType adds the type of the struct t to the schema unless it was
+// already, and returns its name.
+func (b *schemaBuilder) structType(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.PkgPath() + "." + t.Name()
+	if len(t.Name()) == 0 {
+		name = t.String()
+	}
+	// The name is registered before the fields are added, so that
+	// recursive types refer to themselves.
+	b.names[t] = name
+	i := b.addType(name, schema.Atom{})
+	fields := b.structFields(t, nil)
+	b.types[i].Atom = schema.Atom{Map: &schema.Map{Fields: fields}}
+	return name
+}
+
+// structFields appends the fields of the struct t to fields, including
+// those of inlined structs.
+func (b *schemaBuilder) structFields(t reflect.Type, fields []schema.StructField) []schema.StructField {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if len(f.PkgPath) > 0 && !f.Anonymous {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		inline := false
+		for _, option := range tag[1:] {
+			inline = inline || option == "inline"
+		}
+		if f.Anonymous && len(name) == 0 && f.Type.Kind() == reflect.Struct {
+			inline = true
+		}
+		if inline {
+			fields = b.structFields(f.Type, fields)
+			continue
+		}
+		if len(f.PkgPath) > 0 {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		fields = append(fields, schema.StructField{Name: name, Type: b.fieldType(f)})
+	}
+	return fields
+}
+
+// fieldType returns the type of the field f, merging lists as the strategic
+// merge patch tags of f ask for.
+func (b *schemaBuilder) fieldType(f reflect.StructField) schema.TypeRef {
+	ref := b.typeRef(f.Type)
+	list := ref.Inlined.List
+	if list == nil {
+		return ref
+	}
+	merge := false
+	for _, strategy := range strings.Split(f.Tag.Get("patchStrategy"), ",") {
+		merge = merge || strategy == "merge"
+	}
+	if !merge {
+		return ref
+	}
+	if key := f.Tag.Get("patchMergeKey"); len(key) > 0 {
+		list.ElementRelationship = schema.Associative
+		list.Keys = []string{key}
+	} else if list.ElementType.Inlined.Scalar != nil {
+		list.ElementRelationship = schema.Associative
+	}
+	return ref
+}
+
+// hasCustomEncoding returns true if values of type t aren't encoded as their
+// fields.
+func hasCustomEncoding(t reflect.Type) bool {
+	ptr := reflect.PtrTo(t)
+	return t.Implements(jsonMarshalerType) || ptr.Implements(jsonMarshalerType) ||
+		t.Implements(textMarshalerType) || ptr.Implements(textMarshalerType)
+}
+
+func namedTypeRef(name string) schema.TypeRef {
+	return schema.TypeRef{NamedType: &name}
+}
+
+func scalarPtr(s schema.Scalar) *schema.Scalar {
+	return &s
+}
@@ -72,6 +72,15 @@ type Config struct {
 
 	// Called whenever the ListAndWatch drops the connection with an error.
 	WatchErrorHandler WatchErrorHandler
+
+	// SnapshotStore, if set, is used by the reflector to restore the
+	// Queue from a snapshot instead of doing the initial list.
+	SnapshotStore SnapshotStore
+
+	// IncrementalList makes the reflector hand the pages of paginated
+	// lists to the Queue one at a time, if the Queue is an
+	// IncrementalReplacer.
+	IncrementalList bool
 }
 
 // ShouldResyncFunc is a type of function that indicates if a reflector should perform a
@@ -82,6 +91,12 @@ type ShouldResyncFunc func() bool
 // ProcessFunc processes a single object.
 type ProcessFunc func(obj interface{}) error
 
+// TransformFunc allows for transforming an object before it will be processed,
+// e.g. to strip fields that are never read and so reduce the memory footprint
+// of a cache.  It must return an object of the same kind and with the same
+// key as its argument, and it is allowed to mutate its argument in place.
+type TransformFunc func(interface{}) (interface{}, error)
+
 // `*controller` implements Controller
 type controller struct {
 	config         Config
@@ -138,6 +153,8 @@ func (c *controller) Run(stopCh <-chan struct{}) {
 	if c.config.WatchErrorHandler != nil {
 		r.watchErrorHandler = c.config.WatchErrorHandler
 	}
+	r.SnapshotStore = c.config.SnapshotStore
+	r.IncrementalList = c.config.IncrementalList
 
 	c.reflectorMutex.Lock()
 	c.reflector = r
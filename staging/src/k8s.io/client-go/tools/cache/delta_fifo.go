@@ -97,6 +97,13 @@ type DeltaFIFOOptions struct {
 	// When true, `Replaced` events will be sent for items passed to a Replace() call.
 	// When false, `Sync` events will be sent instead.
 	EmitDeltaTypeReplaced bool
+
+	// Transformer, if set, is called on every object handed to Add(),
+	// Update(), Delete() and Replace() before it is queued, so consumers
+	// (and any store fed from the queue) only ever see the transformed
+	// object.  Objects emitted by Resync() come from KnownObjects and are
+	// not transformed again.
+	Transformer TransformFunc
 }
 
 // NewDeltaFIFOWithOptions returns a Queue which can be used to process changes to
@@ -113,6 +120,7 @@ func NewDeltaFIFOWithOptions(opts DeltaFIFOOptions) *DeltaFIFO {
 		knownObjects: opts.KnownObjects,
 
 		emitDeltaTypeReplaced: opts.EmitDeltaTypeReplaced,
+		transformer:           opts.Transformer,
 	}
 	f.cond.L = &f.lock
 	return f
@@ -174,6 +182,13 @@ type DeltaFIFO struct {
 	populated bool
 	// initialPopulationCount is the number of items inserted by the first call of Replace()
 	initialPopulationCount int
+	// poppingInitialItem is true while the PopProcessFunc is running for an
+	// item that was inserted by the first call of Replace().  It is only
+	// meaningful to code running under the lock, i.e. the PopProcessFunc.
+	poppingInitialItem bool
+	// replaceKeys holds the keys of the items added by ReplacePage since
+	// the last BeginReplace, or nil if no such replace is in progress.
+	replaceKeys sets.String
 
 	// keyFunc is used to make the key used for queued item
 	// insertion and retrieval, and should be deterministic.
@@ -190,6 +205,9 @@ type DeltaFIFO struct {
 	// emitDeltaTypeReplaced is whether to emit the Replaced or Sync
 	// DeltaType when Replace() is called (to preserve backwards compat).
 	emitDeltaTypeReplaced bool
+
+	// Called on every object entering the queue, see DeltaFIFOOptions.
+	transformer TransformFunc
 }
 
 var (
@@ -203,6 +221,19 @@ var (
 	ErrZeroLengthDeltasObject = errors.New("0 length Deltas object; can't get key")
 )
 
+// ifDrained calls fn with the queue's lock held if the queue has synced
+// and holds no items, i.e. every Delta added so far has been popped and
+// processed.  It returns whether fn was called.
+func (f *DeltaFIFO) ifDrained(fn func()) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if !f.populated || f.initialPopulationCount > 0 || len(f.queue) > 0 {
+		return false
+	}
+	fn()
+	return true
+}
+
 // Close the queue.
 func (f *DeltaFIFO) Close() {
 	f.lock.Lock()
@@ -240,6 +271,10 @@ func (f *DeltaFIFO) Add(obj interface{}) error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.populated = true
+	obj, err := f.transform(obj)
+	if err != nil {
+		return err
+	}
 	return f.queueActionLocked(Added, obj)
 }
 
@@ -248,6 +283,10 @@ func (f *DeltaFIFO) Update(obj interface{}) error {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	f.populated = true
+	obj, err := f.transform(obj)
+	if err != nil {
+		return err
+	}
 	return f.queueActionLocked(Updated, obj)
 }
 
@@ -285,9 +324,30 @@ func (f *DeltaFIFO) Delete(obj interface{}) error {
 	}
 
 	// exist in items and/or KnownObjects
+	obj, err = f.transform(obj)
+	if err != nil {
+		return err
+	}
 	return f.queueActionLocked(Deleted, obj)
 }
 
+// transform runs f.transformer, if any, on obj.  Tombstones are passed
+// through untouched, since the object they carry is either already
+// transformed or was supplied by the caller as-is.
+func (f *DeltaFIFO) transform(obj interface{}) (interface{}, error) {
+	if f.transformer == nil {
+		return obj, nil
+	}
+	if _, ok := obj.(DeletedFinalStateUnknown); ok {
+		return obj, nil
+	}
+	transformed, err := f.transformer(obj)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't transform object: %v", err)
+	}
+	return transformed, nil
+}
+
 // AddIfNotPresent inserts an item, and puts it in the queue. If the item is already
 // present in the set, it is neither enqueued nor added to the set.
 //
@@ -489,6 +549,9 @@ func (f *DeltaFIFO) Pop(process PopProcessFunc) (interface{}, error) {
 		}
 		id := f.queue[0]
 		f.queue = f.queue[1:]
+		// Items popped before the first replace has finished are part of
+		// the initial population too.
+		f.poppingInitialItem = !f.populated || f.initialPopulationCount > 0
 		if f.initialPopulationCount > 0 {
 			f.initialPopulationCount--
 		}
@@ -499,6 +562,7 @@ func (f *DeltaFIFO) Pop(process PopProcessFunc) (interface{}, error) {
 		}
 		delete(f.items, id)
 		err := process(item)
+		f.poppingInitialItem = false
 		if e, ok := err.(ErrRequeue); ok {
 			f.addIfNotPresent(id, item)
 			err = e.Err
@@ -524,6 +588,76 @@ func (f *DeltaFIFO) Replace(list []interface{}, resourceVersion string) error {
 	defer f.lock.Unlock()
 	keys := make(sets.String, len(list))
 
+	if err := f.replacePageLocked(list, keys); err != nil {
+		return err
+	}
+	queuedDeletions, err := f.queueReplaceDeletionsLocked(keys)
+	if err != nil {
+		return err
+	}
+
+	if !f.populated {
+		f.populated = true
+		// While there shouldn't be any queued deletions in the initial
+		// population of the queue, it's better to be on the safe side.
+		f.initialPopulationCount = len(list) + queuedDeletions
+	}
+
+	return nil
+}
+
+// BeginReplace starts a replace whose objects are handed over page by page
+// through ReplacePage, forgetting about any such replace that was begun but
+// never finished.
+func (f *DeltaFIFO) BeginReplace() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.replaceKeys = sets.String{}
+}
+
+// ReplacePage adds the given objects using the Sync or Replace DeltaType,
+// like Replace does.  The objects can be popped right away, so only a single
+// page of the list has to be held in memory at any time.
+func (f *DeltaFIFO) ReplacePage(list []interface{}) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.replaceKeys == nil {
+		return fmt.Errorf("ReplacePage called without BeginReplace")
+	}
+	return f.replacePageLocked(list, f.replaceKeys)
+}
+
+// FinishReplace ends the replace started by BeginReplace by doing the
+// deletions Replace would have done for the concatenation of all pages.
+// The first finished replace populates the queue just like the first call
+// of Replace does: HasSynced becomes true once everything queued before it
+// returns has been popped.
+func (f *DeltaFIFO) FinishReplace(resourceVersion string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.replaceKeys == nil {
+		return fmt.Errorf("FinishReplace called without BeginReplace")
+	}
+	keys := f.replaceKeys
+	f.replaceKeys = nil
+
+	if _, err := f.queueReplaceDeletionsLocked(keys); err != nil {
+		return err
+	}
+
+	if !f.populated {
+		f.populated = true
+		// Items of earlier pages may already have been popped, so count
+		// what is still queued.
+		f.initialPopulationCount = len(f.queue)
+	}
+
+	return nil
+}
+
+// replacePageLocked adds a Sync/Replaced action for each item in list and
+// records their keys in keys.
+func (f *DeltaFIFO) replacePageLocked(list []interface{}, keys sets.String) error {
 	// keep backwards compat for old clients
 	action := Sync
 	if f.emitDeltaTypeReplaced {
@@ -532,6 +666,10 @@ func (f *DeltaFIFO) Replace(list []interface{}, resourceVersion string) error {
 
 	// Add Sync/Replaced action for each new item.
 	for _, item := range list {
+		item, err := f.transform(item)
+		if err != nil {
+			return err
+		}
 		key, err := f.KeyOf(item)
 		if err != nil {
 			return KeyError{item, err}
@@ -541,7 +679,12 @@ func (f *DeltaFIFO) Replace(list []interface{}, resourceVersion string) error {
 			return fmt.Errorf("couldn't enqueue object: %v", err)
 		}
 	}
+	return nil
+}
 
+// queueReplaceDeletionsLocked queues a deletion for every pre-existing key
+// that is not in keys, and returns the number of deletions queued.
+func (f *DeltaFIFO) queueReplaceDeletionsLocked(keys sets.String) (int, error) {
 	if f.knownObjects == nil {
 		// Do deletion detection against our own list.
 		queuedDeletions := 0
@@ -558,18 +701,10 @@ func (f *DeltaFIFO) Replace(list []interface{}, resourceVersion string) error {
 			}
 			queuedDeletions++
 			if err := f.queueActionLocked(Deleted, DeletedFinalStateUnknown{k, deletedObj}); err != nil {
-				return err
+				return queuedDeletions, err
 			}
 		}
-
-		if !f.populated {
-			f.populated = true
-			// While there shouldn't be any queued deletions in the initial
-			// population of the queue, it's better to be on the safe side.
-			f.initialPopulationCount = len(list) + queuedDeletions
-		}
-
-		return nil
+		return queuedDeletions, nil
 	}
 
 	// Detect deletions not already in the queue.
@@ -590,16 +725,10 @@ func (f *DeltaFIFO) Replace(list []interface{}, resourceVersion string) error {
 		}
 		queuedDeletions++
 		if err := f.queueActionLocked(Deleted, DeletedFinalStateUnknown{k, deletedObj}); err != nil {
-			return err
+			return queuedDeletions, err
 		}
 	}
-
-	if !f.populated {
-		f.populated = true
-		f.initialPopulationCount = len(list) + queuedDeletions
-	}
-
-	return nil
+	return queuedDeletions, nil
 }
 
 // Resync adds, with a Sync type of Delta, every object listed by
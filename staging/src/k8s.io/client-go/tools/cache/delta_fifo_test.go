@@ -307,6 +307,52 @@ func TestDeltaFIFO_Resync(t *testing.T) {
 	}
 }
 
+func TestDeltaFIFO_Transformer(t *testing.T) {
+	transformed := map[string]int{}
+	f := NewDeltaFIFOWithOptions(DeltaFIFOOptions{
+		KeyFunction: testFifoObjectKeyFunc,
+		KnownObjects: literalListerGetter(func() []testFifoObject {
+			return []testFifoObject{mkFifoObj("baz", 1)}
+		}),
+		Transformer: func(obj interface{}) (interface{}, error) {
+			o := obj.(testFifoObject)
+			transformed[o.name]++
+			return mkFifoObj(o.name, o.val.(int)*10), nil
+		},
+	})
+
+	f.Add(mkFifoObj("foo", 1))
+	f.Update(mkFifoObj("foo", 2))
+	f.Replace([]interface{}{mkFifoObj("bar", 3)}, "0")
+	f.Resync()
+
+	if e, a := map[string]int{"foo": 2, "bar": 1}, transformed; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v transforms, got %v", e, a)
+	}
+	if e, a := 20, f.items["foo"].Newest().Object.(testFifoObject).val; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 30, f.items["bar"].Newest().Object.(testFifoObject).val; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if _, ok := f.items["baz"].Newest().Object.(DeletedFinalStateUnknown); !ok {
+		t.Errorf("expected tombstone for baz, got %#v", f.items["baz"])
+	}
+
+	failing := NewDeltaFIFOWithOptions(DeltaFIFOOptions{
+		KeyFunction: testFifoObjectKeyFunc,
+		Transformer: func(obj interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("transform failed")
+		},
+	})
+	if err := failing.Add(mkFifoObj("foo", 1)); err == nil {
+		t.Errorf("expected an error from a failing transform")
+	}
+	if len(failing.items) != 0 {
+		t.Errorf("expected nothing to be queued, got %v", failing.items)
+	}
+}
+
 func TestDeltaFIFO_DeleteExistingNonPropagated(t *testing.T) {
 	f := NewDeltaFIFO(
 		testFifoObjectKeyFunc,
@@ -405,6 +451,55 @@ func TestDeltaFIFO_ReplaceMakesDeletions(t *testing.T) {
 
 // TestDeltaFIFO_ReplaceMakesDeletionsReplaced is the same as the above test, but
 // ensures that a Replaced DeltaType is emitted.
+func TestDeltaFIFO_IncrementalReplace(t *testing.T) {
+	f := NewDeltaFIFOWithOptions(DeltaFIFOOptions{
+		KeyFunction: testFifoObjectKeyFunc,
+		KnownObjects: literalListerGetter(func() []testFifoObject {
+			return []testFifoObject{mkFifoObj("foo", 5), mkFifoObj("bar", 6), mkFifoObj("baz", 7)}
+		}),
+	})
+
+	if err := f.ReplacePage([]interface{}{mkFifoObj("foo", 5)}); err == nil {
+		t.Errorf("expected an error for ReplacePage without BeginReplace")
+	}
+
+	f.BeginReplace()
+	if err := f.ReplacePage([]interface{}{mkFifoObj("foo", 8)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Items of a page can be popped before the replace is finished.
+	if e, a := (Deltas{{Sync, mkFifoObj("foo", 8)}}), Pop(f).(Deltas); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %#v, got %#v", e, a)
+	}
+	if f.HasSynced() {
+		t.Errorf("expected HasSynced to be false before FinishReplace")
+	}
+	if err := f.ReplacePage([]interface{}{mkFifoObj("baz", 9)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.FinishReplace("0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedList := []Deltas{
+		{{Sync, mkFifoObj("baz", 9)}},
+		// "bar" was in none of the pages, so it should get a tombstone.
+		{{Deleted, DeletedFinalStateUnknown{Key: "bar", Obj: mkFifoObj("bar", 6)}}},
+	}
+	for _, expected := range expectedList {
+		if f.HasSynced() {
+			t.Errorf("expected HasSynced to be false with initial items queued")
+		}
+		cur := Pop(f).(Deltas)
+		if e, a := expected, cur; !reflect.DeepEqual(e, a) {
+			t.Errorf("expected %#v, got %#v", e, a)
+		}
+	}
+	if !f.HasSynced() {
+		t.Errorf("expected HasSynced to be true")
+	}
+}
+
 func TestDeltaFIFO_ReplaceMakesDeletionsReplaced(t *testing.T) {
 	f := NewDeltaFIFOWithOptions(DeltaFIFOOptions{
 		KeyFunction: testFifoObjectKeyFunc,
@@ -18,6 +18,7 @@ package cache
 
 import (
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -47,12 +48,27 @@ type Indexer interface {
 	// ByIndex returns the stored objects whose set of indexed values
 	// for the named index includes the given indexed value
 	ByIndex(indexName, indexedValue string) ([]interface{}, error)
+	// ByIndexPrefix returns the stored objects whose set of indexed
+	// values for the named index includes a value that starts with the
+	// given prefix. The index must have been added with AddOrderedIndexers
+	ByIndexPrefix(indexName, prefix string) ([]interface{}, error)
+	// ByIndexRange returns the stored objects whose set of indexed
+	// values for the named index includes a value in [from, to).
+	// Indexed values are compared as strings, and an empty to means
+	// there is no upper bound. The index must have been added with
+	// AddOrderedIndexers
+	ByIndexRange(indexName, from, to string) ([]interface{}, error)
 	// GetIndexer return the indexers
 	GetIndexers() Indexers
 
 	// AddIndexers adds more indexers to this store.  If you call this after you already have data
 	// in the store, the results are undefined.
 	AddIndexers(newIndexers Indexers) error
+	// AddOrderedIndexers is like AddIndexers, but also keeps the indexed
+	// values of the new indexes in order, so that they can be queried
+	// with ByIndexPrefix and ByIndexRange. This costs a little more on
+	// every change of the store than the exact-match indexes do.
+	AddOrderedIndexers(newIndexers Indexers) error
 }
 
 // IndexFunc knows how to compute the set of indexed values for an object.
@@ -80,6 +96,8 @@ func IndexFuncToKeyFuncAdapter(indexFunc IndexFunc) KeyFunc {
 const (
 	// NamespaceIndex is the lookup name for the most comment index function, which is to index by the namespace field.
 	NamespaceIndex string = "namespace"
+	// CreationTimestampIndex is the lookup name for the index function that indexes by the creationTimestamp field.
+	CreationTimestampIndex string = "creationTimestamp"
 )
 
 // MetaNamespaceIndexFunc is a default index function that indexes based on an object's namespace
@@ -91,6 +109,17 @@ func MetaNamespaceIndexFunc(obj interface{}) ([]string, error) {
 	return []string{meta.GetNamespace()}, nil
 }
 
+// MetaCreationTimestampIndexFunc is an index function that indexes based on an object's creationTimestamp.
+// The indexed values are UTC RFC3339 timestamps, which sort in time order, so the index can be queried with
+// ByIndexRange once added with AddOrderedIndexers, e.g. ByIndexRange(CreationTimestampIndex, "", t.UTC().Format(time.RFC3339)) for objects created before t.
+func MetaCreationTimestampIndexFunc(obj interface{}) ([]string, error) {
+	meta, err := meta.Accessor(obj)
+	if err != nil {
+		return []string{""}, fmt.Errorf("object has no meta: %v", err)
+	}
+	return []string{meta.GetCreationTimestamp().UTC().Format(time.RFC3339)}, nil
+}
+
 // Index maps the indexed value to a set of keys in the store that match on that value
 type Index map[string]sets.String
 
@@ -20,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"strings"
 	"testing"
+	"time"
 
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -132,3 +133,27 @@ func TestMultiIndexKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestCreationTimestampIndexRange(t *testing.T) {
+	index := NewIndexer(MetaNamespaceKeyFunc, Indexers{})
+	if err := index.AddOrderedIndexers(Indexers{CreationTimestampIndex: MetaCreationTimestampIndexFunc}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	index.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "old", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))}})
+	index.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "older", CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour))}})
+	index.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "new", CreationTimestamp: metav1.NewTime(now.Add(time.Hour))}})
+
+	items, err := index.ByIndexRange(CreationTimestampIndex, "", now.Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := sets.NewString()
+	for _, item := range items {
+		names.Insert(item.(*v1.Pod).Name)
+	}
+	if expected := sets.NewString("old", "older"); !names.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected.List(), names.List())
+	}
+}
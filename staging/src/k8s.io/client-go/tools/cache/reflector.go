@@ -95,8 +95,25 @@ type Reflector struct {
 	// etcd, which is significantly less efficient and may lead to serious performance and
 	// scalability problems.
 	WatchListPageSize int64
+	// IncrementalList, if true and the store is an IncrementalReplacer, makes
+	// every page of a paginated list go to the store as soon as it arrives,
+	// instead of the whole list being collected and handed to Replace. This
+	// bounds the memory a list takes to about one page on top of the store,
+	// but the store sees the new items of every page before the stale items
+	// are deleted at the end of the list, and a list that fails midway
+	// leaves the pages it applied without the deletions. It is off by
+	// default.
+	IncrementalList bool
 	// Called whenever the ListAndWatch drops the connection with an error.
 	watchErrorHandler WatchErrorHandler
+	// SnapshotStore, if set, is consulted by the first ListAndWatch. If it holds a
+	// snapshot, the snapshot's items are synced into the store and the reflector
+	// starts watching from the snapshot's resource version instead of listing.
+	// Should that resource version be too old (410 Gone), the reflector falls back
+	// to a regular LIST like it does for any expired watch.
+	SnapshotStore SnapshotStore
+	// snapshotConsulted is true once SnapshotStore has been consulted.
+	snapshotConsulted bool
 }
 
 // The WatchErrorHandler is called whenever ListAndWatch drops the
@@ -245,12 +262,16 @@ func (r *Reflector) ListAndWatch(stopCh <-chan struct{}) error {
 
 	options := metav1.ListOptions{ResourceVersion: r.relistResourceVersion()}
 
-	if err := func() error {
+	if r.restoreSnapshot(&resourceVersion) {
+		klog.V(2).Infof("%s: restored %v from snapshot, watching from resource version %q", r.name, r.expectedTypeName, resourceVersion)
+	} else if err := func() error {
 		initTrace := trace.New("Reflector ListAndWatch", trace.Field{"name", r.name})
 		defer initTrace.LogIfLong(10 * time.Second)
 		var list runtime.Object
 		var paginatedResult bool
 		var err error
+		replacer, incremental := r.store.(IncrementalReplacer)
+		incremental = incremental && r.IncrementalList
 		listCh := make(chan struct{}, 1)
 		panicCh := make(chan interface{}, 1)
 		go func() {
@@ -287,7 +308,13 @@ func (r *Reflector) ListAndWatch(stopCh <-chan struct{}) error {
 				pager.PageSize = 0
 			}
 
-			list, paginatedResult, err = pager.List(context.Background(), options)
+			listFunc := pager.List
+			if incremental {
+				listFunc = func(_ context.Context, options metav1.ListOptions) (runtime.Object, bool, error) {
+					return r.listIncrementally(replacer, pager.PageSize, options, stopCh)
+				}
+			}
+			list, paginatedResult, err = listFunc(context.Background(), options)
 			if isExpiredError(err) || isTooLargeResourceVersionError(err) {
 				r.setIsLastSyncResourceVersionUnavailable(true)
 				// Retry immediately if the resource version used to list is unavailable.
@@ -296,7 +323,7 @@ func (r *Reflector) ListAndWatch(stopCh <-chan struct{}) error {
 				// resource version it is listing at is expired or the cache may not yet be synced to the provided
 				// resource version. So we need to fallback to resourceVersion="" in all to recover and ensure
 				// the reflector makes forward progress.
-				list, paginatedResult, err = pager.List(context.Background(), metav1.ListOptions{ResourceVersion: r.relistResourceVersion()})
+				list, paginatedResult, err = listFunc(context.Background(), metav1.ListOptions{ResourceVersion: r.relistResourceVersion()})
 			}
 			close(listCh)
 		}()
@@ -333,6 +360,16 @@ func (r *Reflector) ListAndWatch(stopCh <-chan struct{}) error {
 		}
 		resourceVersion = listMetaInterface.GetResourceVersion()
 		initTrace.Step("Resource version extracted")
+		if incremental {
+			// The items already went to the store page by page.
+			if err := replacer.FinishReplace(resourceVersion); err != nil {
+				return fmt.Errorf("unable to sync list result: %v", err)
+			}
+			initTrace.Step("FinishReplace done")
+			r.setLastSyncResourceVersion(resourceVersion)
+			initTrace.Step("Resource version updated")
+			return nil
+		}
 		items, err := meta.ExtractList(list)
 		if err != nil {
 			return fmt.Errorf("unable to understand list result %#v (%v)", list, err)
@@ -429,6 +466,89 @@ func (r *Reflector) ListAndWatch(stopCh <-chan struct{}) error {
 	}
 }
 
+// restoreSnapshot syncs the store with the snapshot in r.SnapshotStore, if any,
+// and sets *resourceVersion to the snapshot's resource version. It only does so
+// on its first invocation and returns whether the store has been restored.
+func (r *Reflector) restoreSnapshot(resourceVersion *string) bool {
+	if r.SnapshotStore == nil || r.snapshotConsulted {
+		return false
+	}
+	r.snapshotConsulted = true
+
+	snapshot, err := r.SnapshotStore.Load()
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("%s: unable to load snapshot of %v, falling back to list: %v", r.name, r.expectedTypeName, err))
+		return false
+	}
+	if snapshot == nil || snapshot.ResourceVersion == "" {
+		return false
+	}
+	if err := r.syncWith(snapshot.Items, snapshot.ResourceVersion); err != nil {
+		utilruntime.HandleError(fmt.Errorf("%s: unable to sync snapshot of %v, falling back to list: %v", r.name, r.expectedTypeName, err))
+		return false
+	}
+	*resourceVersion = snapshot.ResourceVersion
+	r.setLastSyncResourceVersion(snapshot.ResourceVersion)
+	return true
+}
+
+// listIncrementally lists like ListPager.List, but hands the items of every
+// page to replacer as soon as the page arrives. The returned list only carries
+// the list metadata, its items are left out. Unlike ListPager.List it does not
+// fall back to a full list if a continuation expires, but returns the error,
+// so that ListAndWatch starts over with a consistent list.
+func (r *Reflector) listIncrementally(replacer IncrementalReplacer, pageSize int64, options metav1.ListOptions, stopCh <-chan struct{}) (runtime.Object, bool, error) {
+	if options.Limit == 0 {
+		options.Limit = pageSize
+	}
+	var list *metav1.List
+	paginatedResult := false
+
+	replacer.BeginReplace()
+	for {
+		select {
+		case <-stopCh:
+			return nil, paginatedResult, errorStopRequested
+		default:
+		}
+
+		obj, err := r.listerWatcher.List(options)
+		if err != nil {
+			return nil, paginatedResult, err
+		}
+		m, err := meta.ListAccessor(obj)
+		if err != nil {
+			return nil, paginatedResult, fmt.Errorf("unable to understand list result %#v: %v", obj, err)
+		}
+		if list == nil {
+			list = &metav1.List{ListMeta: metav1.ListMeta{
+				ResourceVersion: m.GetResourceVersion(),
+				SelfLink:        m.GetSelfLink(),
+			}}
+		}
+		items, err := meta.ExtractList(obj)
+		if err != nil {
+			return nil, paginatedResult, fmt.Errorf("unable to understand list result %#v (%v)", obj, err)
+		}
+		page := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			page = append(page, item)
+		}
+		if err := replacer.ReplacePage(page); err != nil {
+			return nil, paginatedResult, fmt.Errorf("unable to sync list result: %v", err)
+		}
+
+		if len(m.GetContinue()) == 0 {
+			return list, paginatedResult, nil
+		}
+
+		options.Continue = m.GetContinue()
+		// Continuations must not specify a resource version.
+		options.ResourceVersion = ""
+		paginatedResult = true
+	}
+}
+
 // syncWith replaces the store's items with the given list.
 func (r *Reflector) syncWith(items []runtime.Object, resourceVersion string) error {
 	found := make([]interface{}, 0, len(items))
@@ -437,6 +437,60 @@ func TestReflectorWatchListPageSize(t *testing.T) {
 	}
 }
 
+func TestReflectorIncrementalList(t *testing.T) {
+	stopCh := make(chan struct{})
+	s := NewStore(MetaNamespaceKeyFunc)
+	s.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "stale", ResourceVersion: "1"}})
+
+	pods := make([]v1.Pod, 10)
+	for i := 0; i < 10; i++ {
+		pods[i] = v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d", i), ResourceVersion: fmt.Sprintf("%d", i)}}
+	}
+	lw := &testLW{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			// Stop once the reflector begins watching since we're only interested in the list.
+			close(stopCh)
+			return watch.NewFake(), nil
+		},
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			if options.Continue != "" {
+				// Every earlier page must already be in the store.
+				if _, exists, _ := s.GetByKey("pod-0"); !exists {
+					t.Errorf("expected the first page to be stored before listing %q", options.Continue)
+				}
+			}
+			switch options.Continue {
+			case "":
+				return &v1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "10", Continue: "C1"}, Items: pods[0:4]}, nil
+			case "C1":
+				return &v1.PodList{ListMeta: metav1.ListMeta{Continue: "C2"}, Items: pods[4:8]}, nil
+			case "C2":
+				return &v1.PodList{ListMeta: metav1.ListMeta{}, Items: pods[8:10]}, nil
+			default:
+				t.Fatalf("Unrecognized continue: %s", options.Continue)
+			}
+			return nil, nil
+		},
+	}
+	r := NewReflector(lw, &v1.Pod{}, s, 0)
+	r.WatchListPageSize = 4
+	r.IncrementalList = true
+	if err := r.ListAndWatch(stopCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results := s.ListKeys()
+	if len(results) != 10 {
+		t.Errorf("Expected 10 results, got %v", results)
+	}
+	if _, exists, _ := s.GetByKey("stale"); exists {
+		t.Errorf("expected the stale pod to be deleted")
+	}
+	if e, a := "10", r.LastSyncResourceVersion(); e != a {
+		t.Errorf("expected resource version %q, got %q", e, a)
+	}
+}
+
 func TestReflectorNotPaginatingNotConsistentReads(t *testing.T) {
 	stopCh := make(chan struct{})
 	s := NewStore(MetaNamespaceKeyFunc)
@@ -134,7 +134,9 @@ type SharedInformer interface {
 	// AddEventHandler adds an event handler to the shared informer using the shared informer's resync
 	// period.  Events to a single handler are delivered sequentially, but there is no coordination
 	// between different handlers.
-	AddEventHandler(handler ResourceEventHandler)
+	// It returns a registration handle for the handler that can be used to remove
+	// the handler again or to tell whether the handler has synced.
+	AddEventHandler(handler ResourceEventHandler) (ResourceEventHandlerRegistration, error)
 	// AddEventHandlerWithResyncPeriod adds an event handler to the
 	// shared informer with the requested resync period; zero means
 	// this handler does not care about resyncs.  The resync operation
@@ -149,7 +151,16 @@ type SharedInformer interface {
 	// between any two resyncs may be longer than the nominal period
 	// because the implementation takes time to do work and there may
 	// be competing load and scheduling noise.
-	AddEventHandlerWithResyncPeriod(handler ResourceEventHandler, resyncPeriod time.Duration)
+	// It returns a registration handle for the handler that can be used to remove
+	// the handler again or to tell whether the handler has synced.
+	AddEventHandlerWithResyncPeriod(handler ResourceEventHandler, resyncPeriod time.Duration) (ResourceEventHandlerRegistration, error)
+	// RemoveEventHandler removes a previously added event handler given by
+	// its registration handle.  Once it returns, the handler's goroutines
+	// have exited and the handler will not be invoked again; notifications
+	// that were still pending for it are dropped.  Removing a handler that
+	// was already removed is a no-op.  RemoveEventHandler must not be
+	// called from within the handler that is being removed.
+	RemoveEventHandler(handle ResourceEventHandlerRegistration) error
 	// GetStore returns the informer's local cache as a Store.
 	GetStore() Store
 	// GetController is deprecated, it does nothing useful
@@ -182,12 +193,52 @@ type SharedInformer interface {
 	SetWatchErrorHandler(handler WatchErrorHandler) error
 }
 
+// ResourceEventHandlerRegistration is the handle returned when an event
+// handler is added to a SharedInformer.  It is opaque apart from
+// HasSynced and can be passed to RemoveEventHandler.
+type ResourceEventHandlerRegistration interface {
+	// HasSynced reports whether the handler has been delivered every
+	// notification that is part of the informer's initial list (or, for a
+	// handler added after the informer started, the synthetic adds for the
+	// objects already in the cache) and has finished handling them.
+	HasSynced() bool
+}
+
 // SharedIndexInformer provides add and get Indexers ability based on SharedInformer.
 type SharedIndexInformer interface {
 	SharedInformer
 	// AddIndexers add indexers to the informer before it starts.
 	AddIndexers(indexers Indexers) error
+	// AddOrderedIndexers adds indexers that can be queried with
+	// ByIndexPrefix and ByIndexRange to the informer before it starts.
+	AddOrderedIndexers(indexers Indexers) error
 	GetIndexer() Indexer
+	// SetTransform sets a function that is run on every object coming
+	// from the ListerWatcher before it is stored in the indexer and
+	// handed to the event handlers.  The transform may mutate and return
+	// its argument; it must keep the object's key unchanged.
+	//
+	// There's only one transform, so if you call this multiple times,
+	// last one wins; calling after the informer has been started returns
+	// an error.
+	SetTransform(handler TransformFunc) error
+	// SetSnapshotStore makes the informer save a snapshot of its indexer,
+	// together with the matching resource version, to store every period.
+	// When the informer starts, it restores its indexer from the stored
+	// snapshot, if any, and resumes watching from there instead of doing
+	// a full LIST; it falls back to a LIST if the snapshot is too old.
+	// Calling this after the informer has been started returns an error.
+	SetSnapshotStore(store SnapshotStore, period time.Duration) error
+	// SetIncrementalList makes the informer apply the pages of paginated
+	// lists to its indexer and hand them to the event handlers as they
+	// arrive, instead of once the whole list has arrived. This bounds the
+	// memory a relist takes, at the cost of the handlers and the indexer
+	// seeing the new objects before the deletions of the objects missing
+	// from the list, which are only applied once the list completes; a
+	// list that fails midway is not followed by those deletions until the
+	// next list succeeds. Calling this after the informer has been started
+	// returns an error.
+	SetIncrementalList(incremental bool) error
 }
 
 // NewSharedInformer creates a new instance for the listwatcher.
@@ -318,6 +369,17 @@ type sharedIndexInformer struct {
 
 	// Called whenever the ListAndWatch drops the connection with an error.
 	watchErrorHandler WatchErrorHandler
+
+	// Called on every object before it reaches the indexer and handlers.
+	transform TransformFunc
+
+	// snapshotStore, if set, is where the indexer is saved to every
+	// snapshotPeriod and restored from on start.
+	snapshotStore  SnapshotStore
+	snapshotPeriod time.Duration
+
+	// incrementalList makes the reflector apply paginated lists page by page.
+	incrementalList bool
 }
 
 // dummyController hides the fact that a SharedInformer is different from a dedicated one
@@ -347,6 +409,9 @@ type updateNotification struct {
 
 type addNotification struct {
 	newObj interface{}
+	// isInInitialList is true when the object is part of the informer's
+	// initial list, or of the synthetic adds sent to a late handler.
+	isInInitialList bool
 }
 
 type deleteNotification struct {
@@ -365,12 +430,50 @@ func (s *sharedIndexInformer) SetWatchErrorHandler(handler WatchErrorHandler) er
 	return nil
 }
 
+func (s *sharedIndexInformer) SetTransform(handler TransformFunc) error {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+
+	s.transform = handler
+	return nil
+}
+
+func (s *sharedIndexInformer) SetSnapshotStore(store SnapshotStore, period time.Duration) error {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+
+	s.snapshotStore = store
+	s.snapshotPeriod = period
+	return nil
+}
+
+func (s *sharedIndexInformer) SetIncrementalList(incremental bool) error {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+
+	s.incrementalList = incremental
+	return nil
+}
+
 func (s *sharedIndexInformer) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
 
 	fifo := NewDeltaFIFOWithOptions(DeltaFIFOOptions{
 		KnownObjects:          s.indexer,
 		EmitDeltaTypeReplaced: true,
+		Transformer:           s.transform,
 	})
 
 	cfg := &Config{
@@ -381,8 +484,13 @@ func (s *sharedIndexInformer) Run(stopCh <-chan struct{}) {
 		RetryOnError:     false,
 		ShouldResync:     s.processor.shouldResync,
 
-		Process:           s.HandleDeltas,
+		Process: func(obj interface{}) error {
+			// Process is invoked with the fifo's lock held, so this read is safe.
+			return s.handleDeltas(obj, fifo.poppingInitialItem)
+		},
 		WatchErrorHandler: s.watchErrorHandler,
+		SnapshotStore:     s.snapshotStore,
+		IncrementalList:   s.incrementalList,
 	}
 
 	func() {
@@ -401,6 +509,11 @@ func (s *sharedIndexInformer) Run(stopCh <-chan struct{}) {
 	defer close(processorStopCh) // Tell Processor to stop
 	wg.StartWithChannel(processorStopCh, s.cacheMutationDetector.Run)
 	wg.StartWithChannel(processorStopCh, s.processor.run)
+	if s.snapshotStore != nil && s.snapshotPeriod > 0 {
+		wg.StartWithChannel(processorStopCh, func(stopCh <-chan struct{}) {
+			s.runSnapshotter(fifo, stopCh)
+		})
+	}
 
 	defer func() {
 		s.startedLock.Lock()
@@ -410,6 +523,46 @@ func (s *sharedIndexInformer) Run(stopCh <-chan struct{}) {
 	s.controller.Run(stopCh)
 }
 
+// runSnapshotter saves a snapshot every snapshotPeriod until stopCh is closed,
+// and then makes a last attempt so that a restart resumes from recent state.
+func (s *sharedIndexInformer) runSnapshotter(fifo *DeltaFIFO, stopCh <-chan struct{}) {
+	wait.Until(func() {
+		s.saveSnapshot(fifo)
+	}, s.snapshotPeriod, stopCh)
+	s.saveSnapshot(fifo)
+}
+
+// saveSnapshot saves the indexer to s.snapshotStore. The indexer lags behind
+// the reflector's resource version while the fifo holds unprocessed deltas,
+// so a snapshot is only taken while the fifo is drained.
+func (s *sharedIndexInformer) saveSnapshot(fifo *DeltaFIFO) {
+	var snapshot *Snapshot
+	var err error
+	drained := fifo.ifDrained(func() {
+		// Every event the reflector observed up to its resource version has
+		// been popped and applied to the indexer.
+		snapshot = &Snapshot{ResourceVersion: s.controller.LastSyncResourceVersion()}
+		for _, item := range s.indexer.List() {
+			obj, ok := item.(runtime.Object)
+			if !ok {
+				err = fmt.Errorf("cannot snapshot %T, it is not a runtime.Object", item)
+				return
+			}
+			snapshot.Items = append(snapshot.Items, obj)
+		}
+	})
+	if !drained || snapshot.ResourceVersion == "" {
+		klog.V(4).Infof("Skipping snapshot of %T, the informer is busy or not synced", s.objectType)
+		return
+	}
+	if err == nil {
+		err = s.snapshotStore.Save(snapshot)
+	}
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("unable to save snapshot of %T: %v", s.objectType, err))
+	}
+}
+
 func (s *sharedIndexInformer) HasSynced() bool {
 	s.startedLock.Lock()
 	defer s.startedLock.Unlock()
@@ -449,12 +602,23 @@ func (s *sharedIndexInformer) AddIndexers(indexers Indexers) error {
 	return s.indexer.AddIndexers(indexers)
 }
 
+func (s *sharedIndexInformer) AddOrderedIndexers(indexers Indexers) error {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+
+	return s.indexer.AddOrderedIndexers(indexers)
+}
+
 func (s *sharedIndexInformer) GetController() Controller {
 	return &dummyController{informer: s}
 }
 
-func (s *sharedIndexInformer) AddEventHandler(handler ResourceEventHandler) {
-	s.AddEventHandlerWithResyncPeriod(handler, s.defaultEventHandlerResyncPeriod)
+func (s *sharedIndexInformer) AddEventHandler(handler ResourceEventHandler) (ResourceEventHandlerRegistration, error) {
+	return s.AddEventHandlerWithResyncPeriod(handler, s.defaultEventHandlerResyncPeriod)
 }
 
 func determineResyncPeriod(desired, check time.Duration) time.Duration {
@@ -474,13 +638,12 @@ func determineResyncPeriod(desired, check time.Duration) time.Duration {
 
 const minimumResyncPeriod = 1 * time.Second
 
-func (s *sharedIndexInformer) AddEventHandlerWithResyncPeriod(handler ResourceEventHandler, resyncPeriod time.Duration) {
+func (s *sharedIndexInformer) AddEventHandlerWithResyncPeriod(handler ResourceEventHandler, resyncPeriod time.Duration) (ResourceEventHandlerRegistration, error) {
 	s.startedLock.Lock()
 	defer s.startedLock.Unlock()
 
 	if s.stopped {
-		klog.V(2).Infof("Handler %v was not added to shared informer because it has stopped already", handler)
-		return
+		return nil, fmt.Errorf("handler %v was not added to shared informer because it has stopped already", handler)
 	}
 
 	if resyncPeriod > 0 {
@@ -504,10 +667,11 @@ func (s *sharedIndexInformer) AddEventHandlerWithResyncPeriod(handler ResourceEv
 	}
 
 	listener := newProcessListener(handler, resyncPeriod, determineResyncPeriod(resyncPeriod, s.resyncCheckPeriod), s.clock.Now(), initialBufferSize)
+	listener.upstreamHasSynced = s.HasSynced
 
 	if !s.started {
 		s.processor.addListener(listener)
-		return
+		return listener, nil
 	}
 
 	// in order to safely join, we have to
@@ -520,11 +684,46 @@ func (s *sharedIndexInformer) AddEventHandlerWithResyncPeriod(handler ResourceEv
 
 	s.processor.addListener(listener)
 	for _, item := range s.indexer.List() {
-		listener.add(addNotification{newObj: item})
+		listener.add(addNotification{newObj: item, isInInitialList: true})
 	}
+	return listener, nil
+}
+
+func (s *sharedIndexInformer) RemoveEventHandler(handle ResourceEventHandlerRegistration) error {
+	listener, ok := handle.(*processorListener)
+	if !ok {
+		return fmt.Errorf("invalid event handler registration %T", handle)
+	}
+
+	running := func() bool {
+		s.startedLock.Lock()
+		defer s.startedLock.Unlock()
+
+		// in order to safely leave, we have to
+		// 1. stop sending add/update/delete notifications
+		// 2. remove the listener and tell its goroutines to stop
+		// 3. unblock
+		s.blockDeltas.Lock()
+		defer s.blockDeltas.Unlock()
+
+		return s.processor.removeListener(listener)
+	}()
+
+	if running {
+		// Wait for .pop() and .run() to exit, outside of the locks so that
+		// a handler blocked on the informer cannot deadlock us.
+		<-listener.done
+	}
+	return nil
 }
 
 func (s *sharedIndexInformer) HandleDeltas(obj interface{}) error {
+	return s.handleDeltas(obj, false)
+}
+
+// handleDeltas is HandleDeltas with the knowledge of whether obj is part of
+// the fifo's initial list, which is used to track per-handler sync state.
+func (s *sharedIndexInformer) handleDeltas(obj interface{}, isInInitialList bool) error {
 	s.blockDeltas.Lock()
 	defer s.blockDeltas.Unlock()
 
@@ -557,7 +756,7 @@ func (s *sharedIndexInformer) HandleDeltas(obj interface{}) error {
 				if err := s.indexer.Add(d.Object); err != nil {
 					return err
 				}
-				s.processor.distribute(addNotification{newObj: d.Object}, false)
+				s.processor.distribute(addNotification{newObj: d.Object, isInInitialList: isInInitialList}, false)
 			}
 		case Deleted:
 			if err := s.indexer.Delete(d.Object); err != nil {
@@ -600,6 +799,36 @@ func (p *sharedProcessor) addListenerLocked(listener *processorListener) {
 	p.syncingListeners = append(p.syncingListeners, listener)
 }
 
+// removeListener forgets the given listener and, if its goroutines are
+// running, tells them to stop.  It returns true if the caller should wait
+// for the listener's goroutines to exit.
+func (p *sharedProcessor) removeListener(listener *processorListener) bool {
+	p.listenersLock.Lock()
+	defer p.listenersLock.Unlock()
+
+	var found bool
+	p.listeners, found = removeProcessorListener(p.listeners, listener)
+	if !found {
+		return false
+	}
+	p.syncingListeners, _ = removeProcessorListener(p.syncingListeners, listener)
+
+	if !p.listenersStarted {
+		return false
+	}
+	close(listener.addCh) // Tell .pop() to stop. .pop() will tell .run() to stop
+	return true
+}
+
+func removeProcessorListener(listeners []*processorListener, listener *processorListener) ([]*processorListener, bool) {
+	for i, l := range listeners {
+		if l == listener {
+			return append(listeners[:i:i], listeners[i+1:]...), true
+		}
+	}
+	return listeners, false
+}
+
 func (p *sharedProcessor) distribute(obj interface{}, sync bool) {
 	p.listenersLock.RLock()
 	defer p.listenersLock.RUnlock()
@@ -626,11 +855,16 @@ func (p *sharedProcessor) run(stopCh <-chan struct{}) {
 		p.listenersStarted = true
 	}()
 	<-stopCh
-	p.listenersLock.RLock()
-	defer p.listenersLock.RUnlock()
+	p.listenersLock.Lock()
+	defer p.listenersLock.Unlock()
 	for _, listener := range p.listeners {
 		close(listener.addCh) // Tell .pop() to stop. .pop() will tell .run() to stop
 	}
+	// The listeners are closed now, forget them so that they cannot be
+	// removed (and closed) a second time.
+	p.listeners = nil
+	p.syncingListeners = nil
+	p.listenersStarted = false
 	p.wg.Wait() // Wait for all .pop() and .run() to stop
 }
 
@@ -711,6 +945,18 @@ type processorListener struct {
 	nextResync time.Time
 	// resyncLock guards access to resyncPeriod and nextResync
 	resyncLock sync.Mutex
+
+	// upstreamHasSynced reports whether the informer has synced.  Nil
+	// means the informer is always considered synced.
+	upstreamHasSynced func() bool
+	// initialPending is the number of initial-list notifications that
+	// were added to this listener but not yet handled.
+	initialPending int
+	// syncLock guards access to initialPending
+	syncLock sync.Mutex
+
+	// done is closed when run() returns.
+	done chan struct{}
 }
 
 func newProcessListener(handler ResourceEventHandler, requestedResyncPeriod, resyncPeriod time.Duration, now time.Time, bufferSize int) *processorListener {
@@ -721,6 +967,7 @@ func newProcessListener(handler ResourceEventHandler, requestedResyncPeriod, res
 		pendingNotifications:  *buffer.NewRingGrowing(bufferSize),
 		requestedResyncPeriod: requestedResyncPeriod,
 		resyncPeriod:          resyncPeriod,
+		done:                  make(chan struct{}),
 	}
 
 	ret.determineNextResync(now)
@@ -729,9 +976,32 @@ func newProcessListener(handler ResourceEventHandler, requestedResyncPeriod, res
 }
 
 func (p *processorListener) add(notification interface{}) {
+	if n, ok := notification.(addNotification); ok && n.isInInitialList {
+		p.syncLock.Lock()
+		p.initialPending++
+		p.syncLock.Unlock()
+	}
 	p.addCh <- notification
 }
 
+// HasSynced implements ResourceEventHandlerRegistration.  The informer
+// must be checked first: once it reports synced, every initial-list
+// notification has already been counted in initialPending.
+func (p *processorListener) HasSynced() bool {
+	if p.upstreamHasSynced != nil && !p.upstreamHasSynced() {
+		return false
+	}
+	p.syncLock.Lock()
+	defer p.syncLock.Unlock()
+	return p.initialPending == 0
+}
+
+func (p *processorListener) initialHandled() {
+	p.syncLock.Lock()
+	defer p.syncLock.Unlock()
+	p.initialPending--
+}
+
 func (p *processorListener) pop() {
 	defer utilruntime.HandleCrash()
 	defer close(p.nextCh) // Tell .run() to stop
@@ -763,6 +1033,8 @@ func (p *processorListener) pop() {
 }
 
 func (p *processorListener) run() {
+	defer close(p.done)
+
 	// this call blocks until the channel is closed.  When a panic happens during the notification
 	// we will catch it, **the offending item will be skipped!**, and after a short delay (one second)
 	// the next notification will be attempted.  This is usually better than the alternative of never
@@ -775,6 +1047,9 @@ func (p *processorListener) run() {
 				p.handler.OnUpdate(notification.oldObj, notification.newObj)
 			case addNotification:
 				p.handler.OnAdd(notification.newObj)
+				if notification.isInInitialList {
+					p.initialHandled()
+				}
 			case deleteNotification:
 				p.handler.OnDelete(notification.oldObj)
 			default:
@@ -357,3 +357,199 @@ func TestSharedInformerErrorHandling(t *testing.T) {
 	}
 	close(stop)
 }
+
+func TestSharedInformerRemoveHandler(t *testing.T) {
+	source := fcache.NewFakeControllerSource()
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}})
+
+	informer := NewSharedInformer(source, &v1.Pod{}, 1*time.Second)
+
+	listener1 := newTestListener("listener1", 0, "pod1", "pod2")
+	handle1, err := informer.AddEventHandler(listener1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	listener2 := newTestListener("listener2", 0, "pod1")
+	handle2, err := informer.AddEventHandler(listener2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go informer.Run(stop)
+
+	if !WaitForCacheSync(stop, handle1.HasSynced, handle2.HasSynced) {
+		t.Fatalf("handlers did not sync")
+	}
+
+	if err := informer.RemoveEventHandler(handle2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// removing twice is a no-op
+	if err := informer.RemoveEventHandler(handle2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(informer.(*sharedIndexInformer).processor.listeners); n != 1 {
+		t.Errorf("expected 1 listener, got %d", n)
+	}
+
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2"}})
+
+	for _, listener := range []*testListener{listener1, listener2} {
+		if !listener.ok() {
+			t.Errorf("%s: expected %v, got %v", listener.name, listener.expectedItemNames, listener.receivedItemNames)
+		}
+	}
+}
+
+func TestSharedInformerHandlerHasSynced(t *testing.T) {
+	source := fcache.NewFakeControllerSource()
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}})
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2"}})
+
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+
+	release := make(chan struct{})
+	slow := ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			<-release
+		},
+	}
+	slowHandle, err := informer.AddEventHandler(slow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go informer.Run(stop)
+
+	if !WaitForCacheSync(stop, informer.HasSynced) {
+		t.Fatalf("informer did not sync")
+	}
+	if slowHandle.HasSynced() {
+		t.Errorf("expected blocked handler not to have synced")
+	}
+
+	// a handler added after the informer synced is synced once it has
+	// handled the synthetic adds for the cached objects
+	late := newTestListener("late", 0, "pod1", "pod2")
+	lateHandle, err := informer.AddEventHandler(late)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !WaitForCacheSync(stop, lateHandle.HasSynced) {
+		t.Fatalf("late handler did not sync")
+	}
+	if !late.satisfiedExpectations() {
+		t.Errorf("late: expected %v, got %v", late.expectedItemNames, late.receivedItemNames)
+	}
+
+	close(release)
+	if !WaitForCacheSync(stop, slowHandle.HasSynced) {
+		t.Fatalf("released handler did not sync")
+	}
+}
+
+func TestSharedInformerAddHandlerAfterStop(t *testing.T) {
+	source := fcache.NewFakeControllerSource()
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+	handle, err := informer.AddEventHandler(newTestListener("listener", 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		informer.Run(stop)
+		close(done)
+	}()
+	close(stop)
+	<-done
+
+	if _, err := informer.AddEventHandler(newTestListener("listener", 0)); err == nil {
+		t.Errorf("expected an error adding a handler to a stopped informer")
+	}
+	if err := informer.RemoveEventHandler(handle); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSharedInformerTransform(t *testing.T) {
+	source := fcache.NewFakeControllerSource()
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Annotations: map[string]string{"a": "b"}}})
+
+	informer := NewSharedInformer(source, &v1.Pod{}, 0).(*sharedIndexInformer)
+	err := informer.SetTransform(func(obj interface{}) (interface{}, error) {
+		if pod, ok := obj.(*v1.Pod); ok {
+			pod.Annotations = nil
+			pod.ManagedFields = nil
+		}
+		return obj, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	added := make(chan *v1.Pod, 2)
+	informer.AddEventHandler(ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			added <- obj.(*v1.Pod)
+		},
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go informer.Run(stop)
+
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2", Annotations: map[string]string{"c": "d"}}})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case pod := <-added:
+			if pod.Annotations != nil {
+				t.Errorf("%s: expected annotations to be stripped, got %v", pod.Name, pod.Annotations)
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out waiting for add notifications")
+		}
+	}
+	for _, obj := range informer.GetStore().List() {
+		if pod := obj.(*v1.Pod); pod.Annotations != nil {
+			t.Errorf("%s: expected cached annotations to be stripped, got %v", pod.Name, pod.Annotations)
+		}
+	}
+
+	if err := informer.SetTransform(nil); err == nil {
+		t.Errorf("expected an error setting a transform on a started informer")
+	}
+}
+
+func TestSharedInformerIncrementalList(t *testing.T) {
+	for _, incremental := range []bool{false, true} {
+		source := fcache.NewFakeControllerSource()
+		source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}})
+
+		informer := NewSharedInformer(source, &v1.Pod{}, 0).(*sharedIndexInformer)
+		if incremental {
+			if err := informer.SetIncrementalList(true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		stop := make(chan struct{})
+		go informer.Run(stop)
+		if !WaitForCacheSync(stop, informer.HasSynced) {
+			t.Fatalf("informer did not sync")
+		}
+		if got := informer.controller.(*controller).config.IncrementalList; got != incremental {
+			t.Errorf("expected IncrementalList %v, got %v", incremental, got)
+		}
+		if err := informer.SetIncrementalList(!incremental); err == nil {
+			t.Errorf("expected an error changing IncrementalList on a started informer")
+		}
+		close(stop)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// This file implements a skiplist data structure.
+
+package cache
+
+import (
+	"math/rand"
+)
+
+const (
+	// skipListMaxLevel bounds the height of a skipList node. With a
+	// promotion probability of 1/4 this comfortably covers 4^16 values.
+	skipListMaxLevel = 16
+)
+
+type skipListNode struct {
+	value string
+	// next holds the successor of this node on each level it takes part in.
+	next []*skipListNode
+}
+
+// skipList is an ordered set of distinct strings. It is not thread safe; the
+// threadSafeMap uses it under its own lock to keep the indexed values of every
+// index in order, which makes prefix and range queries cheap.
+type skipList struct {
+	// head is a sentinel node that takes part in every level.
+	head skipListNode
+	// level is the number of levels currently in use.
+	level int
+	// length is the number of values in the list.
+	length int
+}
+
+func newSkipList() *skipList {
+	return &skipList{
+		head:  skipListNode{next: make([]*skipListNode, skipListMaxLevel)},
+		level: 1,
+	}
+}
+
+func (s *skipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && rand.Int63()&3 == 0 {
+		level++
+	}
+	return level
+}
+
+// seek returns the first node whose value is not less than value. If update is
+// not nil it is filled with the rightmost node on each level that precedes it.
+func (s *skipList) seek(value string, update []*skipListNode) *skipListNode {
+	x := &s.head
+	for i := s.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].value < value {
+			x = x.next[i]
+		}
+		if update != nil {
+			update[i] = x
+		}
+	}
+	return x.next[0]
+}
+
+// insert adds value to the list, if it is not already present.
+func (s *skipList) insert(value string) {
+	var update [skipListMaxLevel]*skipListNode
+	if n := s.seek(value, update[:]); n != nil && n.value == value {
+		return
+	}
+
+	level := s.randomLevel()
+	if level > s.level {
+		for i := s.level; i < level; i++ {
+			update[i] = &s.head
+		}
+		s.level = level
+	}
+
+	node := &skipListNode{value: value, next: make([]*skipListNode, level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	s.length++
+}
+
+// delete removes value from the list, if it is present.
+func (s *skipList) delete(value string) {
+	var update [skipListMaxLevel]*skipListNode
+	n := s.seek(value, update[:])
+	if n == nil || n.value != value {
+		return
+	}
+
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+	s.length--
+}
+
+// ascend calls fn, in order, for every value that is not less than from,
+// until fn returns false.
+func (s *skipList) ascend(from string, fn func(value string) bool) {
+	for n := s.seek(from, nil); n != nil; n = n.next[0] {
+		if !fn(n.value) {
+			return
+		}
+	}
+}
+
+func (s *skipList) len() int {
+	return s.length
+}
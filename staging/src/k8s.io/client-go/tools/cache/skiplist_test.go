@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func skipListValues(s *skipList, from string) []string {
+	values := []string{}
+	s.ascend(from, func(value string) bool {
+		values = append(values, value)
+		return true
+	})
+	return values
+}
+
+func TestSkipListInsertDelete(t *testing.T) {
+	s := newSkipList()
+	expected := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		value := fmt.Sprintf("%04d", rand.Intn(500))
+		if rand.Intn(3) == 0 {
+			s.delete(value)
+			delete(expected, value)
+		} else {
+			s.insert(value)
+			expected[value] = true
+		}
+	}
+
+	want := []string{}
+	for value := range expected {
+		want = append(want, value)
+	}
+	sort.Strings(want)
+
+	if got := skipListValues(s, ""); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if e, a := len(want), s.len(); e != a {
+		t.Errorf("expected length %d, got %d", e, a)
+	}
+}
+
+func TestSkipListAscend(t *testing.T) {
+	s := newSkipList()
+	for _, value := range []string{"rack-b", "rack-a1", "rack-a2", "rack-c", "node"} {
+		s.insert(value)
+	}
+	// duplicates are ignored
+	s.insert("rack-a1")
+
+	if e, a := []string{"rack-a1", "rack-a2", "rack-b", "rack-c"}, skipListValues(s, "rack-a"); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := []string{}, skipListValues(s, "z"); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	var visited []string
+	s.ascend("", func(value string) bool {
+		visited = append(visited, value)
+		return len(visited) < 2
+	})
+	if e, a := []string{"node", "rack-a1"}, visited; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Snapshot is a point in time copy of the objects in a cache, together
+// with the resource version the objects are at least as fresh as.
+type Snapshot struct {
+	// ResourceVersion is the resource version a Reflector can resume
+	// watching from after restoring Items.
+	ResourceVersion string
+	// Items are the objects in the cache.
+	Items []runtime.Object
+}
+
+// SnapshotStore persists Snapshots, so that a restarted Reflector can
+// resume watching instead of doing a full LIST.
+type SnapshotStore interface {
+	// Save replaces the stored snapshot with the given one.
+	Save(snapshot *Snapshot) error
+	// Load returns the stored snapshot, or nil if there is none.
+	Load() (*Snapshot, error)
+}
+
+// fileSnapshotStore keeps a single Snapshot in a local file.  The file
+// holds a sequence of length-prefixed records: first the resource version
+// and then every item, each encoded with codec.
+type fileSnapshotStore struct {
+	path  string
+	codec runtime.Codec
+}
+
+var _ SnapshotStore = &fileSnapshotStore{}
+
+// NewFileSnapshotStore returns a SnapshotStore that keeps its snapshot in
+// the file at path.  Items are encoded and decoded with codec, e.g. a JSON
+// or protobuf codec obtained from CodecForVersions of a CodecFactory that
+// knows the cached types, so that items decode to the cached versions.
+// Writes go to a temporary file that is renamed over path, so a crash never
+// leaves a partially written snapshot behind.
+func NewFileSnapshotStore(path string, codec runtime.Codec) SnapshotStore {
+	return &fileSnapshotStore{
+		path:  path,
+		codec: codec,
+	}
+}
+
+// Save writes snapshot to the store's file.
+func (s *fileSnapshotStore) Save(snapshot *Snapshot) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := s.write(tmp, snapshot); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *fileSnapshotStore) write(w io.Writer, snapshot *Snapshot) error {
+	bw := bufio.NewWriter(w)
+	if err := writeSnapshotRecord(bw, []byte(snapshot.ResourceVersion)); err != nil {
+		return err
+	}
+	buf := &bytes.Buffer{}
+	for _, item := range snapshot.Items {
+		buf.Reset()
+		if err := s.codec.Encode(item, buf); err != nil {
+			return fmt.Errorf("unable to encode %T: %v", item, err)
+		}
+		if err := writeSnapshotRecord(bw, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Load reads the snapshot from the store's file. A missing file is not an
+// error, it just means there is no snapshot yet.
+func (s *fileSnapshotStore) Load() (*Snapshot, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	resourceVersion, err := readSnapshotRecord(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read resource version from %s: %v", s.path, err)
+	}
+	snapshot := &Snapshot{ResourceVersion: string(resourceVersion)}
+	for {
+		data, err := readSnapshotRecord(r)
+		if err == io.EOF {
+			return snapshot, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read item from %s: %v", s.path, err)
+		}
+		item, _, err := s.codec.Decode(data, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode item from %s: %v", s.path, err)
+		}
+		snapshot.Items = append(snapshot.Items, item)
+	}
+}
+
+func writeSnapshotRecord(w io.Writer, data []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readSnapshotRecord returns io.EOF only if r ends cleanly between records.
+func readSnapshotRecord(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	data := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	fcache "k8s.io/client-go/tools/cache/testing"
+)
+
+func newTestSnapshotStore(t *testing.T) (SnapshotStore, func()) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	codecs := serializer.NewCodecFactory(scheme)
+	info, _ := runtime.SerializerInfoForMediaType(codecs.SupportedMediaTypes(), runtime.ContentTypeJSON)
+	codec := codecs.CodecForVersions(info.Serializer, info.Serializer, v1.SchemeGroupVersion, v1.SchemeGroupVersion)
+	return NewFileSnapshotStore(filepath.Join(dir, "pods"), codec), func() { os.RemoveAll(dir) }
+}
+
+func TestFileSnapshotStore(t *testing.T) {
+	store, cleanup := newTestSnapshotStore(t)
+	defer cleanup()
+
+	snapshot, err := store.Load()
+	if err != nil || snapshot != nil {
+		t.Fatalf("expected no snapshot and no error, got %v, %v", snapshot, err)
+	}
+
+	pods := []runtime.Object{
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns", ResourceVersion: "1"}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2", Namespace: "ns", ResourceVersion: "2"}},
+	}
+	if err := store.Save(&Snapshot{ResourceVersion: "2", Items: pods}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// a second save replaces the first one
+	if err := store.Save(&Snapshot{ResourceVersion: "3", Items: pods[1:]}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	snapshot, err = store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := "3", snapshot.ResourceVersion; e != a {
+		t.Errorf("expected resource version %q, got %q", e, a)
+	}
+	if len(snapshot.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(snapshot.Items))
+	}
+	pod := snapshot.Items[0].(*v1.Pod)
+	if pod.Name != "pod2" || pod.Namespace != "ns" || pod.ResourceVersion != "2" {
+		t.Errorf("unexpected pod %#v", pod)
+	}
+}
+
+type fakeSnapshotStore struct {
+	snapshot *Snapshot
+	saved    chan *Snapshot
+}
+
+// Save records snapshot unless saved is full, so that a snapshotter that
+// keeps saving never blocks.
+func (s *fakeSnapshotStore) Save(snapshot *Snapshot) error {
+	select {
+	case s.saved <- snapshot:
+	default:
+	}
+	return nil
+}
+
+func (s *fakeSnapshotStore) Load() (*Snapshot, error) {
+	return s.snapshot, nil
+}
+
+func TestReflectorRestoresSnapshot(t *testing.T) {
+	stopCh := make(chan struct{})
+	s := NewStore(MetaNamespaceKeyFunc)
+	var listCallRVs, watchCallRVs []string
+
+	lw := &testLW{
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			watchCallRVs = append(watchCallRVs, options.ResourceVersion)
+			if options.ResourceVersion == "5" {
+				return nil, apierrors.NewResourceExpired("too old resource version")
+			}
+			close(stopCh)
+			return watch.NewFake(), nil
+		},
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			listCallRVs = append(listCallRVs, options.ResourceVersion)
+			if options.ResourceVersion == "5" {
+				return nil, apierrors.NewResourceExpired("too old resource version")
+			}
+			return &v1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "10"}, Items: []v1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Name: "pod2"}},
+			}}, nil
+		},
+	}
+	r := NewReflector(lw, &v1.Pod{}, s, 0)
+	r.SnapshotStore = &fakeSnapshotStore{snapshot: &Snapshot{
+		ResourceVersion: "5",
+		Items:           []runtime.Object{&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}}},
+	}}
+
+	// The snapshot is restored without a list, and the watch resumes from its
+	// resource version, which has expired.
+	if err := r.ListAndWatch(stopCh); err == nil {
+		t.Fatalf("expected the watch from the expired snapshot to fail")
+	}
+	if e, a := []string{"pod1"}, s.ListKeys(); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := "5", r.LastSyncResourceVersion(); e != a {
+		t.Errorf("expected resource version %q, got %q", e, a)
+	}
+
+	// The next attempt falls back to a list and replaces the restored items.
+	if err := r.ListAndWatch(stopCh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := []string{"pod2"}, s.ListKeys(); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := []string{"5", ""}, listCallRVs; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected list calls with resource versions %v, got %v", e, a)
+	}
+	if e, a := []string{"5", "10"}, watchCallRVs; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected watch calls with resource versions %v, got %v", e, a)
+	}
+}
+
+func TestSharedInformerSavesSnapshot(t *testing.T) {
+	source := fcache.NewFakeControllerSource()
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod1"}})
+	source.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod2"}})
+
+	store := &fakeSnapshotStore{saved: make(chan *Snapshot, 10)}
+	informer := NewSharedInformer(source, &v1.Pod{}, 0)
+	if err := informer.(SharedIndexInformer).SetSnapshotStore(store, 10*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	defer func() {
+		close(stop)
+		select {
+		case <-done:
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Errorf("timed out waiting for the informer to stop")
+		}
+	}()
+	go func() {
+		defer close(done)
+		informer.Run(stop)
+	}()
+
+	select {
+	case snapshot := <-store.saved:
+		if e, a := informer.LastSyncResourceVersion(), snapshot.ResourceVersion; e != a {
+			t.Errorf("expected resource version %q, got %q", e, a)
+		}
+		if e, a := 2, len(snapshot.Items); e != a {
+			t.Errorf("expected %d items, got %d", e, a)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("timed out waiting for a snapshot")
+	}
+}
@@ -19,8 +19,10 @@ package cache
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // Store is a generic object storage and processing interface.  A
@@ -70,6 +72,25 @@ type Store interface {
 	Resync() error
 }
 
+// IncrementalReplacer is implemented by Stores that can have their contents
+// replaced page by page, so that a Reflector does not have to hold a whole
+// paginated list in memory before handing it over.  A BeginReplace, any
+// number of ReplacePage calls and a FinishReplace together have the same
+// end result as a single Replace of the concatenated pages, but the objects
+// of each page become visible as soon as ReplacePage returns.
+type IncrementalReplacer interface {
+	// BeginReplace starts a new replace, abandoning any replace that was
+	// begun but not finished.
+	BeginReplace()
+
+	// ReplacePage adds or updates the objects of one page of the list.
+	ReplacePage([]interface{}) error
+
+	// FinishReplace deletes everything that was stored before the
+	// matching BeginReplace and was not in any of the pages since.
+	FinishReplace(string) error
+}
+
 // KeyFunc knows how to make a key from an object. Implementations should be deterministic.
 type KeyFunc func(obj interface{}) (string, error)
 
@@ -137,9 +158,15 @@ type cache struct {
 	// keyFunc is used to make the key for objects stored in and retrieved from items, and
 	// should be deterministic.
 	keyFunc KeyFunc
+
+	// replaceLock protects replaceKeys, the keys of the items stored by
+	// ReplacePage since the last BeginReplace.
+	replaceLock sync.Mutex
+	replaceKeys sets.String
 }
 
 var _ Store = &cache{}
+var _ IncrementalReplacer = &cache{}
 
 // Add inserts an item into the cache.
 func (c *cache) Add(obj interface{}) error {
@@ -207,10 +234,24 @@ func (c *cache) ByIndex(indexName, indexKey string) ([]interface{}, error) {
 	return c.cacheStorage.ByIndex(indexName, indexKey)
 }
 
+// ByIndexPrefix returns the list of objects that have an indexed value in the named index starting with prefix
+func (c *cache) ByIndexPrefix(indexName, prefix string) ([]interface{}, error) {
+	return c.cacheStorage.ByIndexPrefix(indexName, prefix)
+}
+
+// ByIndexRange returns the list of objects that have an indexed value in the named index in [from, to)
+func (c *cache) ByIndexRange(indexName, from, to string) ([]interface{}, error) {
+	return c.cacheStorage.ByIndexRange(indexName, from, to)
+}
+
 func (c *cache) AddIndexers(newIndexers Indexers) error {
 	return c.cacheStorage.AddIndexers(newIndexers)
 }
 
+func (c *cache) AddOrderedIndexers(newIndexers Indexers) error {
+	return c.cacheStorage.AddOrderedIndexers(newIndexers)
+}
+
 // Get returns the requested item, or sets exists=false.
 // Get is completely threadsafe as long as you treat all items as immutable.
 func (c *cache) Get(obj interface{}) (item interface{}, exists bool, err error) {
@@ -244,6 +285,50 @@ func (c *cache) Replace(list []interface{}, resourceVersion string) error {
 	return nil
 }
 
+// BeginReplace starts a replace of the contents of 'c' by the pages passed
+// to ReplacePage.
+func (c *cache) BeginReplace() {
+	c.replaceLock.Lock()
+	defer c.replaceLock.Unlock()
+	c.replaceKeys = sets.String{}
+}
+
+// ReplacePage stores the given page of the list that replaces the contents
+// of 'c'.
+func (c *cache) ReplacePage(list []interface{}) error {
+	c.replaceLock.Lock()
+	defer c.replaceLock.Unlock()
+	if c.replaceKeys == nil {
+		return fmt.Errorf("ReplacePage called without BeginReplace")
+	}
+	for _, item := range list {
+		key, err := c.keyFunc(item)
+		if err != nil {
+			return KeyError{item, err}
+		}
+		c.replaceKeys.Insert(key)
+		c.cacheStorage.Update(key, item)
+	}
+	return nil
+}
+
+// FinishReplace deletes every item of 'c' that was not in one of the pages
+// passed to ReplacePage since BeginReplace.
+func (c *cache) FinishReplace(resourceVersion string) error {
+	c.replaceLock.Lock()
+	defer c.replaceLock.Unlock()
+	if c.replaceKeys == nil {
+		return fmt.Errorf("FinishReplace called without BeginReplace")
+	}
+	for _, key := range c.cacheStorage.ListKeys() {
+		if !c.replaceKeys.Has(key) {
+			c.cacheStorage.Delete(key)
+		}
+	}
+	c.replaceKeys = nil
+	return nil
+}
+
 // Resync is meaningless for one of these
 func (c *cache) Resync() error {
 	return nil
@@ -18,6 +18,7 @@ package cache
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -50,11 +51,17 @@ type ThreadSafeStore interface {
 	IndexKeys(indexName, indexKey string) ([]string, error)
 	ListIndexFuncValues(name string) []string
 	ByIndex(indexName, indexKey string) ([]interface{}, error)
+	ByIndexPrefix(indexName, prefix string) ([]interface{}, error)
+	ByIndexRange(indexName, from, to string) ([]interface{}, error)
 	GetIndexers() Indexers
 
 	// AddIndexers adds more indexers to this store.  If you call this after you already have data
 	// in the store, the results are undefined.
 	AddIndexers(newIndexers Indexers) error
+	// AddOrderedIndexers adds more indexers whose indexed values are kept
+	// in order for ByIndexPrefix and ByIndexRange. The same restriction as
+	// for AddIndexers applies.
+	AddOrderedIndexers(newIndexers Indexers) error
 	// Resync is a no-op and is deprecated
 	Resync() error
 }
@@ -68,6 +75,9 @@ type threadSafeMap struct {
 	indexers Indexers
 	// indices maps a name to an Index
 	indices Indices
+	// orderedValues maps the name of every ordered Index to its indexed
+	// values, in order. Other indexes don't pay for keeping them.
+	orderedValues map[string]*skipList
 }
 
 func (c *threadSafeMap) Add(key string, obj interface{}) {
@@ -131,6 +141,9 @@ func (c *threadSafeMap) Replace(items map[string]interface{}, resourceVersion st
 
 	// rebuild any index
 	c.indices = Indices{}
+	for name := range c.orderedValues {
+		c.orderedValues[name] = newSkipList()
+	}
 	for key, item := range c.items {
 		c.updateIndices(nil, item, key)
 	}
@@ -197,6 +210,58 @@ func (c *threadSafeMap) ByIndex(indexName, indexedValue string) ([]interface{},
 	return list, nil
 }
 
+// ByIndexPrefix returns a list of the items that have an indexed value in the given index
+// that starts with the given prefix.
+func (c *threadSafeMap) ByIndexPrefix(indexName, prefix string) ([]interface{}, error) {
+	return c.byIndexRange(indexName, prefix, func(indexedValue string) bool {
+		return strings.HasPrefix(indexedValue, prefix)
+	})
+}
+
+// ByIndexRange returns a list of the items that have an indexed value in the given index
+// in the range [from, to). Indexed values compare as strings; an empty to means no upper bound.
+func (c *threadSafeMap) ByIndexRange(indexName, from, to string) ([]interface{}, error) {
+	return c.byIndexRange(indexName, from, func(indexedValue string) bool {
+		return to == "" || indexedValue < to
+	})
+}
+
+// byIndexRange returns the items that have an indexed value in the given index which is
+// not less than from, stopping at the first indexed value for which inRange returns false.
+func (c *threadSafeMap) byIndexRange(indexName, from string, inRange func(string) bool) ([]interface{}, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	indexFunc := c.indexers[indexName]
+	if indexFunc == nil {
+		return nil, fmt.Errorf("Index with name %s does not exist", indexName)
+	}
+
+	values := c.orderedValues[indexName]
+	if values == nil {
+		return nil, fmt.Errorf("Index with name %s is not ordered", indexName)
+	}
+	index := c.indices[indexName]
+
+	// An item may have several indexed values in the range, de-dupe them.
+	storeKeySet := sets.String{}
+	values.ascend(from, func(indexedValue string) bool {
+		if !inRange(indexedValue) {
+			return false
+		}
+		for key := range index[indexedValue] {
+			storeKeySet.Insert(key)
+		}
+		return true
+	})
+
+	list := make([]interface{}, 0, storeKeySet.Len())
+	for storeKey := range storeKeySet {
+		list = append(list, c.items[storeKey])
+	}
+	return list, nil
+}
+
 // IndexKeys returns a list of the Store keys of the objects whose indexed values in the given index include the given indexed value.
 // IndexKeys is thread-safe so long as you treat all items as immutable.
 func (c *threadSafeMap) IndexKeys(indexName, indexedValue string) ([]string, error) {
@@ -234,6 +299,24 @@ func (c *threadSafeMap) AddIndexers(newIndexers Indexers) error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	return c.addIndexersLocked(newIndexers)
+}
+
+func (c *threadSafeMap) AddOrderedIndexers(newIndexers Indexers) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.addIndexersLocked(newIndexers); err != nil {
+		return err
+	}
+	for name := range newIndexers {
+		c.orderedValues[name] = newSkipList()
+	}
+	return nil
+}
+
+// addIndexersLocked must be called from a function that already has a lock on the cache
+func (c *threadSafeMap) addIndexersLocked(newIndexers Indexers) error {
 	if len(c.items) > 0 {
 		return fmt.Errorf("cannot add indexers to running index")
 	}
@@ -268,12 +351,16 @@ func (c *threadSafeMap) updateIndices(oldObj interface{}, newObj interface{}, ke
 			index = Index{}
 			c.indices[name] = index
 		}
+		values := c.orderedValues[name]
 
 		for _, indexValue := range indexValues {
 			set := index[indexValue]
 			if set == nil {
 				set = sets.String{}
 				index[indexValue] = set
+				if values != nil {
+					values.insert(indexValue)
+				}
 			}
 			set.Insert(key)
 		}
@@ -303,6 +390,9 @@ func (c *threadSafeMap) deleteFromIndices(obj interface{}, key string) {
 				// unused empty sets. See `kubernetes/kubernetes/issues/84959`.
 				if len(set) == 0 {
 					delete(index, indexValue)
+					if values := c.orderedValues[name]; values != nil {
+						values.delete(indexValue)
+					}
 				}
 			}
 		}
@@ -317,8 +407,9 @@ func (c *threadSafeMap) Resync() error {
 // NewThreadSafeStore creates a new instance of ThreadSafeStore.
 func NewThreadSafeStore(indexers Indexers, indices Indices) ThreadSafeStore {
 	return &threadSafeMap{
-		items:    map[string]interface{}{},
-		indexers: indexers,
-		indices:  indices,
+		items:         map[string]interface{}{},
+		indexers:      indexers,
+		indices:       indices,
+		orderedValues: map[string]*skipList{},
 	}
 }
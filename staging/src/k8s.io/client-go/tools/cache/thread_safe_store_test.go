@@ -17,6 +17,9 @@ limitations under the License.
 package cache
 
 import (
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -90,3 +93,89 @@ func TestThreadSafeStoreAddKeepsNonEmptySetPostDeleteFromIndex(t *testing.T) {
 		t.Errorf("Index backing string set has incorrect length, expect 1. Set length: %d", len(set))
 	}
 }
+
+func TestThreadSafeStoreByIndexPrefixAndRange(t *testing.T) {
+	testIndexer := "testIndexer"
+
+	indexers := Indexers{
+		testIndexer: func(obj interface{}) ([]string, error) {
+			return strings.Split(obj.(string), ","), nil
+		},
+	}
+
+	store := NewThreadSafeStore(Indexers{}, Indices{})
+	if err := store.AddOrderedIndexers(indexers); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store.Add("a", "rack-a1")
+	store.Add("b", "rack-a2,rack-b1")
+	store.Add("c", "rack-b2")
+	store.Add("d", "rack-c1")
+
+	keys := func(items []interface{}, err error) []string {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		result := []string{}
+		for _, item := range items {
+			result = append(result, item.(string))
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		items    []string
+		expected []string
+	}{
+		{"prefix", keys(store.ByIndexPrefix(testIndexer, "rack-a")), []string{"rack-a1", "rack-a2,rack-b1"}},
+		{"prefix no match", keys(store.ByIndexPrefix(testIndexer, "rack-z")), []string{}},
+		{"range", keys(store.ByIndexRange(testIndexer, "rack-a2", "rack-c")), []string{"rack-a2,rack-b1", "rack-b2"}},
+		{"range unbounded", keys(store.ByIndexRange(testIndexer, "rack-b2", "")), []string{"rack-b2", "rack-c1"}},
+		{"range below", keys(store.ByIndexRange(testIndexer, "", "rack-a2")), []string{"rack-a1"}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.expected, test.items) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, test.items)
+		}
+	}
+
+	store.Delete("a")
+	store.Update("b", "rack-b1")
+	if e, a := []string{}, keys(store.ByIndexPrefix(testIndexer, "rack-a")); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := []string{"rack-b1", "rack-b2"}, keys(store.ByIndexPrefix(testIndexer, "rack-b")); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	store.Replace(map[string]interface{}{"e": "rack-a3"}, "")
+	if e, a := []string{"rack-a3"}, keys(store.ByIndexRange(testIndexer, "", "")); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	if _, err := store.ByIndexPrefix("missing", ""); err == nil {
+		t.Errorf("expected an error for a missing index")
+	}
+}
+
+func TestThreadSafeStoreUnorderedIndex(t *testing.T) {
+	testIndexer := "testIndexer"
+	store := NewThreadSafeStore(Indexers{
+		testIndexer: func(obj interface{}) ([]string, error) {
+			return []string{obj.(string)}, nil
+		},
+	}, Indices{})
+	store.Add("a", "rack-a1")
+
+	if _, err := store.ByIndexPrefix(testIndexer, "rack"); err == nil {
+		t.Errorf("expected an error querying an index that is not ordered")
+	}
+	if len(store.(*threadSafeMap).orderedValues) != 0 {
+		t.Errorf("expected no ordered values to be kept for exact-match indexes")
+	}
+	if items, err := store.ByIndex(testIndexer, "rack-a1"); err != nil || len(items) != 1 {
+		t.Errorf("expected exact-match queries to work, got %v, %v", items, err)
+	}
+}
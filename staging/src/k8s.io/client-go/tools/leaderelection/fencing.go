@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// FencingTokenHeader is the header the round tripper returned by
+// NewFencingTokenRoundTripper sets on writes.
+const FencingTokenHeader = "X-Fencing-Token"
+
+type fencingTokenContextKey struct{}
+
+// WithFencingToken returns a copy of parent carrying the fencing token.
+func WithFencingToken(parent context.Context, token int) context.Context {
+	return context.WithValue(parent, fencingTokenContextKey{}, token)
+}
+
+// FencingTokenFrom returns the fencing token of the term ctx was created for,
+// and whether there is one. The context passed to OnStartedLeading carries
+// the LeaderTransitions of the leader election record at the time the lease
+// was acquired. It increases whenever another candidate acquires the lease,
+// so systems outside of the cluster can reject writes carrying a token lower
+// than the highest one they have seen, from a leader that lost its lease
+// without noticing.
+func FencingTokenFrom(ctx context.Context) (int, bool) {
+	token, ok := ctx.Value(fencingTokenContextKey{}).(int)
+	return token, ok
+}
+
+type requestCanceler interface {
+	CancelRequest(*http.Request)
+}
+
+type fencingTokenRoundTripper struct {
+	rt http.RoundTripper
+}
+
+// NewFencingTokenRoundTripper returns a round tripper setting the
+// FencingTokenHeader of POST, PUT, PATCH and DELETE requests to the fencing
+// token of their context, if it has one. Requests made with the context
+// passed to OnStartedLeading, or a context derived from it, carry the token
+// of the term and fail once the term is over.
+func NewFencingTokenRoundTripper(rt http.RoundTripper) http.RoundTripper {
+	return &fencingTokenRoundTripper{rt: rt}
+}
+
+func (rt *fencingTokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return rt.rt.RoundTrip(req)
+	}
+	token, ok := FencingTokenFrom(req.Context())
+	if !ok {
+		return rt.rt.RoundTrip(req)
+	}
+	req = utilnet.CloneRequest(req)
+	req.Header.Set(FencingTokenHeader, strconv.Itoa(token))
+	return rt.rt.RoundTrip(req)
+}
+
+func (rt *fencingTokenRoundTripper) CancelRequest(req *http.Request) {
+	if canceler, ok := rt.rt.(requestCanceler); ok {
+		canceler.CancelRequest(req)
+	}
+}
+
+func (rt *fencingTokenRoundTripper) WrappedRoundTripper() http.RoundTripper { return rt.rt }
@@ -0,0 +1,144 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	rl "k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+)
+
+type headerRecorder struct {
+	header http.Header
+}
+
+func (r *headerRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.header = req.Header
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestFencingTokenRoundTripper(t *testing.T) {
+	leaderCtx := WithFencingToken(context.Background(), 7)
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		method   string
+		expected string
+	}{
+		{name: "write", ctx: leaderCtx, method: http.MethodPatch, expected: "7"},
+		{name: "read", ctx: leaderCtx, method: http.MethodGet},
+		{name: "no token", ctx: context.Background(), method: http.MethodPost},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := &headerRecorder{}
+			req, err := http.NewRequestWithContext(test.ctx, test.method, "https://example.com/things", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewFencingTokenRoundTripper(recorder).RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := recorder.header.Get(FencingTokenHeader); got != test.expected {
+				t.Errorf("expected token %q, got %q", test.expected, got)
+			}
+			if len(req.Header.Get(FencingTokenHeader)) > 0 {
+				t.Errorf("the original request was modified")
+			}
+		})
+	}
+}
+
+func TestRunFencingTokenAndRelease(t *testing.T) {
+	spec := rl.LeaderElectionRecordToLeaseSpec(&rl.LeaderElectionRecord{LeaderTransitions: 4})
+	c := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"},
+		Spec:       spec,
+	})
+	lock := &rl.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: "foo", Name: "bar"},
+		Client:     c.CoordinationV1(),
+		LockConfig: rl.ResourceLockConfig{Identity: "baz", EventRecorder: &record.FakeRecorder{}},
+	}
+
+	started := make(chan context.Context, 1)
+	var leaderCtxDone bool
+	var holderWhenStopped string
+	lec := LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   2 * time.Second,
+		RetryPeriod:     1 * time.Second,
+		ReleaseOnCancel: true,
+		Callbacks: LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				started <- ctx
+			},
+			OnStoppedLeading: func() {
+				leaderCtx := <-started
+				leaderCtxDone = leaderCtx.Err() != nil
+				lease, err := c.CoordinationV1().Leases("foo").Get(context.Background(), "bar", metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				holderWhenStopped = *lease.Spec.HolderIdentity
+			},
+		},
+	}
+	elector, err := NewLeaderElector(lec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(ctx)
+	}()
+
+	select {
+	case leaderCtx := <-started:
+		if token, ok := FencingTokenFrom(leaderCtx); !ok || token != 5 {
+			t.Errorf("expected fencing token 5, got %d, %v", token, ok)
+		}
+		started <- leaderCtx
+	case <-time.After(10 * time.Second):
+		t.Fatal("failed to become the leader")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return")
+	}
+
+	if !leaderCtxDone {
+		t.Errorf("expected the leader context to be cancelled before OnStoppedLeading")
+	}
+	if holderWhenStopped != "" {
+		t.Errorf("expected the lease to be released before OnStoppedLeading, held by %q", holderWhenStopped)
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 // Package leaderelection implements leader election of a set of endpoints.
 // It uses an annotation in the endpoints object to store the record of the
 // election state. This implementation does not guarantee that only one
-// client is acting as a leader (a.k.a. fencing).
+// client is acting as a leader (a.k.a. fencing). Instead, the context passed
+// to OnStartedLeading carries a fencing token, which systems outside of the
+// cluster can use to reject writes from previous leaders. See
+// FencingTokenFrom.
 //
 // A client only acts on timestamps captured locally to infer the state of the
 // leader election. The client does not consider timestamps in the leader
@@ -150,6 +153,10 @@ type LeaderElectionConfig struct {
 	// ensure all code guarded by this lease has successfully completed
 	// prior to cancelling the context, or you may have two processes
 	// simultaneously acting on the critical path.
+	//
+	// The context passed to OnStartedLeading is cancelled before the lock
+	// is released. Releasing is retried until RenewDeadline, and completes
+	// before OnStoppedLeading is called.
 	ReleaseOnCancel bool
 
 	// Name is the name of the resource lock for debugging
@@ -162,7 +169,9 @@ type LeaderElectionConfig struct {
 // possible future callbacks:
 //  * OnChallenge()
 type LeaderCallbacks struct {
-	// OnStartedLeading is called when a LeaderElector client starts leading
+	// OnStartedLeading is called when a LeaderElector client starts leading.
+	// The context is cancelled when it stops leading, and carries the
+	// fencing token of the term.
 	OnStartedLeading func(context.Context)
 	// OnStoppedLeading is called when a LeaderElector client stops leading
 	OnStoppedLeading func()
@@ -205,8 +214,14 @@ func (le *LeaderElector) Run(ctx context.Context) {
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	go le.config.Callbacks.OnStartedLeading(ctx)
+	go le.config.Callbacks.OnStartedLeading(WithFencingToken(ctx, le.observedRecord.LeaderTransitions))
 	le.renew(ctx)
+
+	// stop the code guarded by the lease before giving it up
+	cancel()
+	if le.config.ReleaseOnCancel {
+		le.releaseUntilDeadline()
+	}
 }
 
 // RunOrDie starts a client with the provided config or panics if the config
@@ -278,15 +293,23 @@ func (le *LeaderElector) renew(ctx context.Context) {
 		klog.Infof("failed to renew lease %v: %v", desc, err)
 		cancel()
 	}, le.config.RetryPeriod, ctx.Done())
+}
 
-	// if we hold the lease, give it up
-	if le.config.ReleaseOnCancel {
-		le.release()
+// releaseUntilDeadline retries releasing the leader lease until it succeeds
+// or RenewDeadline passes.
+func (le *LeaderElector) releaseUntilDeadline() {
+	ctx, cancel := context.WithTimeout(context.Background(), le.config.RenewDeadline)
+	defer cancel()
+	err := wait.PollImmediateUntil(le.config.RetryPeriod, func() (bool, error) {
+		return le.release(ctx), nil
+	}, ctx.Done())
+	if err != nil {
+		klog.Errorf("failed to release lease %v: %v", le.config.Lock.Describe(), err)
 	}
 }
 
 // release attempts to release the leader lease if we have acquired it.
-func (le *LeaderElector) release() bool {
+func (le *LeaderElector) release(ctx context.Context) bool {
 	if !le.IsLeader() {
 		return true
 	}
@@ -297,7 +320,7 @@ func (le *LeaderElector) release() bool {
 		RenewTime:            now,
 		AcquireTime:          now,
 	}
-	if err := le.config.Lock.Update(context.TODO(), leaderElectionRecord); err != nil {
+	if err := le.config.Lock.Update(ctx, leaderElectionRecord); err != nil {
 		klog.Errorf("Failed to release lock: %v", err)
 		return false
 	}
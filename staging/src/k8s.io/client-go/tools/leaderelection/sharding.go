@@ -0,0 +1,495 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	rl "k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/util/workqueue"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// maxConcurrentLeaseUpdates is the number of leases of shards a
+	// ShardedLeaderElector renews or releases at the same time.
+	maxConcurrentLeaseUpdates = 16
+	// memberLeaseGCFactor is the number of LeaseDurations after which the
+	// membership lease of a member that stopped renewing it is deleted.
+	memberLeaseGCFactor = 10
+)
+
+// ShardedLeaderElectionConfig configures a ShardedLeaderElector.
+type ShardedLeaderElectionConfig struct {
+	// Client is used to manage the leases of the shards and of the members.
+	Client coordinationv1client.LeasesGetter
+	// Namespace is the namespace of the leases.
+	Namespace string
+	// Name is the prefix of the names of the leases. Shard i is guarded by
+	// the lease <Name>-shard-<i>, and every member holds a lease named
+	// <Name>-member-<hash of its identity>. A member deletes its lease when
+	// it stops, and the leases of members that stopped renewing them are
+	// deleted after 10 LeaseDurations.
+	Name string
+	// Identity is the unique string identifying this member.
+	Identity string
+	// EventRecorder is optional.
+	EventRecorder rl.EventRecorder
+
+	// Shards is the number of shards. It must be the same for all members.
+	Shards int
+
+	// LeaseDuration, RenewDeadline and RetryPeriod have the meaning they
+	// have in LeaderElectionConfig, for every shard and for the membership
+	// of the elector.
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+
+	// Callbacks are triggered when shards are acquired and lost.
+	Callbacks ShardedLeaderCallbacks
+
+	// ReleaseOnCancel should be set true if the leases of the shards should
+	// be released when the run context is cancelled, so that the other
+	// members take over the shards without waiting for the leases to
+	// expire. The same caution as for LeaderElectionConfig.ReleaseOnCancel
+	// applies.
+	ReleaseOnCancel bool
+}
+
+// ShardedLeaderCallbacks are callbacks that are triggered when a
+// ShardedLeaderElector acquires or loses a shard. Both are optional.
+type ShardedLeaderCallbacks struct {
+	// OnStartedLeading is called asynchronously when the elector acquires a
+	// shard. The context is cancelled when it loses the shard, and carries
+	// the fencing token of the shard.
+	OnStartedLeading func(ctx context.Context, shard int)
+	// OnStoppedLeading is called asynchronously when the elector loses a
+	// shard, after the context of the shard is cancelled. The lease of a
+	// shard that is released, because it was assigned to another member or
+	// on cancel, is only released once the call returned. Run waits for the
+	// calls to return before returning.
+	OnStoppedLeading func(shard int)
+}
+
+// ShardedLeaderElector elects a leader for each of a fixed number of shards
+// among a set of members, so that the work of a controller can be spread
+// over several replicas. Every member holds a membership lease, and owns the
+// shards that rendezvous hashing of the identities of the live members
+// assigns to it. When members join or leave, members release the shards
+// they are no longer assigned, and the new owners acquire them. A shard is
+// only acquired once its lease is released or expired, and its lease is
+// only released once OnStoppedLeading returned, so that a shard has at most
+// one owner under the same assumptions LeaderElector makes.
+//
+// Controllers can use Owns to skip the keys of the shards they don't own.
+type ShardedLeaderElector struct {
+	config ShardedLeaderElectionConfig
+
+	// member renews the membership lease.
+	member *LeaderElector
+	// shards elect the leaders of the shards. They are only used by the
+	// goroutine running the elector.
+	shards []*shardElector
+	// observedMembers tracks the membership leases of the other members
+	// by name, to find out when they expire.
+	observedMembers map[string]observedMember
+
+	// lock guards owned.
+	lock sync.RWMutex
+	// owned maps the shards this member owns to the time their leases were
+	// last renewed.
+	owned map[int]time.Time
+
+	// callbacks tracks the running OnStoppedLeading callbacks and the
+	// releases of leases following them.
+	callbacks sync.WaitGroup
+
+	// clock is wrapper around time to allow for less flaky testing
+	clock clock.Clock
+}
+
+type shardElector struct {
+	*LeaderElector
+	cancel context.CancelFunc
+	// releasing is closed once the lease of the shard is released after
+	// OnStoppedLeading returned. The shard is neither acquired nor renewed
+	// until then.
+	releasing chan struct{}
+}
+
+type observedMember struct {
+	identity     string
+	renewTime    metav1.MicroTime
+	observedTime time.Time
+}
+
+func (m observedMember) sameRecord(other observedMember) bool {
+	return m.identity == other.identity && m.renewTime.Equal(&other.renewTime)
+}
+
+// NewShardedLeaderElector creates a ShardedLeaderElector from a
+// ShardedLeaderElectionConfig.
+func NewShardedLeaderElector(config ShardedLeaderElectionConfig) (*ShardedLeaderElector, error) {
+	if config.LeaseDuration <= config.RenewDeadline {
+		return nil, fmt.Errorf("leaseDuration must be greater than renewDeadline")
+	}
+	if config.RenewDeadline <= time.Duration(JitterFactor*float64(config.RetryPeriod)) {
+		return nil, fmt.Errorf("renewDeadline must be greater than retryPeriod*JitterFactor")
+	}
+	if config.RetryPeriod < 1 {
+		return nil, fmt.Errorf("retryPeriod must be greater than zero")
+	}
+	if config.Shards < 1 {
+		return nil, fmt.Errorf("shards must be greater than zero")
+	}
+	if config.Client == nil {
+		return nil, fmt.Errorf("Client must not be nil")
+	}
+	if len(config.Identity) == 0 {
+		return nil, fmt.Errorf("Identity must not be empty")
+	}
+
+	c := clock.RealClock{}
+	s := &ShardedLeaderElector{
+		config:          config,
+		observedMembers: map[string]observedMember{},
+		owned:           map[int]time.Time{},
+		clock:           c,
+	}
+	s.member = s.newLeaderElector(s.memberLeaseName(config.Identity))
+	for i := 0; i < config.Shards; i++ {
+		s.shards = append(s.shards, &shardElector{LeaderElector: s.newLeaderElector(s.shardLeaseName(i))})
+	}
+	return s, nil
+}
+
+func (s *ShardedLeaderElector) newLeaderElector(name string) *LeaderElector {
+	le := &LeaderElector{
+		config: LeaderElectionConfig{
+			Lock: &rl.LeaseLock{
+				LeaseMeta: metav1.ObjectMeta{Namespace: s.config.Namespace, Name: name},
+				Client:    s.config.Client,
+				LockConfig: rl.ResourceLockConfig{
+					Identity:      s.config.Identity,
+					EventRecorder: s.config.EventRecorder,
+				},
+			},
+			LeaseDuration: s.config.LeaseDuration,
+			RenewDeadline: s.config.RenewDeadline,
+			RetryPeriod:   s.config.RetryPeriod,
+			Name:          name,
+		},
+		clock:   s.clock,
+		metrics: globalMetricsFactory.newLeaderMetrics(),
+	}
+	le.metrics.leaderOff(name)
+	return le
+}
+
+func (s *ShardedLeaderElector) shardLeaseName(shard int) string {
+	return s.config.Name + "-shard-" + strconv.Itoa(shard)
+}
+
+func (s *ShardedLeaderElector) memberLeaseName(identity string) string {
+	h := fnv.New64a()
+	h.Write([]byte(identity))
+	return fmt.Sprintf("%s-member-%016x", s.config.Name, h.Sum64())
+}
+
+// Run runs the elector until ctx is done.
+func (s *ShardedLeaderElector) Run(ctx context.Context) {
+	defer runtime.HandleCrash()
+	wait.JitterUntil(func() {
+		s.sync(ctx)
+	}, s.config.RetryPeriod, JitterFactor, true, ctx.Done())
+	s.shutdown()
+}
+
+// ShardForKey returns the shard of key among the given number of shards.
+func ShardForKey(key string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// Owns returns true if this member owns the shard of key.
+func (s *ShardedLeaderElector) Owns(key string) bool {
+	return s.OwnsShard(ShardForKey(key, s.config.Shards))
+}
+
+// OwnsShard returns true if this member owns shard. A member stops owning
+// a shard when it fails to renew its lease for RenewDeadline, even before
+// the elector notices.
+func (s *ShardedLeaderElector) OwnsShard(shard int) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	renewed, ok := s.owned[shard]
+	return ok && s.clock.Since(renewed) < s.config.RenewDeadline
+}
+
+// OwnedShards returns the sorted shards this member owns.
+func (s *ShardedLeaderElector) OwnedShards() []int {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var shards []int
+	for shard, renewed := range s.owned {
+		if s.clock.Since(renewed) < s.config.RenewDeadline {
+			shards = append(shards, shard)
+		}
+	}
+	sort.Ints(shards)
+	return shards
+}
+
+// sync renews the membership of the elector, and acquires, renews and
+// releases the leases of the shards to match the shards assigned to it.
+func (s *ShardedLeaderElector) sync(ctx context.Context) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.config.RenewDeadline)
+	defer cancel()
+
+	if !s.member.tryAcquireOrRenew(timeoutCtx) {
+		klog.V(4).Infof("failed to renew membership lease %v", s.member.config.Lock.Describe())
+	}
+	members, err := s.liveMembers(timeoutCtx)
+	if err != nil {
+		klog.Errorf("failed to list the members of %v/%v: %v", s.config.Namespace, s.config.Name, err)
+		// Keep the shards we own, rather than acquiring shards based on
+		// an outdated membership.
+		members = nil
+	}
+
+	var renew []int
+	for shard, elector := range s.shards {
+		if elector.releasing != nil {
+			select {
+			case <-elector.releasing:
+				elector.releasing = nil
+			default:
+				continue
+			}
+		}
+		owned := elector.cancel != nil
+		assigned := members != nil && s.assignedTo(shard, members) == s.config.Identity
+		switch {
+		case assigned || (owned && members == nil):
+			renew = append(renew, shard)
+		case owned:
+			// The shard is assigned to another member, which acquires it
+			// once it is released.
+			s.stopLeading(shard, true)
+		}
+	}
+
+	// The leases are updated concurrently, so that all of them are renewed
+	// within RenewDeadline however many shards this member owns.
+	renewed := make([]bool, len(renew))
+	workqueue.ParallelizeUntil(timeoutCtx, maxConcurrentLeaseUpdates, len(renew), func(i int) {
+		if s.shards[renew[i]].tryAcquireOrRenew(timeoutCtx) {
+			s.setRenewed(renew[i])
+			renewed[i] = true
+		}
+	})
+
+	for i, shard := range renew {
+		elector := s.shards[shard]
+		owned := elector.cancel != nil
+		switch {
+		case renewed[i] && !owned:
+			s.startLeading(ctx, shard)
+		case !renewed[i] && owned && !s.OwnsShard(shard):
+			klog.Infof("failed to renew lease %v", elector.config.Lock.Describe())
+			s.stopLeading(shard, false)
+		}
+	}
+}
+
+// liveMembers returns the identities of the members whose membership
+// leases are held and were renewed within the last LeaseDuration, as
+// observed by the local clock. The leases of the members that stopped
+// renewing them memberLeaseGCFactor LeaseDurations ago are deleted.
+func (s *ShardedLeaderElector) liveMembers(ctx context.Context) ([]string, error) {
+	leases, err := s.config.Client.Leases(s.config.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	now := s.clock.Now()
+	prefix := s.config.Name + "-member-"
+	observed := map[string]observedMember{}
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if !strings.HasPrefix(lease.Name, prefix) {
+			continue
+		}
+		record := rl.LeaseSpecToLeaderElectionRecord(&lease.Spec)
+		member := observedMember{
+			identity:     record.HolderIdentity,
+			renewTime:    metav1.MicroTime{Time: record.RenewTime.Time},
+			observedTime: now,
+		}
+		if previous, ok := s.observedMembers[lease.Name]; ok && previous.sameRecord(member) {
+			member.observedTime = previous.observedTime
+		}
+		if lease.Name != s.member.config.Name && now.Sub(member.observedTime) > memberLeaseGCFactor*s.config.LeaseDuration {
+			// The precondition keeps the lease if the member renewed it
+			// in the meantime.
+			err := s.config.Client.Leases(s.config.Namespace).Delete(ctx, lease.Name, metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{ResourceVersion: &lease.ResourceVersion},
+			})
+			if err == nil {
+				klog.V(2).Infof("deleted expired membership lease %v/%v of %q", lease.Namespace, lease.Name, member.identity)
+				continue
+			}
+			klog.V(4).Infof("failed to delete expired membership lease %v/%v: %v", lease.Namespace, lease.Name, err)
+		}
+		observed[lease.Name] = member
+	}
+	s.observedMembers = observed
+
+	var members []string
+	for _, member := range observed {
+		if len(member.identity) == 0 {
+			continue
+		}
+		if member.identity == s.config.Identity || member.observedTime.Add(s.config.LeaseDuration).After(now) {
+			members = append(members, member.identity)
+		}
+	}
+	return members, nil
+}
+
+// assignedTo returns the member shard is assigned to by rendezvous hashing:
+// the member with the highest score for the shard.
+func (s *ShardedLeaderElector) assignedTo(shard int, members []string) string {
+	var assigned string
+	var highest uint64
+	for _, member := range members {
+		score := rendezvousScore(member, shard)
+		if len(assigned) == 0 || score > highest || (score == highest && member < assigned) {
+			assigned, highest = member, score
+		}
+	}
+	return assigned
+}
+
+func rendezvousScore(member string, shard int) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(member))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(shard)))
+	// fnv hashes of similar strings are close, mix the bits so that the
+	// scores of members are independent.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func (s *ShardedLeaderElector) setRenewed(shard int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.owned[shard] = s.clock.Now()
+}
+
+func (s *ShardedLeaderElector) startLeading(ctx context.Context, shard int) {
+	elector := s.shards[shard]
+	elector.config.Lock.RecordEvent("became leader")
+	elector.metrics.leaderOn(elector.config.Name)
+	klog.Infof("successfully acquired lease %v", elector.config.Lock.Describe())
+
+	ctx, elector.cancel = context.WithCancel(ctx)
+	if s.config.Callbacks.OnStartedLeading != nil {
+		go s.config.Callbacks.OnStartedLeading(WithFencingToken(ctx, elector.observedRecord.LeaderTransitions), shard)
+	}
+}
+
+// stopLeading cancels the context of shard and calls OnStoppedLeading. If
+// release is set, the lease of the shard is released once OnStoppedLeading
+// returned, so that the next owner doesn't start while this one is still
+// stopping.
+func (s *ShardedLeaderElector) stopLeading(shard int, release bool) {
+	elector := s.shards[shard]
+	elector.cancel()
+	elector.cancel = nil
+	s.lock.Lock()
+	delete(s.owned, shard)
+	s.lock.Unlock()
+
+	elector.config.Lock.RecordEvent("stopped leading")
+	elector.metrics.leaderOff(elector.config.Name)
+	if s.config.Callbacks.OnStoppedLeading == nil && !release {
+		return
+	}
+	if release {
+		elector.releasing = make(chan struct{})
+	}
+	releasing := elector.releasing
+	s.callbacks.Add(1)
+	go func() {
+		defer s.callbacks.Done()
+		if release {
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), s.config.RenewDeadline)
+				defer cancel()
+				elector.release(ctx)
+				close(releasing)
+			}()
+		}
+		defer runtime.HandleCrash()
+		if s.config.Callbacks.OnStoppedLeading != nil {
+			s.config.Callbacks.OnStoppedLeading(shard)
+		}
+	}()
+}
+
+// shutdown stops leading all shards, deletes the membership lease and waits
+// for the OnStoppedLeading callbacks to return and, if ReleaseOnCancel is
+// set, for the leases of the shards to be released.
+func (s *ShardedLeaderElector) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.RenewDeadline)
+	defer cancel()
+	for shard, elector := range s.shards {
+		if elector.cancel != nil {
+			s.stopLeading(shard, s.config.ReleaseOnCancel)
+		}
+	}
+	if s.member.IsLeader() {
+		// The other members stop counting this one once its lease is gone,
+		// rather than once it expires.
+		name := s.member.config.Name
+		if err := s.config.Client.Leases(s.config.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			klog.Errorf("failed to delete membership lease %v/%v: %v", s.config.Namespace, name, err)
+		}
+	}
+	s.callbacks.Wait()
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestShardedLeaderElector(t *testing.T, c kubernetes.Interface, identity string, onStoppedLeading func(int)) *ShardedLeaderElector {
+	s, err := NewShardedLeaderElector(ShardedLeaderElectionConfig{
+		Client:          c.CoordinationV1(),
+		Namespace:       "foo",
+		Name:            "bar",
+		Identity:        identity,
+		EventRecorder:   &record.FakeRecorder{},
+		Shards:          8,
+		LeaseDuration:   15 * time.Second,
+		RenewDeadline:   10 * time.Second,
+		RetryPeriod:     2 * time.Second,
+		ReleaseOnCancel: true,
+		Callbacks: ShardedLeaderCallbacks{
+			OnStoppedLeading: onStoppedLeading,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// stoppedShards records the shards passed to OnStoppedLeading.
+type stoppedShards struct {
+	lock   sync.Mutex
+	shards []int
+}
+
+func (s *stoppedShards) add(shard int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.shards = append(s.shards, shard)
+}
+
+// get returns the sorted shards once the running callbacks of elector
+// returned, and forgets them.
+func (s *stoppedShards) get(elector *ShardedLeaderElector) []int {
+	elector.callbacks.Wait()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	shards := s.shards
+	s.shards = nil
+	sort.Ints(shards)
+	return shards
+}
+
+func TestShardedLeaderElector(t *testing.T) {
+	c := fake.NewSimpleClientset()
+	newElector := func(identity string, stopped *stoppedShards) *ShardedLeaderElector {
+		return newTestShardedLeaderElector(t, c, identity, stopped.add)
+	}
+	var stoppedA, stoppedB stoppedShards
+	a := newElector("a", &stoppedA)
+	b := newElector("b", &stoppedB)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	all := []int{0, 1, 2, 3, 4, 5, 6, 7}
+	a.sync(ctx)
+	if got := a.OwnedShards(); !reflect.DeepEqual(got, all) {
+		t.Fatalf("expected the only member to own all shards, got %v", got)
+	}
+
+	// b joins: it can't acquire its shards before a releases them.
+	b.sync(ctx)
+	if got := b.OwnedShards(); len(got) != 0 {
+		t.Fatalf("expected b to own no shards before a releases them, got %v", got)
+	}
+	a.sync(ctx)
+	// The leases are released once the OnStoppedLeading callbacks returned.
+	a.callbacks.Wait()
+	b.sync(ctx)
+	ownedA, ownedB := a.OwnedShards(), b.OwnedShards()
+	if len(ownedA) == 0 || len(ownedB) == 0 {
+		t.Fatalf("expected the shards to be spread over both members, got %v and %v", ownedA, ownedB)
+	}
+	union := append(append([]int{}, ownedA...), ownedB...)
+	sort.Ints(union)
+	if !reflect.DeepEqual(union, all) {
+		t.Fatalf("expected every shard to be owned by exactly one member, got %v and %v", ownedA, ownedB)
+	}
+	if got := stoppedA.get(a); !reflect.DeepEqual(got, ownedB) {
+		t.Errorf("expected a to stop leading %v, got %v", ownedB, got)
+	}
+	for i := 0; i < 100; i++ {
+		key := "ns/" + strconv.Itoa(i)
+		if a.Owns(key) == b.Owns(key) {
+			t.Errorf("expected key %q to be owned by exactly one member", key)
+		}
+	}
+
+	// a leaves gracefully: b takes over without waiting for the leases to
+	// expire.
+	a.shutdown()
+	if got := a.OwnedShards(); len(got) != 0 {
+		t.Errorf("expected a to own no shards after shutdown, got %v", got)
+	}
+	if got := stoppedA.get(a); !reflect.DeepEqual(got, ownedA) {
+		t.Errorf("expected a to stop leading %v, got %v", ownedA, got)
+	}
+	if _, err := c.CoordinationV1().Leases("foo").Get(ctx, a.member.config.Name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the membership lease of a to be deleted, got %v", err)
+	}
+	b.sync(ctx)
+	if got := b.OwnedShards(); !reflect.DeepEqual(got, all) {
+		t.Errorf("expected the remaining member to own all shards, got %v", got)
+	}
+	if got := stoppedB.get(b); len(got) != 0 {
+		t.Errorf("expected b to keep its shards, stopped %v", got)
+	}
+}
+
+func TestShardedLeaderElectorSlowStoppedCallback(t *testing.T) {
+	c := fake.NewSimpleClientset()
+	unblock := make(chan struct{})
+	a := newTestShardedLeaderElector(t, c, "a", func(int) { <-unblock })
+	b := newTestShardedLeaderElector(t, c, "b", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a.sync(ctx)
+	b.sync(ctx)
+	// a loses the shards assigned to b. Its callbacks don't hold up the
+	// renewal of the other shards.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.sync(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("sync is blocked by the OnStoppedLeading callbacks")
+	}
+	ownedA := a.OwnedShards()
+	if len(ownedA) == 0 || len(ownedA) == a.config.Shards {
+		t.Fatalf("expected a to keep some of the shards, got %v", ownedA)
+	}
+
+	// b doesn't lead the lost shards while a is still stopping them.
+	for i := 0; i < 3; i++ {
+		b.sync(ctx)
+		a.sync(ctx)
+		if got := b.OwnedShards(); len(got) != 0 {
+			t.Fatalf("expected b to own no shards before the callbacks of a returned, got %v", got)
+		}
+	}
+	if got := a.OwnedShards(); !reflect.DeepEqual(got, ownedA) {
+		t.Errorf("expected a to keep owning %v, got %v", ownedA, got)
+	}
+
+	close(unblock)
+	a.callbacks.Wait()
+	b.sync(ctx)
+	ownedB := b.OwnedShards()
+	union := append(append([]int{}, ownedA...), ownedB...)
+	sort.Ints(union)
+	if !reflect.DeepEqual(union, []int{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("expected b to own the shards a released, got %v and %v", ownedA, ownedB)
+	}
+	a.shutdown()
+	b.shutdown()
+}
+
+func TestShardedLeaderElectorDeletesExpiredMemberLeases(t *testing.T) {
+	c := fake.NewSimpleClientset()
+	a := newTestShardedLeaderElector(t, c, "a", nil)
+	fakeClock := clock.NewFakeClock(time.Now())
+	a.clock = fakeClock
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// b crashed without deleting its membership lease.
+	holder, leaseDurationSeconds := "b", int32(15)
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: a.memberLeaseName("b")},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &leaseDurationSeconds,
+			RenewTime:            &metav1.MicroTime{Time: fakeClock.Now()},
+		},
+	}
+	if _, err := c.CoordinationV1().Leases("foo").Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	a.sync(ctx)
+	if got := a.OwnedShards(); len(got) == 0 || len(got) == a.config.Shards {
+		t.Fatalf("expected some shards to be assigned to b, got %v", got)
+	}
+
+	fakeClock.Step(memberLeaseGCFactor * a.config.LeaseDuration)
+	a.sync(ctx)
+	if got := a.OwnedShards(); len(got) != a.config.Shards {
+		t.Errorf("expected the remaining member to own all shards, got %v", got)
+	}
+	if _, err := c.CoordinationV1().Leases("foo").Get(ctx, lease.Name, metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the membership lease of b to be kept for now, got %v", err)
+	}
+	fakeClock.Step(time.Second)
+	a.sync(ctx)
+	if _, err := c.CoordinationV1().Leases("foo").Get(ctx, lease.Name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the expired membership lease of b to be deleted, got %v", err)
+	}
+}
+
+func TestRendezvousAssignmentIsStable(t *testing.T) {
+	s := &ShardedLeaderElector{}
+	before := []string{"a", "b", "c"}
+	after := []string{"a", "b", "c", "d"}
+	for shard := 0; shard < 100; shard++ {
+		owner := s.assignedTo(shard, before)
+		// Adding a member only moves shards to the new member.
+		if got := s.assignedTo(shard, after); got != owner && got != "d" {
+			t.Errorf("shard %d moved from %q to %q", shard, owner, got)
+		}
+		// The assignment doesn't depend on the order of the members.
+		if got := s.assignedTo(shard, []string{"c", "a", "b"}); got != owner {
+			t.Errorf("shard %d assigned to %q and %q", shard, owner, got)
+		}
+	}
+}
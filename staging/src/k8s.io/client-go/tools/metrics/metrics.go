@@ -46,6 +46,24 @@ type ResultMetric interface {
 	Increment(code string, method string, host string)
 }
 
+// QPSMetric sets the rate chosen by client side rate limiters partitioned by
+// their name.
+type QPSMetric interface {
+	Set(name string, qps float64)
+}
+
+// CircuitStateMetric sets the state of client side circuit breakers
+// partitioned by host and verb.
+type CircuitStateMetric interface {
+	Set(host string, verb string, state string)
+}
+
+// CircuitRejectedMetric counts requests rejected by client side circuit
+// breakers partitioned by host and verb.
+type CircuitRejectedMetric interface {
+	Increment(host string, verb string)
+}
+
 var (
 	// ClientCertExpiry is the expiry time of a client certificate
 	ClientCertExpiry ExpiryMetric = noopExpiry{}
@@ -55,17 +73,26 @@ var (
 	RequestLatency LatencyMetric = noopLatency{}
 	// RateLimiterLatency is the client side rate limiter latency metric.
 	RateLimiterLatency LatencyMetric = noopLatency{}
+	// RateLimiterQPS is the rate metric that adaptive rate limiters will update.
+	RateLimiterQPS QPSMetric = noopQPS{}
 	// RequestResult is the result metric that rest clients will update.
 	RequestResult ResultMetric = noopResult{}
+	// CircuitBreakerState is the state metric that circuit breakers will update.
+	CircuitBreakerState CircuitStateMetric = noopCircuitState{}
+	// CircuitBreakerRejected is the metric of requests rejected by circuit breakers.
+	CircuitBreakerRejected CircuitRejectedMetric = noopCircuitRejected{}
 )
 
 // RegisterOpts contains all the metrics to register. Metrics may be nil.
 type RegisterOpts struct {
-	ClientCertExpiry      ExpiryMetric
-	ClientCertRotationAge DurationMetric
-	RequestLatency        LatencyMetric
-	RateLimiterLatency    LatencyMetric
-	RequestResult         ResultMetric
+	ClientCertExpiry       ExpiryMetric
+	ClientCertRotationAge  DurationMetric
+	RequestLatency         LatencyMetric
+	RateLimiterLatency     LatencyMetric
+	RateLimiterQPS         QPSMetric
+	RequestResult          ResultMetric
+	CircuitBreakerState    CircuitStateMetric
+	CircuitBreakerRejected CircuitRejectedMetric
 }
 
 // Register registers metrics for the rest client to use. This can
@@ -84,9 +111,18 @@ func Register(opts RegisterOpts) {
 		if opts.RateLimiterLatency != nil {
 			RateLimiterLatency = opts.RateLimiterLatency
 		}
+		if opts.RateLimiterQPS != nil {
+			RateLimiterQPS = opts.RateLimiterQPS
+		}
 		if opts.RequestResult != nil {
 			RequestResult = opts.RequestResult
 		}
+		if opts.CircuitBreakerState != nil {
+			CircuitBreakerState = opts.CircuitBreakerState
+		}
+		if opts.CircuitBreakerRejected != nil {
+			CircuitBreakerRejected = opts.CircuitBreakerRejected
+		}
 	})
 }
 
@@ -105,3 +141,15 @@ func (noopLatency) Observe(string, url.URL, time.Duration) {}
 type noopResult struct{}
 
 func (noopResult) Increment(string, string, string) {}
+
+type noopQPS struct{}
+
+func (noopQPS) Set(string, float64) {}
+
+type noopCircuitState struct{}
+
+func (noopCircuitState) Set(string, string, string) {}
+
+type noopCircuitRejected struct{}
+
+func (noopCircuitRejected) Increment(string, string) {}
@@ -0,0 +1,384 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// defaultMaxLag is how many events a consumer of a shared watch may fall
+// behind it, on top of the history it gets when it joins.
+const defaultMaxLag = 1000
+
+// WatchKey identifies the watches a Multiplexer can share.
+type WatchKey struct {
+	Resource      schema.GroupVersionResource
+	Namespace     string
+	LabelSelector string
+	FieldSelector string
+}
+
+// Multiplexer shares watches of the same resource, namespace and selectors
+// among the callers in a process, so that the server sends every event only
+// once. Every caller gets its own watch.Interface that starts after the
+// resourceVersion the caller asked for, and that survives the shared watch
+// being closed by resuming it like a RetryWatcher.
+//
+// A caller can only join a shared watch if the events it needs are still
+// in the history the Multiplexer keeps, which requires resourceVersions to
+// be integers, as they are with the etcd3 storage. Otherwise the caller gets
+// a RetryWatcher of its own.
+//
+// The events of a shared watch are queued for every caller, so that a slow
+// caller doesn't hold up the others. A caller that falls too far behind is
+// sent an Expired error and its watch is closed, like the server does with
+// watches that can't keep up.
+type Multiplexer struct {
+	historySize     int
+	minRestartDelay time.Duration
+	// maxQueued is the most events queued for a consumer.
+	maxQueued int
+
+	lock    sync.Mutex
+	watches map[WatchKey]*sharedWatch
+}
+
+// NewMultiplexer creates a Multiplexer that keeps the last historySize
+// events of every shared watch for callers that join it late.
+func NewMultiplexer(historySize int) *Multiplexer {
+	return newMultiplexer(historySize, 1*time.Second, defaultMaxLag)
+}
+
+func newMultiplexer(historySize int, minRestartDelay time.Duration, maxLag int) *Multiplexer {
+	return &Multiplexer{
+		historySize:     historySize,
+		minRestartDelay: minRestartDelay,
+		maxQueued:       historySize + maxLag,
+		watches:         map[WatchKey]*sharedWatch{},
+	}
+}
+
+// Watch returns a watch of the objects identified by key that starts after
+// resourceVersion. watcher is used to watch them if there is no watch to
+// share yet, and must be scoped to the resource and namespace of key; the
+// selectors of key are added to the options it gets. Like a RetryWatcher,
+// the watch doesn't support a resourceVersion of "" or "0".
+func (m *Multiplexer) Watch(key WatchKey, watcher cache.Watcher, resourceVersion string) (watch.Interface, error) {
+	switch resourceVersion {
+	case "", "0":
+		return nil, fmt.Errorf("initial RV %q is not supported due to issues with underlying WATCH", resourceVersion)
+	}
+	watcher = &selectorWatcher{key: key, watcher: watcher}
+	rv, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		klog.V(4).Infof("Not sharing the watch of %v from non-numeric RV %q", key, resourceVersion)
+		return newRetryWatcher(resourceVersion, watcher, m.minRestartDelay)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if shared := m.watches[key]; shared != nil {
+		w, done := shared.add(rv)
+		if w != nil {
+			return w, nil
+		}
+		if !done {
+			klog.V(4).Infof("Not sharing the watch of %v, RV %q is no longer in its history", key, resourceVersion)
+			return newRetryWatcher(resourceVersion, watcher, m.minRestartDelay)
+		}
+	}
+
+	upstream, err := newRetryWatcher(resourceVersion, watcher, m.minRestartDelay)
+	if err != nil {
+		return nil, err
+	}
+	shared := &sharedWatch{
+		multiplexer: m,
+		key:         key,
+		upstream:    upstream,
+		rv:          rv,
+		historyRV:   rv,
+		consumers:   map[*multiplexedWatcher]struct{}{},
+	}
+	m.watches[key] = shared
+	go shared.run()
+	w, _ := shared.add(rv)
+	return w, nil
+}
+
+// remove stops sharing s with new callers.
+func (m *Multiplexer) remove(s *sharedWatch) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.watches[s.key] == s {
+		delete(m.watches, s.key)
+	}
+}
+
+// selectorWatcher sets the selectors of a key on watch requests.
+type selectorWatcher struct {
+	key     WatchKey
+	watcher cache.Watcher
+}
+
+func (w *selectorWatcher) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	options.LabelSelector = w.key.LabelSelector
+	options.FieldSelector = w.key.FieldSelector
+	return w.watcher.Watch(options)
+}
+
+// bufferedEvent is an event in the history of a shared watch.
+type bufferedEvent struct {
+	event watch.Event
+	rv    uint64
+}
+
+// sharedWatch passes the events of a single watch on to all its consumers.
+type sharedWatch struct {
+	multiplexer *Multiplexer
+	key         WatchKey
+	upstream    *RetryWatcher
+	stopOnce    sync.Once
+
+	lock sync.Mutex
+	// rv is the resourceVersion of the last event received.
+	rv uint64
+	// history holds the last events, which are all those after historyRV.
+	history   []bufferedEvent
+	historyRV uint64
+	consumers map[*multiplexedWatcher]struct{}
+	done      bool
+}
+
+// add returns a consumer that gets the events after rv. It returns nil if
+// the watch is done, or if some of these events are no longer in the
+// history.
+func (s *sharedWatch) add(rv uint64) (w *multiplexedWatcher, done bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.done {
+		return nil, true
+	}
+	if rv < s.historyRV {
+		return nil, false
+	}
+	w = newMultiplexedWatcher(s, rv)
+	for _, e := range s.history {
+		w.push(e.event, e.rv)
+	}
+	s.consumers[w] = struct{}{}
+	return w, false
+}
+
+// remove stops passing events to w, and stops the watch if it was the last
+// consumer.
+func (s *sharedWatch) remove(w *multiplexedWatcher) {
+	s.lock.Lock()
+	delete(s.consumers, w)
+	last := len(s.consumers) == 0 && !s.done
+	if last {
+		s.done = true
+	}
+	s.lock.Unlock()
+
+	if last {
+		s.multiplexer.remove(s)
+		s.stop()
+	}
+}
+
+func (s *sharedWatch) stop() {
+	s.stopOnce.Do(s.upstream.Stop)
+}
+
+func (s *sharedWatch) run() {
+	defer s.stop()
+	for event := range s.upstream.ResultChan() {
+		s.dispatch(event)
+	}
+
+	s.lock.Lock()
+	s.done = true
+	consumers := s.consumers
+	s.consumers = map[*multiplexedWatcher]struct{}{}
+	s.lock.Unlock()
+
+	s.multiplexer.remove(s)
+	for w := range consumers {
+		w.close()
+	}
+}
+
+// dispatch records event in the history and passes it to the consumers.
+// Consumers that fell too far behind are removed.
+func (s *sharedWatch) dispatch(event watch.Event) {
+	s.lock.Lock()
+
+	var rv uint64
+	if event.Type != watch.Error {
+		if getter, ok := event.Object.(resourceVersionGetter); ok {
+			rv, _ = strconv.ParseUint(getter.GetResourceVersion(), 10, 64)
+		}
+		if rv > s.rv {
+			s.rv = rv
+		}
+		if s.multiplexer.historySize > 0 {
+			if len(s.history) == s.multiplexer.historySize {
+				s.historyRV = s.history[0].rv
+				s.history = s.history[1:]
+			}
+			s.history = append(s.history, bufferedEvent{event: event, rv: rv})
+		} else {
+			s.historyRV = s.rv
+		}
+	}
+	for w := range s.consumers {
+		if !w.push(event, rv) {
+			klog.V(4).Infof("Closing a consumer of the watch of %v, it is too slow", s.key)
+			delete(s.consumers, w)
+		}
+	}
+	last := len(s.consumers) == 0 && !s.done
+	if last {
+		s.done = true
+	}
+	s.lock.Unlock()
+
+	if last {
+		s.multiplexer.remove(s)
+		s.stop()
+	}
+}
+
+// multiplexedWatcher is the watch.Interface of a single consumer of a shared
+// watch. Events are queued for it, so that a slow consumer doesn't hold up
+// the others.
+type multiplexedWatcher struct {
+	shared   *sharedWatch
+	result   chan watch.Event
+	stopCh   chan struct{}
+	stopOnce sync.Once
+
+	lock sync.Mutex
+	cond *sync.Cond
+	// rv is the resourceVersion of the last event queued for the consumer.
+	rv     uint64
+	queue  []watch.Event
+	closed bool
+}
+
+func newMultiplexedWatcher(shared *sharedWatch, rv uint64) *multiplexedWatcher {
+	w := &multiplexedWatcher{
+		shared: shared,
+		result: make(chan watch.Event),
+		stopCh: make(chan struct{}),
+		rv:     rv,
+	}
+	w.cond = sync.NewCond(&w.lock)
+	go w.run()
+	return w
+}
+
+// push queues event, unless the consumer already got the events up to its
+// resourceVersion rv. Error events have no resourceVersion and are always
+// queued. If the queue is full, the queued events are replaced by an Expired
+// error, the watcher is closed and push returns false.
+func (w *multiplexedWatcher) push(event watch.Event, rv uint64) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.closed {
+		return false
+	}
+	if event.Type != watch.Error {
+		if rv != 0 && rv <= w.rv {
+			return true
+		}
+		if rv > w.rv {
+			w.rv = rv
+		}
+	}
+	if len(w.queue) >= w.shared.multiplexer.maxQueued {
+		w.queue = []watch.Event{{
+			Type:   watch.Error,
+			Object: &apierrors.NewResourceExpired("the watch consumer is too slow, it fell too far behind the shared watch").ErrStatus,
+		}}
+		w.closed = true
+		w.cond.Signal()
+		return false
+	}
+	w.queue = append(w.queue, event)
+	w.cond.Signal()
+	return true
+}
+
+// close closes the result channel once all queued events were received.
+func (w *multiplexedWatcher) close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.closed = true
+	w.cond.Signal()
+}
+
+func (w *multiplexedWatcher) run() {
+	defer close(w.result)
+	for {
+		w.lock.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.lock.Unlock()
+			return
+		}
+		event := w.queue[0]
+		w.queue = w.queue[1:]
+		w.lock.Unlock()
+
+		select {
+		case w.result <- event:
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+// ResultChan implements Interface.
+func (w *multiplexedWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements Interface.
+func (w *multiplexedWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		w.shared.remove(w)
+		w.close()
+	})
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeWatchServer hands out fake watchers and records the options they
+// were created with.
+type fakeWatchServer struct {
+	lock     sync.Mutex
+	options  []metav1.ListOptions
+	watchers []*watch.FakeWatcher
+}
+
+func (s *fakeWatchServer) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	w := watch.NewFakeWithChanSize(10, false)
+	s.options = append(s.options, options)
+	s.watchers = append(s.watchers, w)
+	return w, nil
+}
+
+func (s *fakeWatchServer) waitForWatch(t *testing.T, n int) (*watch.FakeWatcher, metav1.ListOptions) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		s.lock.Lock()
+		if len(s.watchers) >= n {
+			defer s.lock.Unlock()
+			return s.watchers[n-1], s.options[n-1]
+		}
+		s.lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d watches", n)
+	return nil, metav1.ListOptions{}
+}
+
+func expectEvents(t *testing.T, w watch.Interface, rvs ...string) {
+	t.Helper()
+	for _, rv := range rvs {
+		select {
+		case event := <-w.ResultChan():
+			if e, a := rv, event.Object.(testObject).GetResourceVersion(); e != a {
+				t.Fatalf("expected event with RV %v, got %v", e, a)
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("timed out waiting for event with RV %v", rv)
+		}
+	}
+}
+
+func TestMultiplexer(t *testing.T) {
+	server := &fakeWatchServer{}
+	m := newMultiplexer(2, time.Millisecond, defaultMaxLag)
+	key := WatchKey{
+		Resource:      schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Namespace:     "default",
+		LabelSelector: "app=foo",
+	}
+
+	first, err := m.Watch(key, server, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := m.Watch(key, server, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	upstream, options := server.waitForWatch(t, 1)
+	if e, a := "app=foo", options.LabelSelector; e != a {
+		t.Errorf("expected label selector %v, got %v", e, a)
+	}
+
+	upstream.Add(testObject{resourceVersion: "2"})
+	upstream.Add(testObject{resourceVersion: "3"})
+	expectEvents(t, first, "2", "3")
+	expectEvents(t, second, "2", "3")
+
+	// A late caller gets the events it missed from the history.
+	third, err := m.Watch(key, server, "2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectEvents(t, third, "3")
+
+	// The shared watch is resumed when it is closed.
+	upstream.Stop()
+	upstream, options = server.waitForWatch(t, 2)
+	if e, a := "3", options.ResourceVersion; e != a {
+		t.Errorf("expected the watch to resume from RV %v, got %v", e, a)
+	}
+	upstream.Add(testObject{resourceVersion: "4"})
+	expectEvents(t, first, "4")
+	expectEvents(t, second, "4")
+	expectEvents(t, third, "4")
+
+	// A caller whose RV is no longer in the history gets its own watch.
+	own, err := m.Watch(key, server, "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.waitForWatch(t, 3)
+	own.Stop()
+
+	first.Stop()
+	second.Stop()
+	if upstream.IsStopped() {
+		t.Errorf("expected the shared watch to keep running while it has consumers")
+	}
+	third.Stop()
+	for i := 0; i < 100 && !upstream.IsStopped(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !upstream.IsStopped() {
+		t.Errorf("expected the shared watch to be stopped with its last consumer")
+	}
+}
+
+func TestMultiplexerDifferentKeys(t *testing.T) {
+	server := &fakeWatchServer{}
+	m := newMultiplexer(10, time.Millisecond, defaultMaxLag)
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	a, _ := m.Watch(WatchKey{Resource: pods, Namespace: "a"}, server, "1")
+	b, _ := m.Watch(WatchKey{Resource: pods, Namespace: "b"}, server, "1")
+	defer a.Stop()
+	defer b.Stop()
+	server.waitForWatch(t, 2)
+
+	if _, err := m.Watch(WatchKey{Resource: pods}, server, "0"); err == nil {
+		t.Errorf("expected an error for RV 0")
+	}
+}
+
+var _ cache.Watcher = &fakeWatchServer{}
+
+func TestMultiplexerSlowConsumer(t *testing.T) {
+	server := &fakeWatchServer{}
+	m := newMultiplexer(0, time.Millisecond, 3)
+	key := WatchKey{Resource: schema.GroupVersionResource{Version: "v1", Resource: "pods"}}
+
+	fast, _ := m.Watch(key, server, "1")
+	slow, _ := m.Watch(key, server, "1")
+	upstream, _ := server.waitForWatch(t, 1)
+	for rv := 2; rv < 12; rv++ {
+		upstream.Add(testObject{resourceVersion: strconv.Itoa(rv)})
+		expectEvents(t, fast, strconv.Itoa(rv))
+	}
+
+	// The slow consumer gets the events it had room for, then an Expired
+	// error, and its watch is closed.
+	var events []watch.Event
+	for event := range slow.ResultChan() {
+		events = append(events, event)
+	}
+	if len(events) == 0 || len(events) > 5 {
+		t.Fatalf("expected at most 4 events and an error, got %v", events)
+	}
+	last := events[len(events)-1]
+	if last.Type != watch.Error || !apierrors.IsResourceExpired(apierrors.FromObject(last.Object)) {
+		t.Errorf("expected an Expired error, got %v", last)
+	}
+
+	// The fast consumer keeps the shared watch.
+	if upstream.IsStopped() {
+		t.Errorf("expected the shared watch to keep running for the fast consumer")
+	}
+	upstream.Add(testObject{resourceVersion: "12"})
+	expectEvents(t, fast, "12")
+	slow.Stop()
+	fast.Stop()
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -50,6 +51,10 @@ type tlsCacheKey struct {
 	serverName         string
 	nextProtos         string
 	disableCompression bool
+	// credentialProvider is the provider of the client certificate. Its
+	// transport rotates the certificate in the background, so it is shared
+	// by all configs with the same provider.
+	credentialProvider CredentialProvider
 }
 
 func (t tlsCacheKey) String() string {
@@ -95,9 +100,10 @@ func (c *tlsTransportCache) get(config *Config) (http.RoundTripper, error) {
 		}).DialContext
 	}
 
-	// If we use are reloading files, we need to handle certificate rotation properly
+	// If we use are reloading files, we need to handle certificate rotation properly.
+	// The same goes for certificates from a credential provider.
 	// TODO(jackkleeman): We can also add rotation here when config.HasCertCallback() is true
-	if config.TLS.ReloadTLSFiles {
+	if config.TLS.ReloadTLSFiles || config.HasCredentialProvider() {
 		dynamicCertDialer := certRotatingDialer(tlsConfig.GetClientCertificate, dial)
 		tlsConfig.GetClientCertificate = dynamicCertDialer.GetClientCertificate
 		dial = dynamicCertDialer.connDialer.DialContext
@@ -138,12 +144,18 @@ func tlsConfigKey(c *Config) (tlsCacheKey, bool, error) {
 		return tlsCacheKey{}, false, nil
 	}
 
+	provider, ok := credentialProviderKey(c.CredentialProvider)
+	if !ok {
+		return tlsCacheKey{}, false, nil
+	}
+
 	k := tlsCacheKey{
 		insecure:           c.TLS.Insecure,
 		caData:             string(c.TLS.CAData),
 		serverName:         c.TLS.ServerName,
 		nextProtos:         strings.Join(c.TLS.NextProtos, ","),
 		disableCompression: c.DisableCompression,
+		credentialProvider: provider,
 	}
 
 	if c.TLS.ReloadTLSFiles {
@@ -156,3 +168,17 @@ func tlsConfigKey(c *Config) (tlsCacheKey, bool, error) {
 
 	return k, true, nil
 }
+
+// credentialProviderKey returns what identifies provider in a cache key.
+// Caching providers are identified by the provider they wrap, since a new one
+// is created for every rest.Config that is turned into a transport. Providers
+// of types that can't be compared, e.g. slices or funcs, can't be cached.
+func credentialProviderKey(provider CredentialProvider) (CredentialProvider, bool) {
+	if caching, ok := provider.(*cachingCredentialProvider); ok {
+		provider = caching.provider
+	}
+	if provider != nil && !reflect.TypeOf(provider).Comparable() {
+		return nil, false
+	}
+	return provider, true
+}
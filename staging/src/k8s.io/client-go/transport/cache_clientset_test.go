@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+type noCredentialsProvider struct{}
+
+func (*noCredentialsProvider) Credentials(context.Context) (*transport.Credentials, error) {
+	return nil, nil
+}
+
+func TestCredentialProviderTransportIsShared(t *testing.T) {
+	config := &rest.Config{
+		Host:               "https://127.0.0.1:6443",
+		CredentialProvider: &noCredentialsProvider{},
+	}
+	if _, err := kubernetes.NewForConfig(config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Every client of a clientset gets a transport, which used to start a
+	// certificate rotation goroutine of its own.
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		if _, err := kubernetes.NewForConfig(rest.CopyConfig(config)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before+5 {
+		t.Errorf("expected the clientsets to share the transport, the goroutines grew from %d to %d", before, after)
+	}
+}
@@ -64,6 +64,7 @@ func TestTLSConfigKey(t *testing.T) {
 	// Make sure config fields that affect the tls config affect the cache key
 	dialer := net.Dialer{}
 	getCert := func() (*tls.Certificate, error) { return nil, nil }
+	provider1 := NewFileCertificateProvider("cert", "key")
 	uniqueConfigurations := map[string]*Config{
 		"no tls":   {},
 		"dialer":   {Dial: dialer.DialContext},
@@ -136,6 +137,9 @@ func TestTLSConfigKey(t *testing.T) {
 		},
 		"http2, http1.1": {TLS: TLSConfig{NextProtos: []string{"h2", "http/1.1"}}},
 		"http1.1-only":   {TLS: TLSConfig{NextProtos: []string{"http/1.1"}}},
+		"provider 1":     {CredentialProvider: provider1},
+		"provider 2":     {CredentialProvider: NewFileCertificateProvider("cert", "key")},
+		"chain":          {CredentialProvider: NewChainCredentialProvider(provider1)},
 	}
 	for nameA, valueA := range uniqueConfigurations {
 		for nameB, valueB := range uniqueConfigurations {
@@ -170,3 +174,31 @@ func TestTLSConfigKey(t *testing.T) {
 		}
 	}
 }
+
+func TestTLSConfigKeyCachingCredentialProvider(t *testing.T) {
+	provider := NewFileCertificateProvider("cert", "key")
+	keyA, canCacheA, err := tlsConfigKey(&Config{CredentialProvider: NewCachingCredentialProvider(provider, 0)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyB, canCacheB, err := tlsConfigKey(&Config{CredentialProvider: NewCachingCredentialProvider(provider, 0)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !canCacheA || !canCacheB {
+		t.Fatalf("expected configs with a credential provider to be cacheable")
+	}
+	if keyA != keyB {
+		t.Errorf("expected caching providers of the same provider to have identical cache keys, got:\n\t%s\n\t%s", keyA, keyB)
+	}
+
+	if _, canCache, _ := tlsConfigKey(&Config{CredentialProvider: NewCachingCredentialProvider(uncomparableProvider{}, 0)}); canCache {
+		t.Errorf("expected a provider of an uncomparable type not to be cacheable")
+	}
+}
+
+type uncomparableProvider []CredentialProvider
+
+func (uncomparableProvider) Credentials(context.Context) (*Credentials, error) {
+	return nil, nil
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultCircuitBreakerFailureThreshold = 5
+	defaultCircuitBreakerOpenDuration     = 30 * time.Second
+)
+
+// The states of a circuit, as reported to metrics.
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+// CircuitBreakerConfig configures a round tripper that stops sending requests
+// to a server that keeps failing them. Requests are tracked per host and
+// verb. A request fails if the server responds with 429 Too Many Requests or
+// a 5xx status, or if the round trip returns an error that isn't caused by the
+// caller cancelling the request. This includes timeouts.
+//
+// After FailureThreshold consecutive failures, the circuit opens and requests
+// are rejected with a *CircuitOpenError without being sent. Once OpenDuration
+// has passed, the circuit is half-open: a single request is sent as a probe,
+// while others are still rejected. The circuit closes if the probe succeeds
+// and opens again if it fails.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that open the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before a probe is
+	// sent. Defaults to 30 seconds.
+	OpenDuration time.Duration
+}
+
+// CircuitOpenError is returned for requests that are rejected because the
+// circuit for their host and verb is open.
+type CircuitOpenError struct {
+	Host string
+	Verb string
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open for %s requests to %s", e.Verb, e.Host)
+}
+
+type circuitKey struct {
+	host string
+	verb string
+}
+
+type circuit struct {
+	state    string
+	failures int
+	openedAt time.Time
+	// probing is true while the probe of a half-open circuit is in flight.
+	probing bool
+}
+
+type circuitBreakerRoundTripper struct {
+	failureThreshold int
+	openDuration     time.Duration
+	clock            clock.Clock
+	rt               http.RoundTripper
+
+	lock     sync.Mutex
+	circuits map[circuitKey]*circuit
+}
+
+// NewCircuitBreakerRoundTripper returns a round tripper that rejects requests
+// to hosts that keep failing them, as described by config.
+func NewCircuitBreakerRoundTripper(config CircuitBreakerConfig, rt http.RoundTripper) http.RoundTripper {
+	return newCircuitBreakerRoundTripper(config, clock.RealClock{}, rt)
+}
+
+func newCircuitBreakerRoundTripper(config CircuitBreakerConfig, clock clock.Clock, rt http.RoundTripper) *circuitBreakerRoundTripper {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaultCircuitBreakerFailureThreshold
+	}
+	if config.OpenDuration <= 0 {
+		config.OpenDuration = defaultCircuitBreakerOpenDuration
+	}
+	return &circuitBreakerRoundTripper{
+		failureThreshold: config.FailureThreshold,
+		openDuration:     config.OpenDuration,
+		clock:            clock,
+		rt:               rt,
+		circuits:         map[circuitKey]*circuit{},
+	}
+}
+
+func (rt *circuitBreakerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := circuitKey{host: req.URL.Host, verb: req.Method}
+	probe, allowed := rt.allow(key)
+	if !allowed {
+		metrics.CircuitBreakerRejected.Increment(key.host, key.verb)
+		return nil, &CircuitOpenError{Host: key.host, Verb: key.verb}
+	}
+
+	resp, err := rt.rt.RoundTrip(req)
+	if err != nil && req.Context().Err() == context.Canceled {
+		rt.release(key, probe)
+	} else {
+		rt.record(key, probe, err != nil || isCircuitFailure(resp.StatusCode))
+	}
+	return resp, err
+}
+
+// isCircuitFailure returns true for the status codes of responses that
+// indicate the server is in trouble.
+func isCircuitFailure(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// allow returns whether a request may be sent, and if so whether it is the
+// probe of a half-open circuit.
+func (rt *circuitBreakerRoundTripper) allow(key circuitKey) (probe bool, allowed bool) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	c, ok := rt.circuits[key]
+	if !ok {
+		c = &circuit{state: circuitClosed}
+		rt.circuits[key] = c
+	}
+	switch c.state {
+	case circuitClosed:
+		return false, true
+	case circuitOpen:
+		if rt.clock.Since(c.openedAt) < rt.openDuration {
+			return false, false
+		}
+		rt.setState(key, c, circuitHalfOpen)
+	}
+	if c.probing {
+		return false, false
+	}
+	c.probing = true
+	return true, true
+}
+
+// release forgets about a request whose outcome says nothing about the server.
+func (rt *circuitBreakerRoundTripper) release(key circuitKey, probe bool) {
+	if !probe {
+		return
+	}
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	rt.circuits[key].probing = false
+}
+
+// record updates the circuit with the outcome of a request.
+func (rt *circuitBreakerRoundTripper) record(key circuitKey, probe bool, failed bool) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	c := rt.circuits[key]
+	if probe {
+		c.probing = false
+		if failed {
+			c.openedAt = rt.clock.Now()
+			rt.setState(key, c, circuitOpen)
+		} else {
+			c.failures = 0
+			rt.setState(key, c, circuitClosed)
+		}
+		return
+	}
+	// Requests sent before the circuit opened say nothing about whether it
+	// should close again.
+	if c.state != circuitClosed {
+		return
+	}
+	if !failed {
+		c.failures = 0
+		return
+	}
+	c.failures++
+	if c.failures >= rt.failureThreshold {
+		c.openedAt = rt.clock.Now()
+		rt.setState(key, c, circuitOpen)
+	}
+}
+
+// setState moves c to state. The caller must hold the lock.
+func (rt *circuitBreakerRoundTripper) setState(key circuitKey, c *circuit, state string) {
+	if c.state == state {
+		return
+	}
+	klog.V(2).Infof("Circuit breaker for %s requests to %s changed from %s to %s", key.verb, key.host, c.state, state)
+	c.state = state
+	metrics.CircuitBreakerState.Set(key.host, key.verb, state)
+}
+
+func (rt *circuitBreakerRoundTripper) CancelRequest(req *http.Request) {
+	tryCancelRequest(rt.WrappedRoundTripper(), req)
+}
+
+func (rt *circuitBreakerRoundTripper) WrappedRoundTripper() http.RoundTripper { return rt.rt }
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+type statusRoundTripper struct {
+	status int
+	err    error
+	count  int
+}
+
+func (rt *statusRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.count++
+	if rt.err != nil {
+		return nil, rt.err
+	}
+	return &http.Response{StatusCode: rt.status, Request: req}, nil
+}
+
+func TestCircuitBreakerRoundTripper(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	delegate := &statusRoundTripper{status: http.StatusServiceUnavailable}
+	rt := newCircuitBreakerRoundTripper(CircuitBreakerConfig{FailureThreshold: 3, OpenDuration: time.Minute}, fakeClock, delegate)
+
+	get, _ := http.NewRequest("GET", "https://server-1/api", nil)
+	post, _ := http.NewRequest("POST", "https://server-1/api", nil)
+	otherHost, _ := http.NewRequest("GET", "https://server-2/api", nil)
+
+	expectSent := func(req *http.Request, sent bool) {
+		t.Helper()
+		before := delegate.count
+		_, err := rt.RoundTrip(req)
+		var openErr *CircuitOpenError
+		rejected := errors.As(err, &openErr)
+		if rejected == sent {
+			t.Fatalf("expected sent %v for %s %s, got error %v", sent, req.Method, req.URL.Host, err)
+		}
+		if e, a := sent, delegate.count > before; e != a {
+			t.Fatalf("expected delegate called %v, got %v", e, a)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		expectSent(get, true)
+	}
+	// The circuit for GET requests to server-1 is open, others are not.
+	expectSent(get, false)
+	expectSent(post, true)
+	expectSent(otherHost, true)
+
+	// Only a single probe is sent once the circuit is half-open, and the
+	// circuit opens again when it fails.
+	fakeClock.Step(time.Minute)
+	expectSent(get, true)
+	expectSent(get, false)
+
+	fakeClock.Step(time.Minute)
+	delegate.status = http.StatusOK
+	expectSent(get, true)
+	expectSent(get, true)
+	expectSent(get, true)
+}
+
+func TestCircuitBreakerRoundTripperIgnoresCancellation(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	delegate := &statusRoundTripper{err: context.Canceled}
+	rt := newCircuitBreakerRoundTripper(CircuitBreakerConfig{FailureThreshold: 1}, fakeClock, delegate)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequest("GET", "https://server-1/api", nil)
+	for i := 0; i < 3; i++ {
+		if _, err := rt.RoundTrip(req.WithContext(ctx)); err != context.Canceled {
+			t.Fatalf("expected %v, got %v", context.Canceled, err)
+		}
+	}
+
+	delegate.err = errors.New("timeout")
+	rt.RoundTrip(req)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatalf("expected the circuit to be open")
+	}
+	if delegate.count != 4 {
+		t.Errorf("expected 4 requests to be sent, got %d", delegate.count)
+	}
+}
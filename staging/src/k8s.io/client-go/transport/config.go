@@ -76,6 +76,19 @@ type Config struct {
 	//
 	// socks5 proxying does not currently support spdy streaming endpoints.
 	Proxy func(*http.Request) (*url.URL, error)
+
+	// CircuitBreaker, if set, makes the transport reject requests to hosts
+	// that keep failing them.
+	CircuitBreaker *CircuitBreakerConfig
+
+	// CredentialProvider, if set, supplies the bearer token and client
+	// certificate of requests that aren't otherwise authenticated. It is
+	// asked for every request and TLS handshake; use
+	// NewCachingCredentialProvider to avoid fetching the credentials each
+	// time. It may not be combined with basic or bearer token authentication.
+	// Transports are cached per provider, which must be of a comparable
+	// type, e.g. a pointer, for its transport to be reused.
+	CredentialProvider CredentialProvider
 }
 
 // ImpersonationConfig has all the available impersonation options
@@ -113,6 +126,11 @@ func (c *Config) HasCertCallback() bool {
 	return c.TLS.GetCert != nil
 }
 
+// HasCredentialProvider returns whether the configuration has a credential provider or not.
+func (c *Config) HasCredentialProvider() bool {
+	return c.CredentialProvider != nil
+}
+
 // Wrap adds a transport middleware function that will give the caller
 // an opportunity to wrap the underlying http.RoundTripper prior to the
 // first API call being made. The provided function is invoked after any
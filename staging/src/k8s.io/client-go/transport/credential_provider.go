@@ -0,0 +1,380 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultRefreshBefore is how long before they expire credentials are
+	// refreshed by a caching credential provider, unless told otherwise.
+	defaultRefreshBefore = time.Minute
+
+	// minRefreshInterval is the least time between two background refreshes
+	// of credentials, so that a failing provider isn't called for every
+	// request.
+	minRefreshInterval = time.Second
+)
+
+// Credentials authenticate a client to the server, with a bearer token, a
+// client certificate, or both.
+type Credentials struct {
+	// Token is sent as bearer token if it isn't empty.
+	Token string
+	// Certificate is presented in TLS handshakes if it isn't nil.
+	Certificate *tls.Certificate
+	// Expiry is when the credentials stop being valid. Credentials with a
+	// zero Expiry never expire.
+	Expiry time.Time
+}
+
+// expiredAt returns true if the credentials are no longer valid at now.
+func (c *Credentials) expiredAt(now time.Time) bool {
+	return !c.Expiry.IsZero() && !now.Before(c.Expiry)
+}
+
+// CredentialProvider supplies the credentials of a client.
+type CredentialProvider interface {
+	// Credentials returns the current credentials. It returns nil without
+	// an error if the provider has no credentials to offer, e.g. because
+	// the file it reads them from doesn't exist. The returned credentials
+	// must not be modified.
+	Credentials(ctx context.Context) (*Credentials, error)
+}
+
+// NewChainCredentialProvider returns a provider that asks the given providers
+// in order, and returns the credentials of the first one that has some. It
+// only fails if none of them has credentials and at least one failed.
+func NewChainCredentialProvider(providers ...CredentialProvider) CredentialProvider {
+	return &chainCredentialProvider{providers: providers}
+}
+
+type chainCredentialProvider struct {
+	providers []CredentialProvider
+}
+
+func (c *chainCredentialProvider) Credentials(ctx context.Context) (*Credentials, error) {
+	var errs []error
+	for _, provider := range c.providers {
+		creds, err := provider.Credentials(ctx)
+		if err != nil {
+			klog.V(4).Infof("Credential provider %T failed: %v", provider, err)
+			errs = append(errs, err)
+			continue
+		}
+		if creds != nil {
+			return creds, nil
+		}
+	}
+	return nil, utilerrors.NewAggregate(errs)
+}
+
+// NewCachingCredentialProvider returns a provider that caches the credentials
+// of provider until they expire. Credentials that expire within refreshBefore
+// are still returned, but refreshed in the background so that requests don't
+// wait for them to be renewed. refreshBefore defaults to a minute. If a
+// refresh fails, the cached credentials are used until they expire.
+//
+// Providers returned by NewCachingCredentialProvider are returned unchanged.
+func NewCachingCredentialProvider(provider CredentialProvider, refreshBefore time.Duration) CredentialProvider {
+	if caching, ok := provider.(*cachingCredentialProvider); ok {
+		return caching
+	}
+	return newCachingCredentialProvider(provider, refreshBefore, clock.RealClock{})
+}
+
+func newCachingCredentialProvider(provider CredentialProvider, refreshBefore time.Duration, c clock.Clock) *cachingCredentialProvider {
+	if refreshBefore <= 0 {
+		refreshBefore = defaultRefreshBefore
+	}
+	return &cachingCredentialProvider{
+		provider:      provider,
+		refreshBefore: refreshBefore,
+		clock:         c,
+	}
+}
+
+type cachingCredentialProvider struct {
+	provider      CredentialProvider
+	refreshBefore time.Duration
+	clock         clock.Clock
+
+	// loadLock serializes calls to provider.
+	loadLock sync.Mutex
+
+	lock        sync.Mutex
+	creds       *Credentials
+	refreshing  bool
+	nextRefresh time.Time
+}
+
+func (c *cachingCredentialProvider) Credentials(ctx context.Context) (*Credentials, error) {
+	c.lock.Lock()
+	now := c.clock.Now()
+	creds := c.creds
+	if creds != nil && !creds.expiredAt(now) {
+		if c.needsRefreshLocked(now) {
+			c.refreshing = true
+			go c.refresh()
+		}
+		c.lock.Unlock()
+		return creds, nil
+	}
+	c.lock.Unlock()
+
+	return c.load(ctx)
+}
+
+// needsRefreshLocked returns true if the cached credentials are about to
+// expire and no refresh is in progress. The caller must hold the lock.
+func (c *cachingCredentialProvider) needsRefreshLocked(now time.Time) bool {
+	if c.refreshing || c.creds.Expiry.IsZero() || now.Before(c.nextRefresh) {
+		return false
+	}
+	return !now.Before(c.creds.Expiry.Add(-c.refreshBefore))
+}
+
+// load gets credentials from the provider, unless another caller just did
+// while this one was waiting.
+func (c *cachingCredentialProvider) load(ctx context.Context) (*Credentials, error) {
+	c.loadLock.Lock()
+	defer c.loadLock.Unlock()
+
+	c.lock.Lock()
+	creds := c.creds
+	c.lock.Unlock()
+	if creds != nil && !creds.expiredAt(c.clock.Now()) {
+		return creds, nil
+	}
+
+	creds, err := c.provider.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		c.lock.Lock()
+		c.creds = creds
+		c.lock.Unlock()
+	}
+	return creds, nil
+}
+
+// refresh replaces the cached credentials with new ones from the provider.
+func (c *cachingCredentialProvider) refresh() {
+	defer utilruntime.HandleCrash()
+
+	c.loadLock.Lock()
+	defer c.loadLock.Unlock()
+
+	creds, err := c.provider.Credentials(context.Background())
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.refreshing = false
+	switch {
+	case err != nil:
+		c.nextRefresh = c.clock.Now().Add(minRefreshInterval)
+		utilruntime.HandleError(fmt.Errorf("unable to refresh credentials that expire at %v: %v", c.creds.Expiry, err))
+	case creds != nil:
+		c.creds = creds
+		// Providers that have nothing newer to offer are asked again a
+		// bit later rather than on every request.
+		if !creds.Expiry.After(c.clock.Now().Add(c.refreshBefore)) {
+			c.nextRefresh = c.clock.Now().Add(minRefreshInterval)
+		}
+	}
+}
+
+// NewTokenSourceCredentialProvider returns a provider of the tokens of ts,
+// e.g. one created by NewCachedFileTokenSource.
+func NewTokenSourceCredentialProvider(ts oauth2.TokenSource) CredentialProvider {
+	return &tokenSourceCredentialProvider{ts: ts}
+}
+
+type tokenSourceCredentialProvider struct {
+	ts oauth2.TokenSource
+}
+
+func (p *tokenSourceCredentialProvider) Credentials(context.Context) (*Credentials, error) {
+	token, err := p.ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	if len(token.AccessToken) == 0 {
+		return nil, nil
+	}
+	return &Credentials{Token: token.AccessToken, Expiry: token.Expiry}, nil
+}
+
+// NewFileCertificateProvider returns a provider of the client certificate in
+// the PEM-encoded certFile and keyFile, which expires when the certificate
+// does. The files are read again when they change on disk, which is checked at
+// most once a second. While the files are rewritten, the certificate that was
+// read last is used until it expires. The provider has no credentials if
+// certFile doesn't exist.
+//
+// Connections that were set up with the old certificate are closed when the
+// transport sees a new one, as with certificate files in the TLS config.
+func NewFileCertificateProvider(certFile, keyFile string) CredentialProvider {
+	return &fileCertificateProvider{
+		certFile: certFile,
+		keyFile:  keyFile,
+		clock:    clock.RealClock{},
+	}
+}
+
+type fileCertificateProvider struct {
+	certFile string
+	keyFile  string
+	clock    clock.Clock
+
+	lock      sync.Mutex
+	checked   time.Time
+	certStat  fileStat
+	keyStat   fileStat
+	creds     *Credentials
+	lastError error
+}
+
+// fileStat is what is compared to find out whether a file changed.
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(path string) (fileStat, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStat{}, err
+	}
+	return fileStat{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+func (p *fileCertificateProvider) Credentials(context.Context) (*Credentials, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.clock.Now()
+	if !p.checked.IsZero() && now.Sub(p.checked) < time.Second {
+		return p.creds, p.lastError
+	}
+	p.checked = now
+
+	certStat, err := statFile(p.certFile)
+	if os.IsNotExist(err) {
+		p.creds, p.lastError = nil, nil
+		return nil, nil
+	}
+	if err != nil {
+		return p.keepCurrentLocked(now, err)
+	}
+	keyStat, err := statFile(p.keyFile)
+	if err != nil {
+		return p.keepCurrentLocked(now, err)
+	}
+	if p.creds != nil && certStat == p.certStat && keyStat == p.keyStat {
+		return p.creds, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(p.certFile, p.keyFile)
+	if err != nil {
+		return p.keepCurrentLocked(now, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return p.keepCurrentLocked(now, err)
+	}
+	cert.Leaf = leaf
+	if p.creds != nil {
+		klog.V(1).Infof("Loaded rotated client certificate from %s, valid until %v", p.certFile, leaf.NotAfter)
+	}
+	p.certStat, p.keyStat = certStat, keyStat
+	p.creds, p.lastError = &Credentials{Certificate: &cert, Expiry: leaf.NotAfter}, nil
+	return p.creds, nil
+}
+
+// keepCurrentLocked returns the certificate that was read last if it is still
+// valid, since failing to read the files is expected while they are being
+// rotated. The caller must hold the lock.
+func (p *fileCertificateProvider) keepCurrentLocked(now time.Time, err error) (*Credentials, error) {
+	if p.creds != nil && !p.creds.expiredAt(now) {
+		klog.V(4).Infof("Using the last client certificate read from %s: %v", p.certFile, err)
+		return p.creds, nil
+	}
+	p.creds, p.lastError = nil, fmt.Errorf("unable to load client certificate from %s and %s: %v", p.certFile, p.keyFile, err)
+	return nil, p.lastError
+}
+
+// clientCertificate returns the certificate of provider, or nil if it has
+// none.
+func clientCertificate(provider CredentialProvider) (*tls.Certificate, error) {
+	creds, err := provider.Credentials(context.Background())
+	if err != nil || creds == nil {
+		return nil, err
+	}
+	return creds.Certificate, nil
+}
+
+type credentialProviderRoundTripper struct {
+	provider CredentialProvider
+	rt       http.RoundTripper
+}
+
+// NewCredentialProviderRoundTripper sets the bearer token of the credentials
+// of provider on requests that don't have an Authorization header yet.
+// Client certificates are passed to the server by the TLS config of a
+// transport, see Config.CredentialProvider.
+func NewCredentialProviderRoundTripper(provider CredentialProvider, rt http.RoundTripper) http.RoundTripper {
+	return &credentialProviderRoundTripper{provider: provider, rt: rt}
+}
+
+func (rt *credentialProviderRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(req.Header.Get("Authorization")) != 0 {
+		return rt.rt.RoundTrip(req)
+	}
+	creds, err := rt.provider.Credentials(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get credentials: %v", err)
+	}
+	if creds == nil || len(creds.Token) == 0 {
+		return rt.rt.RoundTrip(req)
+	}
+	req = utilnet.CloneRequest(req)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", creds.Token))
+	return rt.rt.RoundTrip(req)
+}
+
+func (rt *credentialProviderRoundTripper) CancelRequest(req *http.Request) {
+	tryCancelRequest(rt.WrappedRoundTripper(), req)
+}
+
+func (rt *credentialProviderRoundTripper) WrappedRoundTripper() http.RoundTripper { return rt.rt }
@@ -0,0 +1,264 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/cert"
+)
+
+type fakeCredentialProvider struct {
+	lock  sync.Mutex
+	creds *Credentials
+	err   error
+	calls int
+}
+
+func (p *fakeCredentialProvider) Credentials(context.Context) (*Credentials, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.calls++
+	return p.creds, p.err
+}
+
+func (p *fakeCredentialProvider) set(creds *Credentials, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.creds, p.err = creds, err
+}
+
+func (p *fakeCredentialProvider) callCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.calls
+}
+
+func TestChainCredentialProvider(t *testing.T) {
+	none := &fakeCredentialProvider{}
+	failing := &fakeCredentialProvider{err: errors.New("no exec plugin")}
+	token := &fakeCredentialProvider{creds: &Credentials{Token: "token"}}
+	unused := &fakeCredentialProvider{creds: &Credentials{Token: "other"}}
+
+	creds, err := NewChainCredentialProvider(none, failing, token, unused).Credentials(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Token != "token" {
+		t.Errorf("expected the credentials of the first provider that has some, got %#v", creds)
+	}
+	if unused.calls != 0 {
+		t.Errorf("expected the chain to stop at the first provider with credentials")
+	}
+
+	creds, err = NewChainCredentialProvider(none, failing).Credentials(context.Background())
+	if err == nil || creds != nil {
+		t.Errorf("expected the error of the failing provider, got %#v, %v", creds, err)
+	}
+
+	creds, err = NewChainCredentialProvider(none).Credentials(context.Background())
+	if err != nil || creds != nil {
+		t.Errorf("expected no credentials, got %#v, %v", creds, err)
+	}
+}
+
+func TestCachingCredentialProvider(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	delegate := &fakeCredentialProvider{creds: &Credentials{Token: "1", Expiry: fakeClock.Now().Add(time.Hour)}}
+	provider := newCachingCredentialProvider(delegate, 10*time.Minute, fakeClock)
+
+	expectToken := func(token string) {
+		t.Helper()
+		creds, err := provider.Credentials(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if creds.Token != token {
+			t.Fatalf("expected token %q, got %q", token, creds.Token)
+		}
+	}
+
+	expectToken("1")
+	expectToken("1")
+	if calls := delegate.callCount(); calls != 1 {
+		t.Fatalf("expected the credentials to be cached, got %d calls", calls)
+	}
+
+	// Credentials that are about to expire are still used while they are
+	// refreshed in the background.
+	fakeClock.Step(55 * time.Minute)
+	delegate.set(&Credentials{Token: "2", Expiry: fakeClock.Now().Add(2 * time.Hour)}, nil)
+	expectToken("1")
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		creds, err := provider.Credentials(context.Background())
+		return err == nil && creds.Token == "2", err
+	}); err != nil {
+		t.Fatalf("expected the credentials to be refreshed: %v", err)
+	}
+
+	// The cached credentials are used until they expire when refreshing
+	// them fails, and loading fails once they did.
+	delegate.set(nil, errors.New("token endpoint unavailable"))
+	fakeClock.Step(115 * time.Minute)
+	expectToken("2")
+	if err := wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return delegate.callCount() == 3, nil
+	}); err != nil {
+		t.Fatalf("expected the credentials to be refreshed: %v", err)
+	}
+	expectToken("2")
+	fakeClock.Step(5 * time.Minute)
+	if _, err := provider.Credentials(context.Background()); err == nil {
+		t.Fatalf("expected an error once the credentials expired")
+	}
+
+	delegate.set(&Credentials{Token: "3"}, nil)
+	expectToken("3")
+}
+
+func TestNewCachingCredentialProviderIsIdempotent(t *testing.T) {
+	provider := NewCachingCredentialProvider(&fakeCredentialProvider{}, 0)
+	if NewCachingCredentialProvider(provider, 0) != provider {
+		t.Errorf("expected a caching provider not to be wrapped again")
+	}
+}
+
+func TestFileCertificateProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credential-provider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+
+	fakeClock := clock.NewFakeClock(time.Now())
+	provider := NewFileCertificateProvider(certFile, keyFile).(*fileCertificateProvider)
+	provider.clock = fakeClock
+
+	creds, err := provider.Credentials(context.Background())
+	if err != nil || creds != nil {
+		t.Fatalf("expected no credentials without files, got %#v, %v", creds, err)
+	}
+
+	writeCert := func(host string) {
+		t.Helper()
+		certPEM, keyPEM, err := cert.GenerateSelfSignedCertKey(host, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectHost := func(host string) {
+		t.Helper()
+		fakeClock.Step(2 * time.Second)
+		creds, err := provider.Credentials(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		leaf := creds.Certificate.Leaf
+		// Self-signed certificates are issued to host@timestamp.
+		if !strings.HasPrefix(leaf.Subject.CommonName, host+"@") {
+			t.Fatalf("expected the certificate of %q, got %q", host, leaf.Subject.CommonName)
+		}
+		if !creds.Expiry.Equal(leaf.NotAfter) {
+			t.Errorf("expected the credentials to expire with the certificate at %v, got %v", leaf.NotAfter, creds.Expiry)
+		}
+	}
+
+	writeCert("client-1")
+	expectHost("client-1")
+	writeCert("client-2")
+	expectHost("client-2")
+
+	// A key that doesn't match yet, as while the files are rotated, keeps
+	// the last certificate in use.
+	if err := ioutil.WriteFile(keyFile, []byte(keyData), 0600); err != nil {
+		t.Fatal(err)
+	}
+	expectHost("client-2")
+}
+
+func TestCredentialProviderRoundTripper(t *testing.T) {
+	rt := &testRoundTripper{}
+	provider := &fakeCredentialProvider{creds: &Credentials{Token: "token"}}
+	req := &http.Request{Header: http.Header{}}
+
+	NewCredentialProviderRoundTripper(provider, rt).RoundTrip(req)
+	if e, a := "Bearer token", rt.Request.Header.Get("Authorization"); e != a {
+		t.Errorf("expected Authorization %q, got %q", e, a)
+	}
+	if len(req.Header.Get("Authorization")) != 0 {
+		t.Errorf("expected the original request not to be modified")
+	}
+
+	req.Header.Set("Authorization", "Bearer other")
+	NewCredentialProviderRoundTripper(provider, rt).RoundTrip(req)
+	if e, a := "Bearer other", rt.Request.Header.Get("Authorization"); e != a {
+		t.Errorf("expected Authorization %q, got %q", e, a)
+	}
+
+	provider.set(nil, errors.New("unavailable"))
+	if _, err := NewCredentialProviderRoundTripper(provider, rt).RoundTrip(&http.Request{Header: http.Header{}}); err == nil {
+		t.Errorf("expected the error of the provider")
+	}
+}
+
+func TestTLSConfigForCredentialProvider(t *testing.T) {
+	clientCert, err := tls.X509KeyPair([]byte(certData), []byte(keyData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &fakeCredentialProvider{creds: &Credentials{Certificate: &clientCert}}
+	tlsConfig, err := TLSConfigFor(&Config{CredentialProvider: provider})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := tlsConfig.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !certsEqual(got, &clientCert) {
+		t.Errorf("expected the certificate of the credential provider")
+	}
+
+	provider.set(&Credentials{Token: "token"}, nil)
+	got, err = tlsConfig.GetClientCertificate(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Certificate) != 0 {
+		t.Errorf("expected no certificate for credentials without one")
+	}
+}
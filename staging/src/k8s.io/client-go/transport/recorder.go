@@ -0,0 +1,382 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// recordedExchange is a request and its response as written by a Recorder,
+// one per line.
+type recordedExchange struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Query  string `json:"query,omitempty"`
+
+	// Error is set if the round trip failed, in which case there is no
+	// response.
+	Error string `json:"error,omitempty"`
+
+	StatusCode int         `json:"statusCode,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	// Chunks are the pieces of the response body in the order they were
+	// read, which keeps the events of watch streams apart.
+	Chunks [][]byte `json:"chunks,omitempty"`
+}
+
+// ignoredQueryParameters are left out when matching requests because they
+// differ between otherwise identical requests, e.g. reflectors pick a random
+// timeout for every watch.
+var ignoredQueryParameters = []string{"timeoutSeconds"}
+
+// canonicalQuery returns the query of u in a form that is the same for
+// requests that should be matched.
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	for _, param := range ignoredQueryParameters {
+		query.Del(param)
+	}
+	return query.Encode()
+}
+
+// isWatchRequest returns true if req asks for a watch stream.
+func isWatchRequest(req *http.Request) bool {
+	switch req.URL.Query().Get("watch") {
+	case "true", "1":
+		return true
+	}
+	return strings.Contains(req.URL.Path, "/watch/")
+}
+
+// Recorder writes the requests sent through the round trippers it wraps and
+// the responses they got to a file, which a Replayer can serve back later.
+// Response bodies, including watch streams, are recorded as they are read,
+// and every exchange is written once its response body is closed or the
+// Recorder is closed.
+//
+// Use it with rest.Config.Wrap(recorder.Wrap).
+type Recorder struct {
+	lock sync.Mutex
+	file *os.File
+	// open are the response bodies that are still being read.
+	open map[*recordingBody]struct{}
+}
+
+// NewRecorder creates a Recorder that writes to path, replacing any file
+// that is there.
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		file: file,
+		open: map[*recordingBody]struct{}{},
+	}, nil
+}
+
+// Wrap returns a round tripper that records what is sent through rt.
+// It has the signature of a WrapperFunc.
+func (r *Recorder) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return &recordingRoundTripper{recorder: r, rt: rt}
+}
+
+// Close writes the exchanges whose response bodies are still open, e.g.
+// watch streams, with what was read so far, and closes the file.
+func (r *Recorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	var errs []string
+	for body := range r.open {
+		if err := r.writeLocked(body.exchange); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	r.open = nil
+	if err := r.file.Close(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	r.file = nil
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (r *Recorder) write(exchange *recordedExchange) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.writeLocked(exchange)
+}
+
+// writeLocked appends exchange to the file. The caller must hold the lock.
+func (r *Recorder) writeLocked(exchange *recordedExchange) error {
+	if r.file == nil {
+		return fmt.Errorf("recorder is closed")
+	}
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	_, err = r.file.Write(append(data, '\n'))
+	return err
+}
+
+// finish writes the exchange of body unless that already happened.
+func (r *Recorder) finish(body *recordingBody) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.open[body]; !ok {
+		return nil
+	}
+	delete(r.open, body)
+	return r.writeLocked(body.exchange)
+}
+
+type recordingRoundTripper struct {
+	recorder *Recorder
+	rt       http.RoundTripper
+}
+
+func (rt *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &recordedExchange{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  canonicalQuery(req.URL),
+	}
+	resp, err := rt.rt.RoundTrip(req)
+	if err != nil {
+		exchange.Error = err.Error()
+		utilruntime.HandleError(rt.recorder.write(exchange))
+		return nil, err
+	}
+	exchange.StatusCode = resp.StatusCode
+	exchange.Header = resp.Header.Clone()
+
+	body := &recordingBody{recorder: rt.recorder, exchange: exchange, body: resp.Body}
+	rt.recorder.lock.Lock()
+	if rt.recorder.open != nil {
+		rt.recorder.open[body] = struct{}{}
+	}
+	rt.recorder.lock.Unlock()
+	resp.Body = body
+	return resp, nil
+}
+
+func (rt *recordingRoundTripper) CancelRequest(req *http.Request) {
+	tryCancelRequest(rt.WrappedRoundTripper(), req)
+}
+
+func (rt *recordingRoundTripper) WrappedRoundTripper() http.RoundTripper { return rt.rt }
+
+// recordingBody records a response body as it is read.
+type recordingBody struct {
+	recorder *Recorder
+	exchange *recordedExchange
+	body     io.ReadCloser
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		chunk := make([]byte, n)
+		copy(chunk, p[:n])
+		b.recorder.lock.Lock()
+		b.exchange.Chunks = append(b.exchange.Chunks, chunk)
+		b.recorder.lock.Unlock()
+	}
+	if err == io.EOF {
+		if err := b.recorder.finish(b); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	err := b.body.Close()
+	if finishErr := b.recorder.finish(b); err == nil {
+		err = finishErr
+	}
+	return err
+}
+
+// Replayer serves the responses recorded by a Recorder without sending any
+// request. Requests are matched by verb, path and query, except for the
+// timeoutSeconds parameter. Requests that match several exchanges get their
+// responses in the order they were recorded, and the last one again once all
+// were served. Replayed watch streams stay open after their recorded events
+// until they are closed or their request is cancelled, like a watch that
+// doesn't see any changes.
+//
+// Use it with rest.Config.Wrap(replayer.Wrap).
+type Replayer struct {
+	lock      sync.Mutex
+	exchanges map[string][]*recordedExchange
+	served    map[string]int
+}
+
+// NewReplayer creates a Replayer that serves the exchanges recorded in the
+// file at path.
+func NewReplayer(path string) (*Replayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := &Replayer{
+		exchanges: map[string][]*recordedExchange{},
+		served:    map[string]int{},
+	}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			exchange := &recordedExchange{}
+			if err := json.Unmarshal(line, exchange); err != nil {
+				return nil, fmt.Errorf("unable to decode exchange from %s: %v", path, err)
+			}
+			key := replayKey(exchange.Method, exchange.Path, exchange.Query)
+			r.exchanges[key] = append(r.exchanges[key], exchange)
+		}
+		if err == io.EOF {
+			return r, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func replayKey(method, path, query string) string {
+	return method + " " + path + "?" + query
+}
+
+// Wrap returns a round tripper that serves recorded responses. rt is never
+// used. It has the signature of a WrapperFunc.
+func (r *Replayer) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return &replayingRoundTripper{replayer: r}
+}
+
+// next returns the exchange to serve for a request with the given key.
+func (r *Replayer) next(key string) (*recordedExchange, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	exchanges := r.exchanges[key]
+	if len(exchanges) == 0 {
+		return nil, false
+	}
+	i := r.served[key]
+	if i >= len(exchanges) {
+		return exchanges[len(exchanges)-1], true
+	}
+	r.served[key] = i + 1
+	return exchanges[i], true
+}
+
+type replayingRoundTripper struct {
+	replayer *Replayer
+}
+
+func (rt *replayingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange, ok := rt.replayer.next(replayKey(req.Method, req.URL.Path, canonicalQuery(req.URL)))
+	if !ok {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	if len(exchange.Error) > 0 {
+		return nil, errors.New(exchange.Error)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode:    exchange.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        exchange.Header.Clone(),
+		ContentLength: -1,
+		Body: &replayingBody{
+			chunks: exchange.Chunks,
+			hold:   isWatchRequest(req),
+			ctx:    req.Context(),
+			closed: make(chan struct{}),
+		},
+		Request: req,
+	}, nil
+}
+
+// replayingBody serves recorded chunks, one per read at most. The chunks are
+// shared by every replay of the exchange and never modified.
+type replayingBody struct {
+	chunks [][]byte
+	// offset is the number of bytes of chunks[0] that were read.
+	offset int
+	// hold keeps the body open once all chunks were read.
+	hold bool
+	ctx  context.Context
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (b *replayingBody) Read(p []byte) (int, error) {
+	select {
+	case <-b.closed:
+		return 0, io.EOF
+	default:
+	}
+	if len(b.chunks) > 0 {
+		n := copy(p, b.chunks[0][b.offset:])
+		b.offset += n
+		if b.offset == len(b.chunks[0]) {
+			b.chunks = b.chunks[1:]
+			b.offset = 0
+		}
+		return n, nil
+	}
+	if !b.hold {
+		return 0, io.EOF
+	}
+	select {
+	case <-b.closed:
+		return 0, io.EOF
+	case <-b.ctx.Done():
+		return 0, b.ctx.Err()
+	}
+}
+
+func (b *replayingBody) Close() error {
+	b.closeOnce.Do(func() { close(b.closed) })
+	return nil
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+// chunkReader returns a chunk per read.
+type chunkReader struct {
+	chunks [][]byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	delegate := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		chunks := [][]byte{[]byte(`{"kind":"PodList"}`)}
+		if req.URL.Query().Get("watch") == "true" {
+			chunks = [][]byte{[]byte(`{"type":"ADDED"}`), []byte(`{"type":"DELETED"}`)}
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(&chunkReader{chunks: chunks}),
+		}, nil
+	})
+
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "exchanges.json")
+
+	get := func(rt http.RoundTripper, url string) (*http.Response, [][]byte) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", url, nil)
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		defer resp.Body.Close()
+		var chunks [][]byte
+		buf := make([]byte, 1024)
+		for {
+			n, err := resp.Body.Read(buf)
+			if n > 0 {
+				chunks = append(chunks, append([]byte{}, buf[:n]...))
+			}
+			if err != nil {
+				break
+			}
+		}
+		return resp, chunks
+	}
+
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rt := recorder.Wrap(delegate)
+	_, recordedList := get(rt, "https://server/api/v1/pods?timeoutSeconds=10")
+	_, recordedWatch := get(rt, "https://server/api/v1/pods?watch=true&timeoutSeconds=10")
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recordedWatch) != 2 {
+		t.Fatalf("expected the watch to be read in 2 chunks, got %q", recordedWatch)
+	}
+
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rt = replayer.Wrap(nil)
+
+	resp, list := get(rt, "https://elsewhere/api/v1/pods?timeoutSeconds=20")
+	if e, a := "application/json", resp.Header.Get("Content-Type"); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := recordedList, list; len(a) != 1 || string(e[0]) != string(a[0]) {
+		t.Errorf("expected %q, got %q", e, a)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "https://elsewhere/api/v1/pods?watch=true", nil)
+	resp, err = rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := make([]byte, 1024)
+	for i, chunk := range recordedWatch {
+		n, err := resp.Body.Read(buf)
+		if err != nil || string(buf[:n]) != string(chunk) {
+			t.Fatalf("chunk %d: expected %q, got %q, %v", i, chunk, buf[:n], err)
+		}
+	}
+	readErr := make(chan error)
+	go func() {
+		_, err := resp.Body.Read(buf)
+		readErr <- err
+	}()
+	select {
+	case err := <-readErr:
+		t.Fatalf("expected the watch to stay open, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	if err := <-readErr; err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	req, _ = http.NewRequest("GET", "https://elsewhere/api/v1/nodes", nil)
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Errorf("expected an error for a request that wasn't recorded")
+	}
+}
+
+func TestReplaySameExchangeTwice(t *testing.T) {
+	const body = `{"kind":"PodList","items":[]}`
+	delegate := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(&chunkReader{chunks: [][]byte{[]byte(body)}}),
+		}, nil
+	})
+
+	dir, err := ioutil.TempDir("", "recorder")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "exchanges.json")
+
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	req, _ := http.NewRequest("GET", "https://server/api/v1/pods", nil)
+	resp, err := recorder.Wrap(delegate).RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	replayer, err := NewReplayer(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rt := replayer.Wrap(nil)
+	// The last recorded exchange is served for every later request. The
+	// replays read it concurrently, a few bytes at a time.
+	bodies := make(chan string)
+	for i := 0; i < 2; i++ {
+		go func() {
+			req, _ := http.NewRequest("GET", "https://server/api/v1/pods", nil)
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				bodies <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			var data []byte
+			buf := make([]byte, 4)
+			for {
+				n, err := resp.Body.Read(buf)
+				data = append(data, buf[:n]...)
+				if err != nil {
+					break
+				}
+			}
+			bodies <- string(data)
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if got := <-bodies; got != body {
+			t.Errorf("replay %d: expected %q, got %q", i, body, got)
+		}
+	}
+}
@@ -44,6 +44,10 @@ func HTTPWrappersForConfig(config *Config, rt http.RoundTripper) (http.RoundTrip
 	switch {
 	case config.HasBasicAuth() && config.HasTokenAuth():
 		return nil, fmt.Errorf("username/password or bearer token may be set, but not both")
+	case config.HasCredentialProvider() && (config.HasBasicAuth() || config.HasTokenAuth()):
+		return nil, fmt.Errorf("a credential provider may not be combined with username/password or bearer token")
+	case config.HasCredentialProvider():
+		rt = NewCredentialProviderRoundTripper(config.CredentialProvider, rt)
 	case config.HasTokenAuth():
 		var err error
 		rt, err = NewBearerAuthWithRefreshRoundTripper(config.BearerToken, config.BearerTokenFile, rt)
@@ -61,6 +65,9 @@ func HTTPWrappersForConfig(config *Config, rt http.RoundTripper) (http.RoundTrip
 		len(config.Impersonate.Extra) > 0 {
 		rt = NewImpersonatingRoundTripper(config.Impersonate, rt)
 	}
+	if config.CircuitBreaker != nil {
+		rt = NewCircuitBreakerRoundTripper(*config.CircuitBreaker, rt)
+	}
 	return rt, nil
 }
 
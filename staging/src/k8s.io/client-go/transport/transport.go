@@ -58,7 +58,7 @@ func New(config *Config) (http.RoundTripper, error) {
 // TLSConfigFor returns a tls.Config that will provide the transport level security defined
 // by the provided Config. Will return nil if no transport level security is requested.
 func TLSConfigFor(c *Config) (*tls.Config, error) {
-	if !(c.HasCA() || c.HasCertAuth() || c.HasCertCallback() || c.TLS.Insecure || len(c.TLS.ServerName) > 0 || len(c.TLS.NextProtos) > 0) {
+	if !(c.HasCA() || c.HasCertAuth() || c.HasCertCallback() || c.HasCredentialProvider() || c.TLS.Insecure || len(c.TLS.ServerName) > 0 || len(c.TLS.NextProtos) > 0) {
 		return nil, nil
 	}
 	if c.HasCA() && c.TLS.Insecure {
@@ -99,7 +99,7 @@ func TLSConfigFor(c *Config) (*tls.Config, error) {
 		dynamicCertLoader = cachingCertificateLoader(c.TLS.CertFile, c.TLS.KeyFile)
 	}
 
-	if c.HasCertAuth() || c.HasCertCallback() {
+	if c.HasCertAuth() || c.HasCertCallback() || c.HasCredentialProvider() {
 		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
 			// Note: static key/cert data always take precedence over cert
 			// callback.
@@ -120,9 +120,18 @@ func TLSConfigFor(c *Config) (*tls.Config, error) {
 					return cert, nil
 				}
 			}
+			if c.HasCredentialProvider() {
+				cert, err := clientCertificate(c.CredentialProvider)
+				if err != nil {
+					return nil, err
+				}
+				if cert != nil {
+					return cert, nil
+				}
+			}
 
-			// Both c.TLS.CertData/KeyData were unset and GetCert didn't return
-			// anything. Return an empty tls.Certificate, no client cert will
+			// Both c.TLS.CertData/KeyData were unset and neither GetCert nor
+			// the credential provider returned anything. Return an empty tls.Certificate, no client cert will
 			// be sent to the server.
 			return &tls.Certificate{}, nil
 		}
@@ -0,0 +1,286 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/tools/metrics"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// The response headers API Priority and Fairness sets to identify the
+	// priority level and flow schema that handled a request.
+	priorityLevelUIDHeader = "X-Kubernetes-PF-PriorityLevel-UID"
+	flowSchemaUIDHeader    = "X-Kubernetes-PF-FlowSchema-UID"
+
+	// minDecreaseInterval is the least time between two decreases of the
+	// rate, so that the rejections of requests that were sent together
+	// only cut it once.
+	minDecreaseInterval = time.Second
+
+	// defaultAdaptiveMaxQPS is the MaxQPS used when none is set, the default
+	// QPS of rest clients.
+	defaultAdaptiveMaxQPS = 5
+)
+
+// AdaptiveRateLimiter is a RateLimiter that adapts its rate to the responses
+// of the server.
+type AdaptiveRateLimiter interface {
+	RateLimiter
+	// Observe adapts the rate to the outcome of a request, which is either
+	// the response or the error the request failed with.
+	Observe(resp *http.Response, err error)
+}
+
+// AdaptiveRateLimiterConfig configures a rate limiter created by
+// NewAdaptiveRateLimiter.
+type AdaptiveRateLimiterConfig struct {
+	// Name identifies the rate limiter in metrics.
+	Name string
+	// InitialQPS is the rate to start with. It defaults to MaxQPS.
+	InitialQPS float32
+	// MinQPS and MaxQPS bound the rate. MaxQPS defaults to 5 and MinQPS
+	// to 1, or MaxQPS if that is lower. A MaxQPS below MinQPS is raised to
+	// MinQPS.
+	MinQPS float32
+	MaxQPS float32
+	// Burst is the number of requests that may be sent at once. Defaults
+	// to 1.
+	Burst int
+	// AdditiveIncrease is by how much the rate grows for every second of
+	// successful requests at the current rate. Defaults to 1.
+	AdditiveIncrease float32
+	// MultiplicativeDecrease is the factor the rate is multiplied with when
+	// a request is rejected. Defaults to 0.5.
+	MultiplicativeDecrease float32
+}
+
+type adaptiveRateLimiter struct {
+	name     string
+	minQPS   float32
+	maxQPS   float32
+	increase float32
+	decrease float32
+	limiter  *rate.Limiter
+	clock    Clock
+
+	lock         sync.Mutex
+	qps          float32
+	lastDecrease time.Time
+	// pausedUntil is when the server asked to be contacted again through
+	// Retry-After.
+	pausedUntil time.Time
+}
+
+// NewAdaptiveRateLimiter creates a rate limiter that increases its rate
+// additively while requests succeed and cuts it multiplicatively when the
+// server rejects requests with 429 Too Many Requests, e.g. because their API
+// Priority and Fairness priority level is saturated. Requests are not sent
+// before the time given by the Retry-After header of a rejection. The rate
+// is reported through metrics.RateLimiterQPS.
+//
+// The rest client passes the outcome of every request to the rate limiter
+// when it is set as rest.Config.RateLimiter.
+func NewAdaptiveRateLimiter(config AdaptiveRateLimiterConfig) AdaptiveRateLimiter {
+	return newAdaptiveRateLimiter(config, realClock{})
+}
+
+func newAdaptiveRateLimiter(config AdaptiveRateLimiterConfig, c Clock) *adaptiveRateLimiter {
+	if config.MaxQPS <= 0 {
+		config.MaxQPS = defaultAdaptiveMaxQPS
+	}
+	if config.MinQPS <= 0 {
+		config.MinQPS = 1
+		if config.MaxQPS < config.MinQPS {
+			config.MinQPS = config.MaxQPS
+		}
+	}
+	if config.MaxQPS < config.MinQPS {
+		config.MaxQPS = config.MinQPS
+	}
+	if config.InitialQPS <= 0 {
+		config.InitialQPS = config.MaxQPS
+	}
+	if config.InitialQPS < config.MinQPS {
+		config.InitialQPS = config.MinQPS
+	}
+	if config.InitialQPS > config.MaxQPS {
+		config.InitialQPS = config.MaxQPS
+	}
+	if config.AdditiveIncrease <= 0 {
+		config.AdditiveIncrease = 1
+	}
+	if config.MultiplicativeDecrease <= 0 || config.MultiplicativeDecrease >= 1 {
+		config.MultiplicativeDecrease = 0.5
+	}
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+	r := &adaptiveRateLimiter{
+		name:     config.Name,
+		minQPS:   config.MinQPS,
+		maxQPS:   config.MaxQPS,
+		increase: config.AdditiveIncrease,
+		decrease: config.MultiplicativeDecrease,
+		limiter:  rate.NewLimiter(rate.Limit(config.InitialQPS), config.Burst),
+		clock:    c,
+		qps:      config.InitialQPS,
+	}
+	metrics.RateLimiterQPS.Set(r.name, float64(r.qps))
+	return r
+}
+
+func (r *adaptiveRateLimiter) Observe(resp *http.Response, err error) {
+	if err != nil || resp == nil {
+		return
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		r.rejected(resp)
+	case resp.StatusCode < http.StatusInternalServerError:
+		r.succeeded()
+	}
+}
+
+func (r *adaptiveRateLimiter) succeeded() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.qps >= r.maxQPS {
+		return
+	}
+	// At the current rate, there are qps successes a second.
+	qps := r.qps + r.increase/r.qps
+	if qps > r.maxQPS {
+		qps = r.maxQPS
+	}
+	r.setQPSLocked(qps)
+}
+
+func (r *adaptiveRateLimiter) rejected(resp *http.Response) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock.Now()
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		if until := now.Add(time.Duration(seconds) * time.Second); until.After(r.pausedUntil) {
+			r.pausedUntil = until
+		}
+	}
+	if now.Sub(r.lastDecrease) < minDecreaseInterval {
+		return
+	}
+	r.lastDecrease = now
+	qps := r.qps * r.decrease
+	if qps < r.minQPS {
+		qps = r.minQPS
+	}
+	klog.V(3).Infof("Rate limiter %q lowered its QPS from %v to %v after a rejection by priority level %q, flow schema %q",
+		r.name, r.qps, qps, resp.Header.Get(priorityLevelUIDHeader), resp.Header.Get(flowSchemaUIDHeader))
+	r.setQPSLocked(qps)
+}
+
+// setQPSLocked changes the rate. The caller must hold the lock.
+func (r *adaptiveRateLimiter) setQPSLocked(qps float32) {
+	r.qps = qps
+	r.limiter.SetLimitAt(r.clock.Now(), rate.Limit(qps))
+	metrics.RateLimiterQPS.Set(r.name, float64(qps))
+}
+
+// pause returns how long the server asked to wait before sending requests.
+func (r *adaptiveRateLimiter) pause() time.Duration {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.pausedUntil.Sub(r.clock.Now())
+}
+
+func (r *adaptiveRateLimiter) TryAccept() bool {
+	if r.pause() > 0 {
+		return false
+	}
+	return r.limiter.AllowN(r.clock.Now(), 1)
+}
+
+// Accept will block until a token becomes available
+func (r *adaptiveRateLimiter) Accept() {
+	if d := r.pause(); d > 0 {
+		r.clock.Sleep(d)
+	}
+	now := r.clock.Now()
+	r.clock.Sleep(r.limiter.ReserveN(now, 1).DelayFrom(now))
+}
+
+func (r *adaptiveRateLimiter) Stop() {
+}
+
+func (r *adaptiveRateLimiter) QPS() float32 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.qps
+}
+
+func (r *adaptiveRateLimiter) Wait(ctx context.Context) error {
+	if d := r.pause(); d > 0 {
+		if err := r.sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+	now := r.clock.Now()
+	reservation := r.limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return fmt.Errorf("rate limiter %q can't grant a token", r.name)
+	}
+	if err := r.sleep(ctx, reservation.DelayFrom(now)); err != nil {
+		reservation.CancelAt(r.clock.Now())
+		return err
+	}
+	return nil
+}
+
+// sleep waits for d on the clock of r, or until ctx is done.
+func (r *adaptiveRateLimiter) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	var after <-chan time.Time
+	if c, ok := r.clock.(interface {
+		After(time.Duration) <-chan time.Time
+	}); ok {
+		after = c.After(d)
+	} else {
+		ch := make(chan time.Time, 1)
+		go func() {
+			r.clock.Sleep(d)
+			ch <- r.clock.Now()
+		}()
+		after = ch
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-after:
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func response(code int, header http.Header) *http.Response {
+	return &http.Response{StatusCode: code, Header: header}
+}
+
+func TestAdaptiveRateLimiter(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	r := newAdaptiveRateLimiter(AdaptiveRateLimiterConfig{
+		InitialQPS: 10,
+		MinQPS:     2,
+		MaxQPS:     20,
+		Burst:      1,
+	}, fakeClock)
+
+	// A second of successful requests raises the rate by one.
+	for i := 0; i < 10; i++ {
+		r.Observe(response(http.StatusOK, http.Header{}), nil)
+	}
+	if qps := r.QPS(); qps < 10.9 || qps > 11 {
+		t.Errorf("expected a QPS of about 11, got %v", qps)
+	}
+
+	// Errors and server errors don't change the rate.
+	r.Observe(nil, http.ErrHandlerTimeout)
+	r.Observe(response(http.StatusInternalServerError, http.Header{}), nil)
+	if qps := r.QPS(); qps < 10.9 || qps > 11 {
+		t.Errorf("expected a QPS of about 11, got %v", qps)
+	}
+
+	// Rejections halve the rate, once for requests rejected together.
+	rejection := response(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"2"}})
+	r.Observe(rejection, nil)
+	r.Observe(rejection, nil)
+	if qps := r.QPS(); qps < 5.4 || qps > 5.5 {
+		t.Errorf("expected a QPS of about 5.5, got %v", qps)
+	}
+	fakeClock.Step(time.Second)
+	r.Observe(rejection, nil)
+	fakeClock.Step(time.Second)
+	r.Observe(rejection, nil)
+	if e, a := float32(2), r.QPS(); e != a {
+		t.Errorf("expected the QPS to stay at the minimum of %v, got %v", e, a)
+	}
+
+	// Requests are only accepted once the Retry-After of the last rejection passed.
+	if r.TryAccept() {
+		t.Errorf("expected no requests to be accepted during Retry-After")
+	}
+	fakeClock.Step(2 * time.Second)
+	if !r.TryAccept() {
+		t.Errorf("expected a request to be accepted after Retry-After")
+	}
+}
+
+func TestAdaptiveRateLimiterMaxQPS(t *testing.T) {
+	r := newAdaptiveRateLimiter(AdaptiveRateLimiterConfig{MinQPS: 1, MaxQPS: 5, Burst: 1}, clock.NewFakeClock(time.Now()))
+	if e, a := float32(5), r.QPS(); e != a {
+		t.Errorf("expected to start at %v, got %v", e, a)
+	}
+	r.Observe(response(http.StatusOK, http.Header{}), nil)
+	if e, a := float32(5), r.QPS(); e != a {
+		t.Errorf("expected to stay at %v, got %v", e, a)
+	}
+}
+
+func TestAdaptiveRateLimiterDefaults(t *testing.T) {
+	r := newAdaptiveRateLimiter(AdaptiveRateLimiterConfig{}, clock.NewFakeClock(time.Now()))
+	if e, a := float32(defaultAdaptiveMaxQPS), r.QPS(); e != a {
+		t.Errorf("expected to start at %v, got %v", e, a)
+	}
+	if e, a := float32(1), r.minQPS; e != a {
+		t.Errorf("expected a minimum of %v, got %v", e, a)
+	}
+	// Without a default burst, no token would ever be granted.
+	if err := r.Wait(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAdaptiveRateLimiterWaitUsesClock(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	r := newAdaptiveRateLimiter(AdaptiveRateLimiterConfig{MinQPS: 1, MaxQPS: 1, Burst: 1}, fakeClock)
+	r.Observe(response(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"10"}}), nil)
+
+	done := make(chan error)
+	go func() {
+		done <- r.Wait(context.Background())
+	}()
+	for !fakeClock.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("expected Wait to block during Retry-After, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	fakeClock.Step(10 * time.Second)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// A cancelled wait returns the error of the context.
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- r.Wait(ctx)
+	}()
+	for !fakeClock.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
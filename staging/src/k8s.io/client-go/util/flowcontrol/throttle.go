@@ -69,6 +69,9 @@ func (realClock) Now() time.Time {
 func (realClock) Sleep(d time.Duration) {
 	time.Sleep(d)
 }
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
 
 // NewTokenBucketRateLimiterWithClock is identical to NewTokenBucketRateLimiter
 // but allows an injectable clock, for testing.
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// Snapshotter is implemented by queues that can describe what they hold,
+// for debugging.
+type Snapshotter interface {
+	// Snapshot returns a point in time copy of the queue's items.
+	Snapshot() QueueSnapshot
+}
+
+// QueueSnapshot describes the items in a queue. An item can appear in more
+// than one list, e.g. an item that was added again while being processed is
+// both processing and dirty.
+type QueueSnapshot struct {
+	// Name is the name of the queue, if known.
+	Name string `json:"name,omitempty"`
+	// Queued are the items waiting for Get, in the order Get returns them.
+	Queued []ItemSnapshot `json:"queued"`
+	// Processing are the items returned by Get that are not Done yet.
+	Processing []ItemSnapshot `json:"processing"`
+	// Dirty are the items that need to be processed.
+	Dirty []ItemSnapshot `json:"dirty"`
+	// Waiting are the items a delaying queue will add later.
+	Waiting []ItemSnapshot `json:"waiting"`
+}
+
+// ItemSnapshot describes a single item in a queue.
+type ItemSnapshot struct {
+	Item interface{} `json:"item"`
+	// TimeInQueueSeconds is how long the item has been queued or dirty
+	// since it was added, or processing since Get returned it.
+	TimeInQueueSeconds float64 `json:"timeInQueueSeconds,omitempty"`
+	// ReadyInSeconds is how long a waiting item still has to wait.
+	ReadyInSeconds float64 `json:"readyInSeconds,omitempty"`
+	// Requeues is the number of times a rate limited item was requeued.
+	Requeues int `json:"requeues,omitempty"`
+	// Priority is the priority of an item in a priority queue.
+	Priority int `json:"priority,omitempty"`
+	// Flow is the flow of an item in a fair queue.
+	Flow string `json:"flow,omitempty"`
+}
+
+var debugQueues = struct {
+	lock sync.Mutex
+	// queues maps every registered queue to the unique name it is served
+	// under.
+	queues map[Snapshotter]string
+}{
+	queues: map[Snapshotter]string{},
+}
+
+// registerDebugQueue exposes q through the debug handler until it is
+// unregistered. If another queue is registered with the same name, q is
+// served under the name with a "-2", "-3"... suffix.
+func registerDebugQueue(name string, q Snapshotter) {
+	if len(name) == 0 {
+		return
+	}
+	debugQueues.lock.Lock()
+	defer debugQueues.lock.Unlock()
+
+	taken := make(map[string]bool, len(debugQueues.queues))
+	for _, registered := range debugQueues.queues {
+		taken[registered] = true
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	debugQueues.queues[q] = unique
+}
+
+// unregisterDebugQueue stops exposing q, if it is registered.
+func unregisterDebugQueue(q Snapshotter) {
+	debugQueues.lock.Lock()
+	defer debugQueues.lock.Unlock()
+	delete(debugQueues.queues, q)
+}
+
+// NewDebugHandler returns an http.Handler that serves snapshots of the named
+// queues constructed by NewNamedRateLimitingQueue,
+// NewNamedPriorityRateLimitingQueue, NewNamedPriorityQueue and
+// NewNamedFairQueue as JSON. Without parameters, it serves a list with a
+// snapshot of every queue. With a "name" query parameter, it serves the
+// snapshot of the queue with that name. A queue is served until it is shut
+// down; like its goroutines, it is never released if it isn't.
+func NewDebugHandler() http.Handler {
+	return http.HandlerFunc(serveDebugQueues)
+}
+
+func serveDebugQueues(w http.ResponseWriter, r *http.Request) {
+	debugQueues.lock.Lock()
+	queues := make(map[string]Snapshotter, len(debugQueues.queues))
+	for q, name := range debugQueues.queues {
+		queues[name] = q
+	}
+	debugQueues.lock.Unlock()
+
+	var result interface{}
+	if name := r.URL.Query().Get("name"); len(name) > 0 {
+		q, ok := queues[name]
+		if !ok {
+			http.Error(w, "queue not found", http.StatusNotFound)
+			return
+		}
+		snapshot := q.Snapshot()
+		snapshot.Name = name
+		result = snapshot
+	} else {
+		names := make([]string, 0, len(queues))
+		for name := range queues {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		snapshots := make([]QueueSnapshot, 0, len(names))
+		for _, name := range names {
+			snapshot := queues[name].Snapshot()
+			snapshot.Name = name
+			snapshots = append(snapshots, snapshot)
+		}
+		result = snapshots
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(result); err != nil {
+		utilruntime.HandleError(err)
+		http.Error(w, fmt.Sprintf("unable to encode the snapshots: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(buf.Bytes())
+	utilruntime.HandleError(err)
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func itemsOf(snapshots []ItemSnapshot) []interface{} {
+	var items []interface{}
+	for _, s := range snapshots {
+		items = append(items, s.Item)
+	}
+	return items
+}
+
+func TestDebugHandler(t *testing.T) {
+	q := NewNamedRateLimitingQueue(NewItemExponentialFailureRateLimiter(time.Hour, time.Hour), "debug-test")
+	q.Add("a")
+	q.Add("b")
+	q.AddRateLimited("c")
+	if item, _ := q.Get(); item != "a" {
+		t.Fatalf("expected %v, got %v", "a", item)
+	}
+	q.Add("a")
+	if err := waitForWaitingQueueToFill(q.(*rateLimitingType).DelayingInterface); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	server := httptest.NewServer(NewDebugHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?name=debug-test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	snapshot := QueueSnapshot{}
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if e, a := "debug-test", snapshot.Name; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := []interface{}{"b"}, itemsOf(snapshot.Queued); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected queued %v, got %v", e, a)
+	}
+	if e, a := []interface{}{"a"}, itemsOf(snapshot.Processing); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected processing %v, got %v", e, a)
+	}
+	if e, a := 2, len(snapshot.Dirty); e != a {
+		t.Errorf("expected %v dirty items, got %v", e, a)
+	}
+	if e, a := []interface{}{"c"}, itemsOf(snapshot.Waiting); !reflect.DeepEqual(e, a) {
+		t.Fatalf("expected waiting %v, got %v", e, a)
+	}
+	if snapshot.Waiting[0].ReadyInSeconds <= 0 {
+		t.Errorf("expected c to be ready in the future, got %v", snapshot.Waiting[0].ReadyInSeconds)
+	}
+	if e, a := 1, snapshot.Waiting[0].Requeues; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	q.ShutDown()
+	resp, err = http.Get(server.URL + "?name=debug-test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected status %d after shutdown, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func getSnapshot(t *testing.T, url, name string) QueueSnapshot {
+	resp, err := http.Get(url + "?name=" + name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status %d for %s, got %d", http.StatusOK, name, resp.StatusCode)
+	}
+	snapshot := QueueSnapshot{}
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return snapshot
+}
+
+func TestDebugHandlerDuplicateNames(t *testing.T) {
+	first := NewNamedRateLimitingQueue(DefaultControllerRateLimiter(), "debug-duplicate")
+	second := NewNamedRateLimitingQueue(DefaultControllerRateLimiter(), "debug-duplicate")
+	first.Add("first")
+	second.Add("second")
+
+	server := httptest.NewServer(NewDebugHandler())
+	defer server.Close()
+
+	if e, a := []interface{}{"first"}, itemsOf(getSnapshot(t, server.URL, "debug-duplicate").Queued); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected queued %v, got %v", e, a)
+	}
+	if e, a := []interface{}{"second"}, itemsOf(getSnapshot(t, server.URL, "debug-duplicate-2").Queued); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected queued %v, got %v", e, a)
+	}
+
+	// The name of a shut down queue is free for the next one.
+	first.ShutDown()
+	third := NewNamedRateLimitingQueue(DefaultControllerRateLimiter(), "debug-duplicate")
+	defer third.ShutDown()
+	third.Add("third")
+	if e, a := []interface{}{"third"}, itemsOf(getSnapshot(t, server.URL, "debug-duplicate").Queued); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected queued %v, got %v", e, a)
+	}
+	second.ShutDown()
+}
+
+func TestDebugHandlerPriorityQueue(t *testing.T) {
+	q := NewNamedPriorityQueue("debug-priority")
+	defer q.ShutDown()
+	q.AddWithPriority("low", -1)
+	q.AddWithPriority("high", 1)
+	q.Add("default")
+
+	server := httptest.NewServer(NewDebugHandler())
+	defer server.Close()
+
+	snapshot := getSnapshot(t, server.URL, "debug-priority")
+	if e, a := []interface{}{"high", "default", "low"}, itemsOf(snapshot.Queued); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected queued %v, got %v", e, a)
+	}
+	if e, a := 1, snapshot.Queued[0].Priority; e != a {
+		t.Errorf("expected priority %v, got %v", e, a)
+	}
+}
+
+func TestDebugHandlerPriorityRateLimitingQueue(t *testing.T) {
+	q := NewNamedPriorityRateLimitingQueue(NewItemExponentialFailureRateLimiter(time.Hour, time.Hour), "debug-priority-rate-limiting")
+	defer q.ShutDown()
+	q.AddWithPriority("queued", 1)
+	q.AddRateLimitedWithPriority("waiting", 2)
+	if err := waitForWaitingQueueToFill(q.(*priorityRateLimitingType).PriorityDelayingInterface); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	server := httptest.NewServer(NewDebugHandler())
+	defer server.Close()
+
+	snapshot := getSnapshot(t, server.URL, "debug-priority-rate-limiting")
+	if e, a := []interface{}{"queued"}, itemsOf(snapshot.Queued); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected queued %v, got %v", e, a)
+	}
+	if len(snapshot.Waiting) != 1 {
+		t.Fatalf("expected one waiting item, got %v", snapshot.Waiting)
+	}
+	if waiting := snapshot.Waiting[0]; waiting.Item != "waiting" || waiting.Requeues != 1 || waiting.Priority != 2 {
+		t.Errorf("expected the waiting item with 1 requeue and priority 2, got %#v", waiting)
+	}
+
+	q.ShutDown()
+	resp, err := http.Get(server.URL + "?name=debug-priority-rate-limiting")
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the queue to be unregistered on shut down, got status %d", resp.StatusCode)
+	}
+}
+
+func TestDebugHandlerFairQueue(t *testing.T) {
+	q := NewNamedFairQueue("debug-fair", func(item interface{}) string {
+		return item.(string)[:1]
+	}, 1)
+	defer q.ShutDown()
+	q.Add("a1")
+	q.Add("a2")
+	q.Add("b1")
+	if item, _ := q.Get(); item != "a1" {
+		t.Fatalf("expected %v, got %v", "a1", item)
+	}
+
+	server := httptest.NewServer(NewDebugHandler())
+	defer server.Close()
+
+	snapshot := getSnapshot(t, server.URL, "debug-fair")
+	if e, a := []interface{}{"b1", "a2"}, itemsOf(snapshot.Queued); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected queued %v, got %v", e, a)
+	}
+	if e, a := []interface{}{"a1"}, itemsOf(snapshot.Processing); !reflect.DeepEqual(e, a) {
+		t.Errorf("expected processing %v, got %v", e, a)
+	}
+	if e, a := "a", snapshot.Processing[0].Flow; e != a {
+		t.Errorf("expected flow %v, got %v", e, a)
+	}
+}
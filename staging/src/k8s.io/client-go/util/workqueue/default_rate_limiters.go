@@ -17,11 +17,14 @@ limitations under the License.
 package workqueue
 
 import (
+	"fmt"
 	"math"
 	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 )
 
 type RateLimiter interface {
@@ -70,6 +73,9 @@ type ItemExponentialFailureRateLimiter struct {
 
 	baseDelay time.Duration
 	maxDelay  time.Duration
+
+	// storage, if set, persists the failure counts
+	storage Storage
 }
 
 var _ RateLimiter = &ItemExponentialFailureRateLimiter{}
@@ -82,6 +88,22 @@ func NewItemExponentialFailureRateLimiter(baseDelay time.Duration, maxDelay time
 	}
 }
 
+// NewItemExponentialFailureRateLimiterWithStorage is like
+// NewItemExponentialFailureRateLimiter, but persists the failure counts of
+// string items in storage and starts with the failure counts found there.
+func NewItemExponentialFailureRateLimiterWithStorage(baseDelay time.Duration, maxDelay time.Duration, storage Storage) (RateLimiter, error) {
+	failures, err := loadFailures(storage)
+	if err != nil {
+		return nil, err
+	}
+	return &ItemExponentialFailureRateLimiter{
+		failures:  failures,
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+		storage:   storage,
+	}, nil
+}
+
 func DefaultItemBasedRateLimiter() RateLimiter {
 	return NewItemExponentialFailureRateLimiter(time.Millisecond, 1000*time.Second)
 }
@@ -92,6 +114,7 @@ func (r *ItemExponentialFailureRateLimiter) When(item interface{}) time.Duration
 
 	exp := r.failures[item]
 	r.failures[item] = r.failures[item] + 1
+	persistFailures(r.storage, item, r.failures[item])
 
 	// The backoff is capped such that 'calculated' value never overflows.
 	backoff := float64(r.baseDelay.Nanoseconds()) * math.Pow(2, float64(exp))
@@ -118,7 +141,10 @@ func (r *ItemExponentialFailureRateLimiter) Forget(item interface{}) {
 	r.failuresLock.Lock()
 	defer r.failuresLock.Unlock()
 
-	delete(r.failures, item)
+	if _, exists := r.failures[item]; exists {
+		delete(r.failures, item)
+		persistFailures(r.storage, item, 0)
+	}
 }
 
 // ItemFastSlowRateLimiter does a quick retry for a certain number of attempts, then a slow retry after that
@@ -129,6 +155,9 @@ type ItemFastSlowRateLimiter struct {
 	maxFastAttempts int
 	fastDelay       time.Duration
 	slowDelay       time.Duration
+
+	// storage, if set, persists the failure counts
+	storage Storage
 }
 
 var _ RateLimiter = &ItemFastSlowRateLimiter{}
@@ -142,11 +171,29 @@ func NewItemFastSlowRateLimiter(fastDelay, slowDelay time.Duration, maxFastAttem
 	}
 }
 
+// NewItemFastSlowRateLimiterWithStorage is like NewItemFastSlowRateLimiter,
+// but persists the failure counts of string items in storage and starts with
+// the failure counts found there.
+func NewItemFastSlowRateLimiterWithStorage(fastDelay, slowDelay time.Duration, maxFastAttempts int, storage Storage) (RateLimiter, error) {
+	failures, err := loadFailures(storage)
+	if err != nil {
+		return nil, err
+	}
+	return &ItemFastSlowRateLimiter{
+		failures:        failures,
+		fastDelay:       fastDelay,
+		slowDelay:       slowDelay,
+		maxFastAttempts: maxFastAttempts,
+		storage:         storage,
+	}, nil
+}
+
 func (r *ItemFastSlowRateLimiter) When(item interface{}) time.Duration {
 	r.failuresLock.Lock()
 	defer r.failuresLock.Unlock()
 
 	r.failures[item] = r.failures[item] + 1
+	persistFailures(r.storage, item, r.failures[item])
 
 	if r.failures[item] <= r.maxFastAttempts {
 		return r.fastDelay
@@ -166,7 +213,35 @@ func (r *ItemFastSlowRateLimiter) Forget(item interface{}) {
 	r.failuresLock.Lock()
 	defer r.failuresLock.Unlock()
 
-	delete(r.failures, item)
+	if _, exists := r.failures[item]; exists {
+		delete(r.failures, item)
+		persistFailures(r.storage, item, 0)
+	}
+}
+
+// loadFailures returns the failure counts recorded in storage.
+func loadFailures(storage Storage) (map[interface{}]int, error) {
+	state, err := storage.Load()
+	if err != nil {
+		return nil, err
+	}
+	failures := make(map[interface{}]int, len(state.Failures))
+	for item, count := range state.Failures {
+		failures[item] = count
+	}
+	return failures, nil
+}
+
+// persistFailures records the failure count of item in storage, if there
+// is a storage and the item is a string.
+func persistFailures(storage Storage, item interface{}, failures int) {
+	key, ok := item.(string)
+	if !ok || storage == nil {
+		return
+	}
+	if err := storage.SetFailures(key, failures); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to persist failures of %q: %v", key, err))
+	}
 }
 
 // MaxOfRateLimiter calls every RateLimiter and returns the worst case response
@@ -18,6 +18,7 @@ package workqueue
 
 import (
 	"container/heap"
+	"fmt"
 	"sync"
 	"time"
 
@@ -33,6 +34,16 @@ type DelayingInterface interface {
 	AddAfter(item interface{}, duration time.Duration)
 }
 
+// PriorityDelayingInterface is a DelayingInterface that hands out items by
+// priority, see PriorityInterface.
+type PriorityDelayingInterface interface {
+	DelayingInterface
+	PriorityInterface
+	// AddAfterWithPriority adds an item to the workqueue at the given priority
+	// after the indicated duration has passed
+	AddAfterWithPriority(item interface{}, priority int, duration time.Duration)
+}
+
 // NewDelayingQueue constructs a new workqueue with delayed queuing ability
 func NewDelayingQueue() DelayingInterface {
 	return NewDelayingQueueWithCustomClock(clock.RealClock{}, "")
@@ -41,7 +52,7 @@ func NewDelayingQueue() DelayingInterface {
 // NewDelayingQueueWithCustomQueue constructs a new workqueue with ability to
 // inject custom queue Interface instead of the default one
 func NewDelayingQueueWithCustomQueue(q Interface, name string) DelayingInterface {
-	return newDelayingQueue(clock.RealClock{}, q, name)
+	return newDelayingQueue(clock.RealClock{}, q, name, nil)
 }
 
 // NewNamedDelayingQueue constructs a new named workqueue with delayed queuing ability
@@ -49,26 +60,57 @@ func NewNamedDelayingQueue(name string) DelayingInterface {
 	return NewDelayingQueueWithCustomClock(clock.RealClock{}, name)
 }
 
+// NewNamedPriorityDelayingQueue constructs a new named workqueue with delayed
+// queuing ability that hands out items by priority
+func NewNamedPriorityDelayingQueue(name string) PriorityDelayingInterface {
+	return newDelayingQueue(clock.RealClock{}, NewNamedPriorityQueue(name), name, nil)
+}
+
 // NewDelayingQueueWithCustomClock constructs a new named workqueue
 // with ability to inject real or fake clock for testing purposes
 func NewDelayingQueueWithCustomClock(clock clock.Clock, name string) DelayingInterface {
-	return newDelayingQueue(clock, NewNamed(name), name)
+	return newDelayingQueue(clock, NewNamed(name), name, nil)
+}
+
+// NewNamedDelayingQueueWithStorage constructs a new named workqueue with
+// delayed queuing ability, whose waiting items are persisted in storage.
+// Items that were waiting when storage was last used are added again, at the
+// time they were waiting for.
+func NewNamedDelayingQueueWithStorage(name string, storage Storage) (DelayingInterface, error) {
+	return newDelayingQueueWithStorage(clock.RealClock{}, NewNamed(name), name, storage)
 }
 
-func newDelayingQueue(clock clock.Clock, q Interface, name string) *delayingType {
+// newDelayingQueue constructs a delaying queue, which persists its waiting
+// items in storage if it is not nil.
+func newDelayingQueue(clock clock.Clock, q Interface, name string, storage Storage) *delayingType {
 	ret := &delayingType{
 		Interface:       q,
 		clock:           clock,
 		heartbeat:       clock.NewTicker(maxWait),
 		stopCh:          make(chan struct{}),
 		waitingForAddCh: make(chan *waitFor, 1000),
+		snapshotCh:      make(chan chan []waitFor),
 		metrics:         newRetryMetrics(name),
+		storage:         storage,
 	}
 
 	go ret.waitingLoop()
 	return ret
 }
 
+func newDelayingQueueWithStorage(clock clock.Clock, q Interface, name string, storage Storage) (*delayingType, error) {
+	state, err := storage.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := newDelayingQueue(clock, q, name, storage)
+	for item, waiting := range state.Waiting {
+		ret.waitingForAddCh <- &waitFor{data: item, priority: waiting.Priority, readyAt: waiting.ReadyAt}
+	}
+	return ret, nil
+}
+
 // delayingType wraps an Interface and provides delayed re-enquing
 type delayingType struct {
 	Interface
@@ -87,14 +129,21 @@ type delayingType struct {
 	// waitingForAddCh is a buffered channel that feeds waitingForAdd
 	waitingForAddCh chan *waitFor
 
+	// snapshotCh asks the waiting loop for a copy of the waiting entries
+	snapshotCh chan chan []waitFor
+
 	// metrics counts the number of retries
 	metrics retryMetrics
+
+	// storage, if set, persists the items that are waiting
+	storage Storage
 }
 
 // waitFor holds the data to add and the time it should be added
 type waitFor struct {
-	data    t
-	readyAt time.Time
+	data     t
+	priority int
+	readyAt  time.Time
 	// index in the priority queue (heap)
 	index int
 }
@@ -156,8 +205,25 @@ func (q *delayingType) ShutDown() {
 	})
 }
 
+// AddWithPriority adds the given item to the work queue at the given
+// priority. The priority is ignored if the wrapped Interface is not a
+// PriorityInterface.
+func (q *delayingType) AddWithPriority(item interface{}, priority int) {
+	if pq, ok := q.Interface.(PriorityInterface); ok {
+		pq.AddWithPriority(item, priority)
+		return
+	}
+	q.Add(item)
+}
+
 // AddAfter adds the given item to the work queue after the given delay
 func (q *delayingType) AddAfter(item interface{}, duration time.Duration) {
+	q.AddAfterWithPriority(item, DefaultPriority, duration)
+}
+
+// AddAfterWithPriority adds the given item to the work queue at the given
+// priority after the given delay
+func (q *delayingType) AddAfterWithPriority(item interface{}, priority int, duration time.Duration) {
 	// don't add if we're already shutting down
 	if q.ShuttingDown() {
 		return
@@ -167,17 +233,70 @@ func (q *delayingType) AddAfter(item interface{}, duration time.Duration) {
 
 	// immediately add things with no delay
 	if duration <= 0 {
-		q.Add(item)
+		q.AddWithPriority(item, priority)
 		return
 	}
 
+	readyAt := q.clock.Now().Add(duration)
+	if key, ok := item.(string); ok && q.storage != nil {
+		if err := q.storage.SetWaiting(key, priority, readyAt); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to persist waiting item %q: %v", key, err))
+		}
+	}
+
 	select {
 	case <-q.stopCh:
 		// unblock if ShutDown() is called
-	case q.waitingForAddCh <- &waitFor{data: item, readyAt: q.clock.Now().Add(duration)}:
+	case q.waitingForAddCh <- &waitFor{data: item, priority: priority, readyAt: readyAt}:
+	}
+}
+
+// addReady adds an entry whose time has come to the work queue and removes
+// it from storage.
+func (q *delayingType) addReady(entry *waitFor) {
+	q.AddWithPriority(entry.data, entry.priority)
+	if key, ok := entry.data.(string); ok && q.storage != nil {
+		if err := q.storage.DeleteWaiting(key); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to persist added item %q: %v", key, err))
+		}
 	}
 }
 
+// insertWaiting adds an entry that is not ready yet to the waiting entries.
+// If the item was already waiting for a sooner time, storage is updated to
+// hold that time again.
+func (q *delayingType) insertWaiting(waitingForQueue *waitForPriorityQueue, waitingEntryByData map[t]*waitFor, entry *waitFor) {
+	insert(waitingForQueue, waitingEntryByData, entry)
+	if key, ok := entry.data.(string); ok && q.storage != nil {
+		if existing := waitingEntryByData[entry.data]; existing != entry && (existing.readyAt != entry.readyAt || existing.priority != entry.priority) {
+			if err := q.storage.SetWaiting(key, existing.priority, existing.readyAt); err != nil {
+				utilruntime.HandleError(fmt.Errorf("failed to persist waiting item %q: %v", key, err))
+			}
+		}
+	}
+}
+
+// Snapshot returns the items in the wrapped Interface, if it is a
+// Snapshotter, and the items waiting to be added.
+func (q *delayingType) Snapshot() QueueSnapshot {
+	var snapshot QueueSnapshot
+	if s, ok := q.Interface.(Snapshotter); ok {
+		snapshot = s.Snapshot()
+	}
+
+	entriesCh := make(chan []waitFor, 1)
+	select {
+	case <-q.stopCh:
+		return snapshot
+	case q.snapshotCh <- entriesCh:
+	}
+	now := q.clock.Now()
+	for _, entry := range <-entriesCh {
+		snapshot.Waiting = append(snapshot.Waiting, ItemSnapshot{Item: entry.data, ReadyInSeconds: entry.readyAt.Sub(now).Seconds(), Priority: entry.priority})
+	}
+	return snapshot
+}
+
 // maxWait keeps a max bound on the wait time. It's just insurance against weird things happening.
 // Checking the queue every 10 seconds isn't expensive and we know that we'll never end up with an
 // expired item sitting for more than 10 seconds.
@@ -213,7 +332,7 @@ func (q *delayingType) waitingLoop() {
 			}
 
 			entry = heap.Pop(waitingForQueue).(*waitFor)
-			q.Add(entry.data)
+			q.addReady(entry)
 			delete(waitingEntryByData, entry.data)
 		}
 
@@ -235,14 +354,21 @@ func (q *delayingType) waitingLoop() {
 		case <-q.heartbeat.C():
 			// continue the loop, which will add ready items
 
+		case snapshotCh := <-q.snapshotCh:
+			entries := make([]waitFor, 0, waitingForQueue.Len())
+			for _, entry := range *waitingForQueue {
+				entries = append(entries, *entry)
+			}
+			snapshotCh <- entries
+
 		case <-nextReadyAt:
 			// continue the loop, which will add ready items
 
 		case waitEntry := <-q.waitingForAddCh:
 			if waitEntry.readyAt.After(q.clock.Now()) {
-				insert(waitingForQueue, waitingEntryByData, waitEntry)
+				q.insertWaiting(waitingForQueue, waitingEntryByData, waitEntry)
 			} else {
-				q.Add(waitEntry.data)
+				q.addReady(waitEntry)
 			}
 
 			drained := false
@@ -250,9 +376,9 @@ func (q *delayingType) waitingLoop() {
 				select {
 				case waitEntry := <-q.waitingForAddCh:
 					if waitEntry.readyAt.After(q.clock.Now()) {
-						insert(waitingForQueue, waitingEntryByData, waitEntry)
+						q.insertWaiting(waitingForQueue, waitingEntryByData, waitEntry)
 					} else {
-						q.Add(waitEntry.data)
+						q.addReady(waitEntry)
 					}
 				default:
 					drained = true
@@ -267,6 +393,10 @@ func insert(q *waitForPriorityQueue, knownEntries map[t]*waitFor, entry *waitFor
 	// if the entry already exists, update the time only if it would cause the item to be queued sooner
 	existing, exists := knownEntries[entry.data]
 	if exists {
+		// and keep the higher priority
+		if entry.priority > existing.priority {
+			existing.priority = entry.priority
+		}
 		if existing.readyAt.After(entry.readyAt) {
 			existing.readyAt = entry.readyAt
 			heap.Fix(q, existing.index)
@@ -0,0 +1,351 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// FlowKeyFunc maps an item to the flow it belongs to, e.g. a namespaced
+// name to its namespace. It must be deterministic.
+type FlowKeyFunc func(item interface{}) string
+
+// NewFairQueue constructs a new work queue that is fair among flows: every
+// item is mapped to a flow by flowKey, and Get hands out items of the flows
+// that have items waiting in round-robin order, so a flow with many items
+// can't starve the others. Items of a single flow are handed out in the
+// order in which they were added. If maxConcurrencyPerFlow is positive, no
+// more than that many items of a single flow are processed at the same time.
+//
+// A flow only takes memory while it has items queued or processing, so
+// there can be any number of flow keys, e.g. one per namespace.
+func NewFairQueue(flowKey FlowKeyFunc, maxConcurrencyPerFlow int) Interface {
+	return NewNamedFairQueue("", flowKey, maxConcurrencyPerFlow)
+}
+
+// NewNamedFairQueue constructs a new named fair work queue, see NewFairQueue.
+func NewNamedFairQueue(name string, flowKey FlowKeyFunc, maxConcurrencyPerFlow int) Interface {
+	rc := clock.RealClock{}
+	q := newFairQueue(
+		rc,
+		globalMetricsFactory.newQueueMetrics(name, rc),
+		defaultUnfinishedWorkUpdatePeriod,
+		flowKey,
+		maxConcurrencyPerFlow,
+	)
+	registerDebugQueue(name, q)
+	return q
+}
+
+func newFairQueue(c clock.Clock, metrics queueMetrics, updatePeriod time.Duration, flowKey FlowKeyFunc, maxConcurrencyPerFlow int) *fairType {
+	q := &fairType{
+		flowKey:                    flowKey,
+		maxConcurrencyPerFlow:      maxConcurrencyPerFlow,
+		flows:                      map[string]*fairFlow{},
+		dirty:                      set{},
+		processing:                 set{},
+		addTimes:                   map[t]time.Time{},
+		processingStartTimes:       map[t]time.Time{},
+		cond:                       sync.NewCond(&sync.Mutex{}),
+		metrics:                    metrics,
+		unfinishedWorkUpdatePeriod: updatePeriod,
+		clock:                      c,
+	}
+	go q.updateUnfinishedWorkLoop()
+	return q
+}
+
+// fairFlow holds the items of a single flow.
+type fairFlow struct {
+	key string
+	// queue defines the order in which we will work on the items of this
+	// flow. Every element of queue should be in the dirty set and not in
+	// the processing set.
+	queue []t
+	// processing is the number of items of this flow that are being
+	// processed.
+	processing int
+}
+
+// fairType is a work queue like Type, except that it keeps a queue per
+// flow and serves the flows round-robin.
+//
+// Unlike the API Priority and Fairness filter, which deals flows onto a
+// fixed number of queues by shuffle sharding (see
+// k8s.io/apiserver/pkg/util/shufflesharding), every flow key gets a queue of
+// its own. Shuffle sharding bounds the number of queues when the number of
+// flows is unknown, at the price of flows sharing queues, which here would
+// mix up the order of the items of a flow and make maxConcurrencyPerFlow
+// limit groups of flows instead. Exact flows don't need that bound: a flow
+// is dropped as soon as it has no queued or processing items, so there are
+// never more flows than items in the queue, which is what a deduplicating
+// queue holds anyway.
+type fairType struct {
+	flowKey               FlowKeyFunc
+	maxConcurrencyPerFlow int
+
+	// flows holds every flow that has queued or processing items.
+	flows map[string]*fairFlow
+	// active holds the flows that have queued items, in the order in
+	// which they are served. next is the index in active of the flow
+	// whose turn is next.
+	active []*fairFlow
+	next   int
+	// queued is the number of items in the queues of all flows.
+	queued int
+
+	// dirty defines all of the items that need to be processed.
+	dirty set
+
+	// Things that are currently being processed are in the processing set.
+	// These things may be simultaneously in the dirty set. When we finish
+	// processing something and remove it from this set, we'll check if
+	// it's in the dirty set, and if so, add it to the queue.
+	processing set
+
+	// addTimes and processingStartTimes record when the items in the
+	// dirty and processing sets got there, for Snapshot.
+	addTimes             map[t]time.Time
+	processingStartTimes map[t]time.Time
+
+	cond *sync.Cond
+
+	shuttingDown bool
+
+	metrics queueMetrics
+
+	unfinishedWorkUpdatePeriod time.Duration
+	clock                      clock.Clock
+}
+
+var _ Interface = &fairType{}
+var _ Snapshotter = &fairType{}
+
+// Add marks item as needing processing.
+func (q *fairType) Add(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	if q.shuttingDown {
+		return
+	}
+	if q.dirty.has(item) {
+		return
+	}
+
+	q.metrics.add(item)
+
+	q.dirty.insert(item)
+	q.addTimes[item] = q.clock.Now()
+	if q.processing.has(item) {
+		return
+	}
+
+	q.enqueue(item)
+}
+
+// enqueue appends item to the queue of its flow. The caller must hold the
+// lock.
+func (q *fairType) enqueue(item t) {
+	key := q.flowKey(item)
+	flow, ok := q.flows[key]
+	if !ok {
+		flow = &fairFlow{key: key}
+		q.flows[key] = flow
+	}
+	if len(flow.queue) == 0 {
+		// The flow joins the round just before the flow whose turn is
+		// next, so it waits for a full round like everybody else.
+		q.active = append(q.active, nil)
+		copy(q.active[q.next+1:], q.active[q.next:])
+		q.active[q.next] = flow
+		q.next++
+		if q.next == len(q.active) {
+			q.next = 0
+		}
+	}
+	flow.queue = append(flow.queue, item)
+	q.queued++
+	q.cond.Signal()
+}
+
+// Len returns the current queue length, for informational purposes only. You
+// shouldn't e.g. gate a call to Add() or Get() on Len() being a particular
+// value, that can't be synchronized properly.
+func (q *fairType) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.queued
+}
+
+// Get blocks until it can return an item to be processed, taking the flows
+// in turn and skipping flows that are at their concurrency limit. If
+// shutdown = true, the caller should end their goroutine. You must call Done
+// with item when you have finished processing it.
+func (q *fairType) Get() (item interface{}, shutdown bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for {
+		if q.queued == 0 && q.shuttingDown {
+			// We are shutting down and have been drained.
+			return nil, true
+		}
+		if i := q.nextEligible(); i >= 0 {
+			return q.dequeue(i), false
+		}
+		q.cond.Wait()
+	}
+}
+
+// nextEligible returns the index in active of the first flow, starting with
+// the one whose turn is next, that may have another item processed, or -1 if
+// there is none. The caller must hold the lock.
+func (q *fairType) nextEligible() int {
+	for n := 0; n < len(q.active); n++ {
+		i := (q.next + n) % len(q.active)
+		if q.maxConcurrencyPerFlow <= 0 || q.active[i].processing < q.maxConcurrencyPerFlow {
+			return i
+		}
+	}
+	return -1
+}
+
+// dequeue takes the first item of the i-th active flow and passes the turn
+// to the flow after it. The caller must hold the lock.
+func (q *fairType) dequeue(i int) t {
+	flow := q.active[i]
+	item := flow.queue[0]
+	flow.queue = flow.queue[1:]
+	flow.processing++
+	q.queued--
+
+	if len(flow.queue) == 0 {
+		q.active = append(q.active[:i], q.active[i+1:]...)
+		q.next = i
+	} else {
+		q.next = i + 1
+	}
+	if q.next >= len(q.active) {
+		q.next = 0
+	}
+
+	q.metrics.get(item)
+
+	q.processing.insert(item)
+	q.processingStartTimes[item] = q.clock.Now()
+	q.dirty.delete(item)
+	delete(q.addTimes, item)
+
+	return item
+}
+
+// Done marks item as done processing, and if it has been marked as dirty again
+// while it was being processed, it will be re-added to the queue for
+// re-processing.
+func (q *fairType) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.metrics.done(item)
+
+	if !q.processing.has(item) {
+		return
+	}
+	q.processing.delete(item)
+	delete(q.processingStartTimes, item)
+
+	key := q.flowKey(item)
+	flow := q.flows[key]
+	flow.processing--
+	if q.dirty.has(item) {
+		q.enqueue(item)
+	} else if len(flow.queue) == 0 && flow.processing == 0 {
+		delete(q.flows, key)
+	}
+	if q.maxConcurrencyPerFlow > 0 {
+		// Waiters may have been blocked by the concurrency limit of
+		// this flow.
+		q.cond.Broadcast()
+	}
+}
+
+// ShutDown will cause q to ignore all new items added to it. As soon as the
+// worker goroutines have drained the existing items in the queue, they will be
+// instructed to exit.
+func (q *fairType) ShutDown() {
+	unregisterDebugQueue(q)
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+func (q *fairType) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.shuttingDown
+}
+
+// Snapshot returns the items in q, see Snapshotter. The queued items are
+// listed in the order Get returns them when no flow is at its concurrency
+// limit.
+func (q *fairType) Snapshot() QueueSnapshot {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	now := q.clock.Now()
+	snapshot := QueueSnapshot{}
+	for round := 0; len(snapshot.Queued) < q.queued; round++ {
+		for n := 0; n < len(q.active); n++ {
+			flow := q.active[(q.next+n)%len(q.active)]
+			if round >= len(flow.queue) {
+				continue
+			}
+			item := flow.queue[round]
+			snapshot.Queued = append(snapshot.Queued, ItemSnapshot{Item: item, Flow: flow.key, TimeInQueueSeconds: now.Sub(q.addTimes[item]).Seconds()})
+		}
+	}
+	for item := range q.processing {
+		snapshot.Processing = append(snapshot.Processing, ItemSnapshot{Item: item, Flow: q.flowKey(item), TimeInQueueSeconds: now.Sub(q.processingStartTimes[item]).Seconds()})
+	}
+	for item := range q.dirty {
+		snapshot.Dirty = append(snapshot.Dirty, ItemSnapshot{Item: item, Flow: q.flowKey(item), TimeInQueueSeconds: now.Sub(q.addTimes[item]).Seconds()})
+	}
+	return snapshot
+}
+
+func (q *fairType) updateUnfinishedWorkLoop() {
+	t := q.clock.NewTicker(q.unfinishedWorkUpdatePeriod)
+	defer t.Stop()
+	for range t.C() {
+		if !func() bool {
+			q.cond.L.Lock()
+			defer q.cond.L.Unlock()
+			if !q.shuttingDown {
+				q.metrics.updateUnfinishedWork()
+				return true
+			}
+			return false
+
+		}() {
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// testFlowKey maps "flow/name" to "flow".
+func testFlowKey(item interface{}) string {
+	return strings.SplitN(item.(string), "/", 2)[0]
+}
+
+func TestFairQueueRoundRobin(t *testing.T) {
+	q := NewFairQueue(testFlowKey, 0)
+	defer q.ShutDown()
+
+	for _, item := range []string{"a/1", "a/2", "a/3", "b/1", "c/1", "b/2"} {
+		q.Add(item)
+	}
+	if e, a := 6, q.Len(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	expected := []interface{}{"a/1", "b/1", "c/1", "a/2", "b/2", "a/3"}
+	if actual := getAll(q, len(expected)); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestFairQueueDropsIdleFlows(t *testing.T) {
+	q := newFairQueue(clock.RealClock{}, noMetrics{}, defaultUnfinishedWorkUpdatePeriod, testFlowKey, 1)
+	defer q.ShutDown()
+
+	for i := 0; i < 1000; i++ {
+		q.Add(fmt.Sprintf("%d/1", i))
+	}
+	if e, a := 1000, len(q.flows); e != a {
+		t.Errorf("expected %v flows, got %v", e, a)
+	}
+	for i := 0; i < 1000; i++ {
+		item, _ := q.Get()
+		q.Done(item)
+	}
+	if len(q.flows) != 0 || len(q.active) != 0 {
+		t.Errorf("expected the drained flows to be dropped, got %d flows and %d active ones", len(q.flows), len(q.active))
+	}
+}
+
+func TestFairQueueAddWhileProcessing(t *testing.T) {
+	q := NewFairQueue(testFlowKey, 0)
+	defer q.ShutDown()
+
+	q.Add("a/1")
+	item, _ := q.Get()
+	q.Add(item)
+	q.Add(item)
+	if e, a := 0, q.Len(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	q.Done(item)
+	if e, a := 1, q.Len(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if item, _ := q.Get(); item != "a/1" {
+		t.Errorf("expected %v, got %v", "a/1", item)
+	}
+}
+
+func TestFairQueueMaxConcurrencyPerFlow(t *testing.T) {
+	q := NewFairQueue(testFlowKey, 1)
+	defer q.ShutDown()
+
+	q.Add("a/1")
+	q.Add("a/2")
+	q.Add("b/1")
+
+	first, _ := q.Get()
+	if first != "a/1" {
+		t.Errorf("expected %v, got %v", "a/1", first)
+	}
+	// Flow "a" is at its limit, so "b" goes next.
+	if item, _ := q.Get(); item != "b/1" {
+		t.Errorf("expected %v, got %v", "b/1", item)
+	}
+
+	got := make(chan interface{})
+	go func() {
+		item, _ := q.Get()
+		got <- item
+	}()
+	select {
+	case item := <-got:
+		t.Fatalf("expected Get to block while a/1 is processing, got %v", item)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	q.Done(first)
+	select {
+	case item := <-got:
+		if item != "a/2" {
+			t.Errorf("expected %v, got %v", "a/2", item)
+		}
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatalf("expected Get to return after a/1 is done")
+	}
+}
+
+func TestFairQueueShutDown(t *testing.T) {
+	q := NewFairQueue(testFlowKey, 0)
+
+	q.Add("a/1")
+	q.ShutDown()
+	q.Add("a/2")
+
+	if item, shutdown := q.Get(); item != "a/1" || shutdown {
+		t.Errorf("expected a/1 to be drained, got %v, %v", item, shutdown)
+	}
+	if _, shutdown := q.Get(); !shutdown {
+		t.Errorf("expected shutdown")
+	}
+}
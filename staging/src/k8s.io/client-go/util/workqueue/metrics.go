@@ -153,6 +153,45 @@ func (m *defaultQueueMetrics) sinceInSeconds(start time.Time) float64 {
 	return m.clock.Since(start).Seconds()
 }
 
+// priorityMetrics tracks the depth of a priority queue per priority. It
+// expects the caller to lock before setting any metrics.
+type priorityMetrics interface {
+	add(priority int)
+	get(priority int)
+}
+
+type defaultPriorityMetrics struct {
+	name     string
+	provider PriorityMetricsProvider
+	// current depth of a workqueue per priority, created on first use
+	depth map[int]GaugeMetric
+}
+
+func (m *defaultPriorityMetrics) add(priority int) {
+	if m == nil {
+		return
+	}
+
+	m.depthMetric(priority).Inc()
+}
+
+func (m *defaultPriorityMetrics) get(priority int) {
+	if m == nil {
+		return
+	}
+
+	m.depthMetric(priority).Dec()
+}
+
+func (m *defaultPriorityMetrics) depthMetric(priority int) GaugeMetric {
+	depth, ok := m.depth[priority]
+	if !ok {
+		depth = m.provider.NewPriorityDepthMetric(m.name, priority)
+		m.depth[priority] = depth
+	}
+	return depth
+}
+
 type retryMetrics interface {
 	retry()
 }
@@ -180,6 +219,12 @@ type MetricsProvider interface {
 	NewRetriesMetric(name string) CounterMetric
 }
 
+// PriorityMetricsProvider can be implemented in addition to MetricsProvider
+// to report the depth of priority queues per priority.
+type PriorityMetricsProvider interface {
+	NewPriorityDepthMetric(name string, priority int) GaugeMetric
+}
+
 type noopMetricsProvider struct{}
 
 func (_ noopMetricsProvider) NewDepthMetric(name string) GaugeMetric {
@@ -244,6 +289,19 @@ func (f *queueMetricsFactory) newQueueMetrics(name string, clock clock.Clock) qu
 	}
 }
 
+func (f *queueMetricsFactory) newPriorityMetrics(name string) priorityMetrics {
+	var ret *defaultPriorityMetrics
+	pmp, ok := f.metricsProvider.(PriorityMetricsProvider)
+	if len(name) == 0 || !ok {
+		return ret
+	}
+	return &defaultPriorityMetrics{
+		name:     name,
+		provider: pmp,
+		depth:    map[int]GaugeMetric{},
+	}
+}
+
 func newRetryMetrics(name string) retryMetrics {
 	var ret *defaultRetryMetrics
 	if len(name) == 0 {
@@ -0,0 +1,330 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// DefaultPriority is the priority of items added through Add.
+const DefaultPriority = 0
+
+// PriorityInterface is an Interface that hands out items with a higher
+// priority before items with a lower one. Items of equal priority are handed
+// out in the order in which they were added. Like in Interface, an item is
+// queued at most once and never processed concurrently.
+type PriorityInterface interface {
+	Interface
+	// AddWithPriority marks item as needing processing at the given
+	// priority. If the item is already waiting to be processed, it keeps
+	// the higher of its current and the given priority.
+	AddWithPriority(item interface{}, priority int)
+}
+
+// NewPriorityQueue constructs a new work queue that orders items by priority.
+func NewPriorityQueue() PriorityInterface {
+	return NewNamedPriorityQueue("")
+}
+
+// NewNamedPriorityQueue constructs a new named work queue that orders items
+// by priority.
+func NewNamedPriorityQueue(name string) PriorityInterface {
+	rc := clock.RealClock{}
+	q := newPriorityQueue(
+		rc,
+		globalMetricsFactory.newQueueMetrics(name, rc),
+		globalMetricsFactory.newPriorityMetrics(name),
+		defaultUnfinishedWorkUpdatePeriod,
+	)
+	registerDebugQueue(name, q)
+	return q
+}
+
+func newPriorityQueue(c clock.Clock, metrics queueMetrics, priorityMetrics priorityMetrics, updatePeriod time.Duration) *priorityType {
+	q := &priorityType{
+		waiting:                    map[t]*prioritizedItem{},
+		dirty:                      map[t]int{},
+		processing:                 set{},
+		addTimes:                   map[t]time.Time{},
+		processingStartTimes:       map[t]time.Time{},
+		cond:                       sync.NewCond(&sync.Mutex{}),
+		metrics:                    metrics,
+		priorityMetrics:            priorityMetrics,
+		unfinishedWorkUpdatePeriod: updatePeriod,
+		clock:                      c,
+	}
+	go q.updateUnfinishedWorkLoop()
+	return q
+}
+
+// priorityType is a work queue like Type, except that the order in which
+// items are handed out is determined by their priority.
+type priorityType struct {
+	// queue defines the order in which we will work on items. Every
+	// element of queue should be in the dirty set and not in the
+	// processing set.
+	queue prioritizedItems
+	// waiting maps every item in queue to its entry.
+	waiting map[t]*prioritizedItem
+
+	// dirty maps all of the items that need to be processed to their
+	// priority.
+	dirty map[t]int
+
+	// Things that are currently being processed are in the processing set.
+	// These things may be simultaneously in the dirty set. When we finish
+	// processing something and remove it from this set, we'll check if
+	// it's in the dirty set, and if so, add it to the queue.
+	processing set
+
+	// addTimes and processingStartTimes record when the items in the
+	// dirty and processing sets got there, for Snapshot.
+	addTimes             map[t]time.Time
+	processingStartTimes map[t]time.Time
+
+	// seq is the sequence number of the last item added to queue, it
+	// keeps items of equal priority in FIFO order.
+	seq uint64
+
+	cond *sync.Cond
+
+	shuttingDown bool
+
+	metrics         queueMetrics
+	priorityMetrics priorityMetrics
+
+	unfinishedWorkUpdatePeriod time.Duration
+	clock                      clock.Clock
+}
+
+var _ PriorityInterface = &priorityType{}
+var _ Snapshotter = &priorityType{}
+
+// Add marks item as needing processing at DefaultPriority.
+func (q *priorityType) Add(item interface{}) {
+	q.AddWithPriority(item, DefaultPriority)
+}
+
+// AddWithPriority marks item as needing processing at the given priority.
+func (q *priorityType) AddWithPriority(item interface{}, priority int) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	if q.shuttingDown {
+		return
+	}
+	if current, dirty := q.dirty[item]; dirty {
+		if priority <= current {
+			return
+		}
+		q.dirty[item] = priority
+		if entry, waiting := q.waiting[item]; waiting {
+			q.priorityMetrics.get(entry.priority)
+			q.priorityMetrics.add(priority)
+			entry.priority = priority
+			heap.Fix(&q.queue, entry.index)
+		}
+		return
+	}
+
+	q.metrics.add(item)
+
+	q.dirty[item] = priority
+	q.addTimes[item] = q.clock.Now()
+	if q.processing.has(item) {
+		return
+	}
+
+	q.push(item, priority)
+}
+
+// push adds item to queue. The caller must hold the lock.
+func (q *priorityType) push(item t, priority int) {
+	q.seq++
+	entry := &prioritizedItem{data: item, priority: priority, seq: q.seq}
+	heap.Push(&q.queue, entry)
+	q.waiting[item] = entry
+	q.priorityMetrics.add(priority)
+	q.cond.Signal()
+}
+
+// Len returns the current queue length, for informational purposes only. You
+// shouldn't e.g. gate a call to Add() or Get() on Len() being a particular
+// value, that can't be synchronized properly.
+func (q *priorityType) Len() int {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	return q.queue.Len()
+}
+
+// Get blocks until it can return an item to be processed, choosing the one
+// with the highest priority. If shutdown = true, the caller should end their
+// goroutine. You must call Done with item when you have finished processing
+// it.
+func (q *priorityType) Get() (item interface{}, shutdown bool) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	for q.queue.Len() == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if q.queue.Len() == 0 {
+		// We must be shutting down.
+		return nil, true
+	}
+
+	entry := heap.Pop(&q.queue).(*prioritizedItem)
+	item = entry.data
+	delete(q.waiting, item)
+
+	q.metrics.get(item)
+	q.priorityMetrics.get(entry.priority)
+
+	q.processing.insert(item)
+	q.processingStartTimes[item] = q.clock.Now()
+	delete(q.dirty, item)
+	delete(q.addTimes, item)
+
+	return item, false
+}
+
+// Done marks item as done processing, and if it has been marked as dirty again
+// while it was being processed, it will be re-added to the queue for
+// re-processing at the highest priority it was added with since.
+func (q *priorityType) Done(item interface{}) {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	q.metrics.done(item)
+
+	q.processing.delete(item)
+	delete(q.processingStartTimes, item)
+	if priority, dirty := q.dirty[item]; dirty {
+		q.push(item, priority)
+	}
+}
+
+// ShutDown will cause q to ignore all new items added to it. As soon as the
+// worker goroutines have drained the existing items in the queue, they will be
+// instructed to exit.
+func (q *priorityType) ShutDown() {
+	unregisterDebugQueue(q)
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+func (q *priorityType) ShuttingDown() bool {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	return q.shuttingDown
+}
+
+// Snapshot returns the items in q, see Snapshotter. The queued items are
+// listed in the order Get returns them.
+func (q *priorityType) Snapshot() QueueSnapshot {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	queue := append(prioritizedItems(nil), q.queue...)
+	sort.Slice(queue, queue.Less)
+
+	now := q.clock.Now()
+	snapshot := QueueSnapshot{}
+	for _, entry := range queue {
+		snapshot.Queued = append(snapshot.Queued, ItemSnapshot{Item: entry.data, Priority: entry.priority, TimeInQueueSeconds: now.Sub(q.addTimes[entry.data]).Seconds()})
+	}
+	for item := range q.processing {
+		snapshot.Processing = append(snapshot.Processing, ItemSnapshot{Item: item, TimeInQueueSeconds: now.Sub(q.processingStartTimes[item]).Seconds()})
+	}
+	for item, priority := range q.dirty {
+		snapshot.Dirty = append(snapshot.Dirty, ItemSnapshot{Item: item, Priority: priority, TimeInQueueSeconds: now.Sub(q.addTimes[item]).Seconds()})
+	}
+	return snapshot
+}
+
+func (q *priorityType) updateUnfinishedWorkLoop() {
+	t := q.clock.NewTicker(q.unfinishedWorkUpdatePeriod)
+	defer t.Stop()
+	for range t.C() {
+		if !func() bool {
+			q.cond.L.Lock()
+			defer q.cond.L.Unlock()
+			if !q.shuttingDown {
+				q.metrics.updateUnfinishedWork()
+				return true
+			}
+			return false
+
+		}() {
+			return
+		}
+	}
+}
+
+// prioritizedItem holds an item waiting in a priorityType.
+type prioritizedItem struct {
+	data     t
+	priority int
+	// seq orders items of equal priority
+	seq uint64
+	// index in the priority queue (heap)
+	index int
+}
+
+// prioritizedItems implements heap.Interface. The item with the highest
+// priority that was added first is at the root (index 0).
+type prioritizedItems []*prioritizedItem
+
+func (pq prioritizedItems) Len() int {
+	return len(pq)
+}
+func (pq prioritizedItems) Less(i, j int) bool {
+	if pq[i].priority != pq[j].priority {
+		return pq[i].priority > pq[j].priority
+	}
+	return pq[i].seq < pq[j].seq
+}
+func (pq prioritizedItems) Swap(i, j int) {
+	pq[i], pq[j] = pq[j], pq[i]
+	pq[i].index = i
+	pq[j].index = j
+}
+
+// Push adds an item to the queue. Push should not be called directly; instead,
+// use `heap.Push`.
+func (pq *prioritizedItems) Push(x interface{}) {
+	n := len(*pq)
+	item := x.(*prioritizedItem)
+	item.index = n
+	*pq = append(*pq, item)
+}
+
+// Pop removes an item from the queue. Pop should not be called directly;
+// instead, use `heap.Pop`.
+func (pq *prioritizedItems) Pop() interface{} {
+	n := len(*pq)
+	item := (*pq)[n-1]
+	item.index = -1
+	*pq = (*pq)[0:(n - 1)]
+	return item
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func getAll(q Interface, n int) []interface{} {
+	var items []interface{}
+	for i := 0; i < n; i++ {
+		item, _ := q.Get()
+		items = append(items, item)
+		q.Done(item)
+	}
+	return items
+}
+
+func TestPriorityQueueOrder(t *testing.T) {
+	q := NewPriorityQueue()
+	defer q.ShutDown()
+
+	q.Add("a")
+	q.AddWithPriority("b", 5)
+	q.Add("c")
+	q.AddWithPriority("d", 5)
+	q.AddWithPriority("e", -1)
+
+	expected := []interface{}{"b", "d", "a", "c", "e"}
+	if actual := getAll(q, len(expected)); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestPriorityQueueDeduping(t *testing.T) {
+	q := NewPriorityQueue()
+	defer q.ShutDown()
+
+	q.Add("a")
+	q.Add("b")
+	// Raises the priority of the waiting "b".
+	q.AddWithPriority("b", 3)
+	// Doesn't lower it again.
+	q.Add("b")
+	if e, a := 2, q.Len(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	expected := []interface{}{"b", "a"}
+	if actual := getAll(q, len(expected)); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestPriorityQueueAddWhileProcessing(t *testing.T) {
+	q := NewPriorityQueue()
+	defer q.ShutDown()
+
+	q.Add("a")
+	item, _ := q.Get()
+	q.Add("b")
+	q.AddWithPriority(item, 7)
+	if e, a := 1, q.Len(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	q.Done(item)
+
+	// "a" was re-added with a higher priority while processing.
+	expected := []interface{}{"a", "b"}
+	if actual := getAll(q, len(expected)); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestPriorityDelayingQueue(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Now())
+	q := newDelayingQueue(fakeClock, NewPriorityQueue(), "", nil)
+	defer q.ShutDown()
+
+	q.AddAfterWithPriority("low", 0, 50*time.Millisecond)
+	q.AddAfterWithPriority("high", 9, 50*time.Millisecond)
+	// Adding again with a lower priority keeps the higher one.
+	q.AddAfterWithPriority("high", 1, 10*time.Millisecond)
+	if err := waitForWaitingQueueToFill(q); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	fakeClock.Step(60 * time.Millisecond)
+	if err := waitForAdded(q, 2); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	expected := []interface{}{"high", "low"}
+	if actual := getAll(q, len(expected)); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+type testPriorityMetricsProvider struct {
+	testMetricsProvider
+	priorityDepth map[int]*testMetric
+}
+
+func (m *testPriorityMetricsProvider) NewPriorityDepthMetric(name string, priority int) GaugeMetric {
+	metric := &testMetric{}
+	m.priorityDepth[priority] = metric
+	return metric
+}
+
+func TestPriorityQueueMetrics(t *testing.T) {
+	mp := &testPriorityMetricsProvider{priorityDepth: map[int]*testMetric{}}
+	c := clock.NewFakeClock(time.Now())
+	mf := queueMetricsFactory{metricsProvider: mp}
+	q := newPriorityQueue(c, mf.newQueueMetrics("test", c), mf.newPriorityMetrics("test"), time.Millisecond)
+	defer q.ShutDown()
+
+	q.Add("a")
+	q.Add("b")
+	q.AddWithPriority("c", 2)
+	if e, a := 3.0, mp.depth.gaugeValue(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 2.0, mp.priorityDepth[0].gaugeValue(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 1.0, mp.priorityDepth[2].gaugeValue(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	// Raising the priority of a waiting item moves it between depths.
+	q.AddWithPriority("b", 2)
+	if e, a := 1.0, mp.priorityDepth[0].gaugeValue(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 2.0, mp.priorityDepth[2].gaugeValue(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+
+	getAll(q, 2)
+	if e, a := 0.0, mp.priorityDepth[2].gaugeValue(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 1.0, mp.depth.gaugeValue(); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
@@ -51,6 +51,8 @@ func newQueue(c clock.Clock, metrics queueMetrics, updatePeriod time.Duration) *
 		clock:                      c,
 		dirty:                      set{},
 		processing:                 set{},
+		addTimes:                   map[t]time.Time{},
+		processingStartTimes:       map[t]time.Time{},
 		cond:                       sync.NewCond(&sync.Mutex{}),
 		metrics:                    metrics,
 		unfinishedWorkUpdatePeriod: updatePeriod,
@@ -77,6 +79,11 @@ type Type struct {
 	// it's in the dirty set, and if so, add it to the queue.
 	processing set
 
+	// addTimes and processingStartTimes record when the items in the
+	// dirty and processing sets got there, for Snapshot.
+	addTimes             map[t]time.Time
+	processingStartTimes map[t]time.Time
+
 	cond *sync.Cond
 
 	shuttingDown bool
@@ -118,6 +125,7 @@ func (q *Type) Add(item interface{}) {
 	q.metrics.add(item)
 
 	q.dirty.insert(item)
+	q.addTimes[item] = q.clock.Now()
 	if q.processing.has(item) {
 		return
 	}
@@ -154,7 +162,9 @@ func (q *Type) Get() (item interface{}, shutdown bool) {
 	q.metrics.get(item)
 
 	q.processing.insert(item)
+	q.processingStartTimes[item] = q.clock.Now()
 	q.dirty.delete(item)
+	delete(q.addTimes, item)
 
 	return item, false
 }
@@ -169,6 +179,7 @@ func (q *Type) Done(item interface{}) {
 	q.metrics.done(item)
 
 	q.processing.delete(item)
+	delete(q.processingStartTimes, item)
 	if q.dirty.has(item) {
 		q.queue = append(q.queue, item)
 		q.cond.Signal()
@@ -192,6 +203,25 @@ func (q *Type) ShuttingDown() bool {
 	return q.shuttingDown
 }
 
+// Snapshot returns the items in q, see Snapshotter.
+func (q *Type) Snapshot() QueueSnapshot {
+	q.cond.L.Lock()
+	defer q.cond.L.Unlock()
+
+	now := q.clock.Now()
+	snapshot := QueueSnapshot{}
+	for _, item := range q.queue {
+		snapshot.Queued = append(snapshot.Queued, ItemSnapshot{Item: item, TimeInQueueSeconds: now.Sub(q.addTimes[item]).Seconds()})
+	}
+	for item := range q.processing {
+		snapshot.Processing = append(snapshot.Processing, ItemSnapshot{Item: item, TimeInQueueSeconds: now.Sub(q.processingStartTimes[item]).Seconds()})
+	}
+	for item := range q.dirty {
+		snapshot.Dirty = append(snapshot.Dirty, ItemSnapshot{Item: item, TimeInQueueSeconds: now.Sub(q.addTimes[item]).Seconds()})
+	}
+	return snapshot
+}
+
 func (q *Type) updateUnfinishedWorkLoop() {
 	t := q.clock.NewTicker(q.unfinishedWorkUpdatePeriod)
 	defer t.Stop()
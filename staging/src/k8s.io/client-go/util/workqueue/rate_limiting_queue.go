@@ -16,6 +16,8 @@ limitations under the License.
 
 package workqueue
 
+import "k8s.io/apimachinery/pkg/util/clock"
+
 // RateLimitingInterface is an interface that rate limits items being added to the queue.
 type RateLimitingInterface interface {
 	DelayingInterface
@@ -41,11 +43,36 @@ func NewRateLimitingQueue(rateLimiter RateLimiter) RateLimitingInterface {
 	}
 }
 
+// NewNamedRateLimitingQueue constructs a new named workqueue with rateLimited
+// queuing ability. Until it is shut down, the queue can be inspected through
+// the handler returned by NewDebugHandler.
+// Remember to call Forget!  If you don't, you may end up tracking failures forever.
 func NewNamedRateLimitingQueue(rateLimiter RateLimiter, name string) RateLimitingInterface {
-	return &rateLimitingType{
+	q := &rateLimitingType{
 		DelayingInterface: NewNamedDelayingQueue(name),
 		rateLimiter:       rateLimiter,
 	}
+	registerDebugQueue(name, q)
+	return q
+}
+
+// NewNamedRateLimitingQueueWithStorage constructs a new named workqueue with
+// rateLimited queuing ability, whose waiting items are persisted in storage,
+// see NewNamedDelayingQueueWithStorage. To persist the failure counts as well,
+// use a rate limiter constructed with the same storage, e.g. by
+// NewItemExponentialFailureRateLimiterWithStorage.
+// Remember to call Forget!  If you don't, you may end up tracking failures forever.
+func NewNamedRateLimitingQueueWithStorage(rateLimiter RateLimiter, name string, storage Storage) (RateLimitingInterface, error) {
+	q, err := NewNamedDelayingQueueWithStorage(name, storage)
+	if err != nil {
+		return nil, err
+	}
+	ret := &rateLimitingType{
+		DelayingInterface: q,
+		rateLimiter:       rateLimiter,
+	}
+	registerDebugQueue(name, ret)
+	return ret, nil
 }
 
 // rateLimitingType wraps an Interface and provides rateLimited re-enquing
@@ -67,3 +94,102 @@ func (q *rateLimitingType) NumRequeues(item interface{}) int {
 func (q *rateLimitingType) Forget(item interface{}) {
 	q.rateLimiter.Forget(item)
 }
+
+// ShutDown shuts down the wrapped queue and stops exposing it for debugging.
+func (q *rateLimitingType) ShutDown() {
+	unregisterDebugQueue(q)
+	q.DelayingInterface.ShutDown()
+}
+
+// Snapshot returns the items in the wrapped queue, if it is a Snapshotter,
+// together with the number of times they were requeued.
+func (q *rateLimitingType) Snapshot() QueueSnapshot {
+	return snapshotWithRequeues(q.DelayingInterface, q.rateLimiter)
+}
+
+// snapshotWithRequeues returns the items in q, if it is a Snapshotter,
+// together with the number of times rateLimiter saw them requeued.
+func snapshotWithRequeues(q interface{}, rateLimiter RateLimiter) QueueSnapshot {
+	var snapshot QueueSnapshot
+	if s, ok := q.(Snapshotter); ok {
+		snapshot = s.Snapshot()
+	}
+	for _, items := range [][]ItemSnapshot{snapshot.Queued, snapshot.Processing, snapshot.Dirty, snapshot.Waiting} {
+		for i := range items {
+			items[i].Requeues = rateLimiter.NumRequeues(items[i].Item)
+		}
+	}
+	return snapshot
+}
+
+// PriorityRateLimitingInterface is a RateLimitingInterface that hands out
+// items by priority, see PriorityInterface.
+type PriorityRateLimitingInterface interface {
+	RateLimitingInterface
+	PriorityDelayingInterface
+
+	// AddRateLimitedWithPriority adds an item to the workqueue at the given
+	// priority after the rate limiter says it's ok
+	AddRateLimitedWithPriority(item interface{}, priority int)
+}
+
+// NewNamedPriorityRateLimitingQueue constructs a new named workqueue with
+// rateLimited queuing ability that hands out items by priority. Until it is
+// shut down, the queue can be inspected through the handler returned by
+// NewDebugHandler.
+// Remember to call Forget!  If you don't, you may end up tracking failures forever.
+func NewNamedPriorityRateLimitingQueue(rateLimiter RateLimiter, name string) PriorityRateLimitingInterface {
+	// The priority queue isn't registered itself, its snapshots lack the
+	// requeues and the waiting items.
+	rc := clock.RealClock{}
+	pq := newPriorityQueue(
+		rc,
+		globalMetricsFactory.newQueueMetrics(name, rc),
+		globalMetricsFactory.newPriorityMetrics(name),
+		defaultUnfinishedWorkUpdatePeriod,
+	)
+	q := &priorityRateLimitingType{
+		PriorityDelayingInterface: newDelayingQueue(rc, pq, name, nil),
+		rateLimiter:               rateLimiter,
+	}
+	registerDebugQueue(name, q)
+	return q
+}
+
+// priorityRateLimitingType wraps a PriorityDelayingInterface and provides
+// rateLimited re-enquing
+type priorityRateLimitingType struct {
+	PriorityDelayingInterface
+
+	rateLimiter RateLimiter
+}
+
+// AddRateLimited AddAfter's the item at DefaultPriority based on the time when the rate limiter says it's ok
+func (q *priorityRateLimitingType) AddRateLimited(item interface{}) {
+	q.AddRateLimitedWithPriority(item, DefaultPriority)
+}
+
+// AddRateLimitedWithPriority AddAfterWithPriority's the item based on the time when the rate limiter says it's ok
+func (q *priorityRateLimitingType) AddRateLimitedWithPriority(item interface{}, priority int) {
+	q.PriorityDelayingInterface.AddAfterWithPriority(item, priority, q.rateLimiter.When(item))
+}
+
+func (q *priorityRateLimitingType) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+func (q *priorityRateLimitingType) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+// ShutDown shuts down the wrapped queue and stops exposing it for debugging.
+func (q *priorityRateLimitingType) ShutDown() {
+	unregisterDebugQueue(q)
+	q.PriorityDelayingInterface.ShutDown()
+}
+
+// Snapshot returns the items in the wrapped queue together with the number
+// of times they were requeued.
+func (q *priorityRateLimitingType) Snapshot() QueueSnapshot {
+	return snapshotWithRequeues(q.PriorityDelayingInterface, q.rateLimiter)
+}
@@ -0,0 +1,416 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Storage persists the state of a work queue that would otherwise be lost
+// when the process exits: the items waiting to be added by a delaying queue
+// and the failure counts of a rate limiter. Only items that are strings, like
+// the keys produced by cache.MetaNamespaceKeyFunc, are persisted. A Storage
+// should back at most one delaying queue and one rate limiter.
+type Storage interface {
+	// SetWaiting records that item is to be added at the given priority
+	// once readyAt has passed.
+	SetWaiting(item string, priority int, readyAt time.Time) error
+	// DeleteWaiting records that item is no longer waiting.
+	DeleteWaiting(item string) error
+	// SetFailures records the number of failures of item. Zero failures
+	// means the item is forgotten.
+	SetFailures(item string, failures int) error
+	// Load returns everything recorded so far.
+	Load() (*StorageState, error)
+}
+
+// StorageState is the state recorded in a Storage.
+type StorageState struct {
+	// Waiting holds the items waiting to be added by a delaying queue.
+	Waiting map[string]WaitingItem
+	// Failures holds the failure counts of a rate limiter.
+	Failures map[string]int
+}
+
+// WaitingItem describes when and how an item is to be added to a queue.
+type WaitingItem struct {
+	Priority int
+	ReadyAt  time.Time
+}
+
+func newStorageState() *StorageState {
+	return &StorageState{
+		Waiting:  map[string]WaitingItem{},
+		Failures: map[string]int{},
+	}
+}
+
+// storageRecord is a single entry of the log kept by FileStorage.
+type storageRecord struct {
+	Op       string    `json:"op"`
+	Item     string    `json:"item"`
+	Priority int       `json:"priority,omitempty"`
+	ReadyAt  time.Time `json:"readyAt,omitempty"`
+	Failures int       `json:"failures,omitempty"`
+}
+
+const (
+	opSetWaiting    = "setWaiting"
+	opDeleteWaiting = "deleteWaiting"
+	opSetFailures   = "setFailures"
+)
+
+func (s *StorageState) apply(r *storageRecord) error {
+	switch r.Op {
+	case opSetWaiting:
+		s.Waiting[r.Item] = WaitingItem{Priority: r.Priority, ReadyAt: r.ReadyAt}
+	case opDeleteWaiting:
+		delete(s.Waiting, r.Item)
+	case opSetFailures:
+		if r.Failures == 0 {
+			delete(s.Failures, r.Item)
+		} else {
+			s.Failures[r.Item] = r.Failures
+		}
+	default:
+		return fmt.Errorf("unknown operation %q", r.Op)
+	}
+	return nil
+}
+
+// minCompactRecords is the least number of records FileStorage appends to
+// its log before compacting it.
+const minCompactRecords = 1000
+
+// fileStorageSyncPeriod is how often a buffered FileStorage writes the
+// changes it buffered to its log and syncs it to disk.
+const fileStorageSyncPeriod = 100 * time.Millisecond
+
+// FileStorage is a Storage that keeps an append-only log in a local file.
+// By default every change is appended and synced to disk before the call
+// recording it returns, so that nothing recorded is lost on a crash. A
+// buffered FileStorage instead appends and syncs the changes in the
+// background every fileStorageSyncPeriod, and on Flush and Close, so that
+// the queue and the rate limiter never wait for the disk, at the price of
+// losing the changes made since the last sync on a crash. The log is
+// compacted, i.e. rewritten to hold only the current state, when it is
+// opened and whenever it has grown to more than twice the size the current
+// state needs.
+type FileStorage struct {
+	// lock guards state, pending, records and closed. It is never held
+	// while writing to the disk.
+	lock  sync.Mutex
+	state *StorageState
+	// pending holds the encoded records that are not written yet.
+	pending bytes.Buffer
+	// records is the number of records in the log, including the pending
+	// ones.
+	records int
+	closed  bool
+
+	// fileLock serializes the writes to the log, and guards flushErr.
+	fileLock sync.Mutex
+	path     string
+	file     *os.File
+	// flushErr is the result of the last write to the log. It is returned
+	// to the callers whose changes were written by another caller's Flush.
+	flushErr error
+
+	// buffered is set if the changes are written in the background.
+	buffered bool
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+var _ Storage = &FileStorage{}
+
+// NewFileStorage opens the log at path, creating it if needed. A record that
+// was only partially written when the process crashed is ignored. Changes
+// are synced to disk before the calls recording them return.
+func NewFileStorage(path string) (*FileStorage, error) {
+	return newFileStorage(path, false)
+}
+
+// NewBufferedFileStorage is like NewFileStorage, except that changes are
+// synced to disk in the background, so the changes made in the last
+// fileStorageSyncPeriod before a crash are lost.
+func NewBufferedFileStorage(path string) (*FileStorage, error) {
+	return newFileStorage(path, true)
+}
+
+func newFileStorage(path string, buffered bool) (*FileStorage, error) {
+	s := &FileStorage{
+		path:     path,
+		state:    newStorageState(),
+		buffered: buffered,
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	if err := s.read(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(s.stateRecords()); err != nil {
+		return nil, err
+	}
+	if buffered {
+		go s.syncLoop()
+	} else {
+		close(s.doneCh)
+	}
+	return s, nil
+}
+
+func (s *FileStorage) syncLoop() {
+	defer close(s.doneCh)
+	wait.Until(func() {
+		if err := s.Flush(); err != nil {
+			utilruntime.HandleError(fmt.Errorf("unable to write %s: %v", s.path, err))
+		}
+	}, fileStorageSyncPeriod, s.stopCh)
+}
+
+func (s *FileStorage) read() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// Anything after the last newline is a torn write.
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		record := &storageRecord{}
+		if err := json.Unmarshal(line, record); err != nil {
+			return fmt.Errorf("unable to decode record from %s: %v", s.path, err)
+		}
+		if err := s.state.apply(record); err != nil {
+			return fmt.Errorf("unable to apply record from %s: %v", s.path, err)
+		}
+	}
+}
+
+// stateRecords returns the records that make up the current state. The
+// caller must hold the lock, or be the only user of s.
+func (s *FileStorage) stateRecords() []*storageRecord {
+	var records []*storageRecord
+	for item, waiting := range s.state.Waiting {
+		records = append(records, &storageRecord{Op: opSetWaiting, Item: item, Priority: waiting.Priority, ReadyAt: waiting.ReadyAt})
+	}
+	for item, failures := range s.state.Failures {
+		records = append(records, &storageRecord{Op: opSetFailures, Item: item, Failures: failures})
+	}
+	return records
+}
+
+// compact replaces the log by one that holds just the given records. The
+// caller must hold the fileLock, or be the only user of s.
+func (s *FileStorage) compact(records []*storageRecord) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, record := range records {
+		data, err := encodeStorageRecord(record)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0600)
+	return err
+}
+
+// Flush writes the buffered changes to the log and syncs it to disk,
+// compacting the log if it has grown too much.
+func (s *FileStorage) Flush() error {
+	s.fileLock.Lock()
+	defer s.fileLock.Unlock()
+
+	s.lock.Lock()
+	if s.file == nil {
+		s.lock.Unlock()
+		return fmt.Errorf("storage %s is closed", s.path)
+	}
+	var compacted []*storageRecord
+	live := len(s.state.Waiting) + len(s.state.Failures)
+	if s.records >= minCompactRecords && s.records > 2*live {
+		// The state includes the pending changes.
+		compacted = s.stateRecords()
+		s.records = len(compacted)
+		s.pending.Reset()
+	}
+	pending := append([]byte(nil), s.pending.Bytes()...)
+	s.pending.Reset()
+	s.lock.Unlock()
+
+	if compacted != nil {
+		s.flushErr = s.compact(compacted)
+		return s.flushErr
+	}
+	if len(pending) == 0 {
+		return s.flushErr
+	}
+	if _, err := s.file.Write(pending); err != nil {
+		s.flushErr = err
+		return err
+	}
+	s.flushErr = s.file.Sync()
+	return s.flushErr
+}
+
+func encodeStorageRecord(record *storageRecord) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(record); err != nil {
+		return nil, fmt.Errorf("unable to encode record: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// append applies record to the state and buffers it to be appended to the
+// log. Unless s is buffered, it is written and synced before append
+// returns, along with the records buffered by concurrent callers.
+func (s *FileStorage) append(record *storageRecord) error {
+	if err := s.buffer(record); err != nil {
+		return err
+	}
+	if s.buffered {
+		return nil
+	}
+	return s.Flush()
+}
+
+func (s *FileStorage) buffer(record *storageRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("storage %s is closed", s.path)
+	}
+	if err := s.state.apply(record); err != nil {
+		return err
+	}
+	data, err := encodeStorageRecord(record)
+	if err != nil {
+		return err
+	}
+	s.pending.Write(data)
+	s.records++
+	return nil
+}
+
+// SetWaiting records that item is to be added once readyAt has passed.
+func (s *FileStorage) SetWaiting(item string, priority int, readyAt time.Time) error {
+	return s.append(&storageRecord{Op: opSetWaiting, Item: item, Priority: priority, ReadyAt: readyAt})
+}
+
+// DeleteWaiting records that item is no longer waiting.
+func (s *FileStorage) DeleteWaiting(item string) error {
+	return s.append(&storageRecord{Op: opDeleteWaiting, Item: item})
+}
+
+// SetFailures records the number of failures of item.
+func (s *FileStorage) SetFailures(item string, failures int) error {
+	return s.append(&storageRecord{Op: opSetFailures, Item: item, Failures: failures})
+}
+
+// Load returns a copy of the current state.
+func (s *FileStorage) Load() (*StorageState, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	state := newStorageState()
+	for item, waiting := range s.state.Waiting {
+		state.Waiting[item] = waiting
+	}
+	for item, failures := range s.state.Failures {
+		state.Failures[item] = failures
+	}
+	return state, nil
+}
+
+// Close writes the buffered changes and closes the log. Later changes fail.
+func (s *FileStorage) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.lock.Unlock()
+
+	close(s.stopCh)
+	<-s.doneCh
+	flushErr := s.Flush()
+
+	s.fileLock.Lock()
+	defer s.fileLock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	err := s.file.Close()
+	s.file = nil
+	if flushErr != nil {
+		return flushErr
+	}
+	return err
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workqueue
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func newTestFileStorage(t *testing.T, path string) *FileStorage {
+	s, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestFileStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workqueue-storage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	readyAt := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	s := newTestFileStorage(t, path)
+	s.SetWaiting("a", 0, readyAt)
+	s.SetWaiting("b", 3, readyAt.Add(time.Minute))
+	s.DeleteWaiting("a")
+	s.SetFailures("a", 2)
+	s.SetFailures("b", 1)
+	s.SetFailures("b", 0)
+	s.Close()
+
+	// Simulate a crash in the middle of appending a record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.WriteString(`{"op":"setFailures","item":"c"`)
+	f.Close()
+
+	s = newTestFileStorage(t, path)
+	defer s.Close()
+	state, err := s.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &StorageState{
+		Waiting:  map[string]WaitingItem{"b": {Priority: 3, ReadyAt: readyAt.Add(time.Minute)}},
+		Failures: map[string]int{"a": 2},
+	}
+	if !reflect.DeepEqual(expected, state) {
+		t.Errorf("expected %#v, got %#v", expected, state)
+	}
+}
+
+func TestFileStorageCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workqueue-storage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	s := newTestFileStorage(t, path)
+	defer s.Close()
+	for i := 0; i < 3*minCompactRecords; i++ {
+		s.SetFailures(fmt.Sprintf("item-%d", i%10), i+1)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if records := bytes.Count(data, []byte("\n")); records >= minCompactRecords {
+		t.Errorf("expected the log to be compacted, it has %d records", records)
+	}
+	state, _ := s.Load()
+	if e, a := 3*minCompactRecords, state.Failures["item-9"]; e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
+
+func TestFileStorageSurvivesCrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workqueue-storage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	// The first storage is never closed, as if the process crashed right
+	// after the change was recorded.
+	readyAt := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	crashed := newTestFileStorage(t, path)
+	if err := crashed.SetWaiting("a", 1, readyAt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := newTestFileStorage(t, path)
+	defer s.Close()
+	state, err := s.Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := map[string]WaitingItem{"a": {Priority: 1, ReadyAt: readyAt}}, state.Waiting; !reflect.DeepEqual(e, a) {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
+
+func TestFileStorageSyncsInBackground(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workqueue-storage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	s, err := NewBufferedFileStorage(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer s.Close()
+	if err := s.SetFailures("foo", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = wait.PollImmediate(10*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		data, err := ioutil.ReadFile(path)
+		return bytes.Contains(data, []byte(`"foo"`)), err
+	})
+	if err != nil {
+		t.Errorf("expected the change to be written without a Flush: %v", err)
+	}
+}
+
+func TestDelayingQueueWithStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workqueue-storage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	fakeClock := clock.NewFakeClock(time.Now())
+	s := newTestFileStorage(t, path)
+	q, err := newDelayingQueueWithStorage(fakeClock, New(), "", s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q.AddAfter("foo", 30*time.Minute)
+	if err := waitForWaitingQueueToFill(q); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	// Crash, and restart.
+	q.ShutDown()
+	s.Close()
+
+	s = newTestFileStorage(t, path)
+	defer s.Close()
+	q, err = newDelayingQueueWithStorage(fakeClock, New(), "", s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer q.ShutDown()
+	if err := waitForWaitingQueueToFill(q); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if q.Len() != 0 {
+		t.Errorf("should not have added")
+	}
+
+	fakeClock.Step(31 * time.Minute)
+	if err := waitForAdded(q, 1); err != nil {
+		t.Fatalf("should have added")
+	}
+	if item, _ := q.Get(); item != "foo" {
+		t.Errorf("expected %v, got %v", "foo", item)
+	}
+	state, _ := s.Load()
+	if len(state.Waiting) != 0 {
+		t.Errorf("expected no waiting items to be stored, got %v", state.Waiting)
+	}
+}
+
+func TestRateLimiterWithStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workqueue-storage")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue.log")
+
+	s := newTestFileStorage(t, path)
+	limiter, err := NewItemExponentialFailureRateLimiterWithStorage(time.Millisecond, time.Second, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limiter.When("one")
+	limiter.When("one")
+	limiter.When("two")
+	limiter.Forget("two")
+	s.Close()
+
+	s = newTestFileStorage(t, path)
+	defer s.Close()
+	limiter, err = NewItemExponentialFailureRateLimiterWithStorage(time.Millisecond, time.Second, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e, a := 2, limiter.NumRequeues("one"); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 0, limiter.NumRequeues("two"); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+	if e, a := 4*time.Millisecond, limiter.When("one"); e != a {
+		t.Errorf("expected %v, got %v", e, a)
+	}
+}
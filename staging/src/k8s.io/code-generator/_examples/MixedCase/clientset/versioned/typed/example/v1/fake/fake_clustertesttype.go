@@ -111,7 +111,7 @@ func (c *FakeClusterTestTypes) UpdateStatus(ctx context.Context, clusterTestType
 // Delete takes name of the clusterTestType and deletes it. Returns an error if one occurs.
 func (c *FakeClusterTestTypes) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewRootDeleteAction(clustertesttypesResource, name), &examplev1.ClusterTestType{})
+		Invokes(testing.NewRootDeleteActionWithOptions(clustertesttypesResource, name, opts), &examplev1.ClusterTestType{})
 	return err
 }
 
@@ -126,7 +126,7 @@ func (c *FakeClusterTestTypes) DeleteCollection(ctx context.Context, opts v1.Del
 // Patch applies the patch and returns the patched clusterTestType.
 func (c *FakeClusterTestTypes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *examplev1.ClusterTestType, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewRootPatchSubresourceAction(clustertesttypesResource, name, pt, data, subresources...), &examplev1.ClusterTestType{})
+		Invokes(testing.NewRootPatchSubresourceActionWithOptions(clustertesttypesResource, name, pt, data, opts, subresources...), &examplev1.ClusterTestType{})
 	if obj == nil {
 		return nil, err
 	}
@@ -51,7 +51,8 @@ func NewSimpleClientset(objects ...runtime.Object) *Clientset {
 	cs.AddWatchReactor("*", func(action testing.Action) (handled bool, ret watch.Interface, err error) {
 		gvr := action.GetResource()
 		ns := action.GetNamespace()
-		watch, err := o.Watch(gvr, ns)
+		watchAction, _ := action.(testing.WatchActionImpl)
+		watch, err := o.Watch(gvr, ns, watchAction.ListOptions)
 		if err != nil {
 			return false, nil, err
 		}
@@ -110,7 +110,7 @@ func (c *FakeTestTypes) UpdateStatus(ctx context.Context, testType *example2.Tes
 // Delete takes name of the testType and deletes it. Returns an error if one occurs.
 func (c *FakeTestTypes) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewRootDeleteAction(testtypesResource, name), &example2.TestType{})
+		Invokes(testing.NewRootDeleteActionWithOptions(testtypesResource, name, opts), &example2.TestType{})
 	return err
 }
 
@@ -125,7 +125,7 @@ func (c *FakeTestTypes) DeleteCollection(ctx context.Context, opts v1.DeleteOpti
 // Patch applies the patch and returns the patched testType.
 func (c *FakeTestTypes) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *example2.TestType, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewRootPatchSubresourceAction(testtypesResource, name, pt, data, subresources...), &example2.TestType{})
+		Invokes(testing.NewRootPatchSubresourceActionWithOptions(testtypesResource, name, pt, data, opts, subresources...), &example2.TestType{})
 	if obj == nil {
 		return nil, err
 	}
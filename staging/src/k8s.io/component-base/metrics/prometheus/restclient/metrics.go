@@ -47,6 +47,14 @@ var (
 		[]string{"verb", "url"},
 	)
 
+	rateLimiterQPS = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Name: "rest_client_rate_limiter_qps",
+			Help: "QPS chosen by adaptive client side rate limiters. Broken down by rate limiter name.",
+		},
+		[]string{"name"},
+	)
+
 	requestResult = k8smetrics.NewCounterVec(
 		&k8smetrics.CounterOpts{
 			Name: "rest_client_requests_total",
@@ -55,6 +63,22 @@ var (
 		[]string{"code", "method", "host"},
 	)
 
+	circuitBreakerState = k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Name: "rest_client_circuit_breaker_state",
+			Help: "State of client side circuit breakers, 1 for the current state and 0 for the others. Broken down by host, verb and state.",
+		},
+		[]string{"host", "verb", "state"},
+	)
+
+	circuitBreakerRejected = k8smetrics.NewCounterVec(
+		&k8smetrics.CounterOpts{
+			Name: "rest_client_circuit_breaker_rejected_requests_total",
+			Help: "Number of requests rejected by client side circuit breakers, partitioned by host and verb.",
+		},
+		[]string{"host", "verb"},
+	)
+
 	execPluginCertTTLAdapter = &expiryToTTLAdapter{}
 
 	execPluginCertTTL = k8smetrics.NewGaugeFunc(
@@ -109,14 +133,20 @@ func init() {
 
 	legacyregistry.MustRegister(requestLatency)
 	legacyregistry.MustRegister(requestResult)
+	legacyregistry.MustRegister(rateLimiterQPS)
 	legacyregistry.RawMustRegister(execPluginCertTTL)
 	legacyregistry.MustRegister(execPluginCertRotation)
+	legacyregistry.MustRegister(circuitBreakerState)
+	legacyregistry.MustRegister(circuitBreakerRejected)
 	metrics.Register(metrics.RegisterOpts{
-		ClientCertExpiry:      execPluginCertTTLAdapter,
-		ClientCertRotationAge: &rotationAdapter{m: execPluginCertRotation},
-		RequestLatency:        &latencyAdapter{m: requestLatency},
-		RateLimiterLatency:    &latencyAdapter{m: rateLimiterLatency},
-		RequestResult:         &resultAdapter{requestResult},
+		ClientCertExpiry:       execPluginCertTTLAdapter,
+		ClientCertRotationAge:  &rotationAdapter{m: execPluginCertRotation},
+		RequestLatency:         &latencyAdapter{m: requestLatency},
+		RateLimiterLatency:     &latencyAdapter{m: rateLimiterLatency},
+		RateLimiterQPS:         &qpsAdapter{m: rateLimiterQPS},
+		RequestResult:          &resultAdapter{requestResult},
+		CircuitBreakerState:    &circuitStateAdapter{circuitBreakerState},
+		CircuitBreakerRejected: &circuitRejectedAdapter{circuitBreakerRejected},
 	})
 }
 
@@ -128,6 +158,14 @@ func (l *latencyAdapter) Observe(verb string, u url.URL, latency time.Duration)
 	l.m.WithLabelValues(verb, u.String()).Observe(latency.Seconds())
 }
 
+type qpsAdapter struct {
+	m *k8smetrics.GaugeVec
+}
+
+func (q *qpsAdapter) Set(name string, qps float64) {
+	q.m.WithLabelValues(name).Set(qps)
+}
+
 type resultAdapter struct {
 	m *k8smetrics.CounterVec
 }
@@ -136,6 +174,31 @@ func (r *resultAdapter) Increment(code, method, host string) {
 	r.m.WithLabelValues(code, method, host).Inc()
 }
 
+// circuitStates are the states a circuit breaker reports.
+var circuitStates = []string{"closed", "open", "half-open"}
+
+type circuitStateAdapter struct {
+	m *k8smetrics.GaugeVec
+}
+
+func (c *circuitStateAdapter) Set(host, verb, state string) {
+	for _, s := range circuitStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		c.m.WithLabelValues(host, verb, s).Set(value)
+	}
+}
+
+type circuitRejectedAdapter struct {
+	m *k8smetrics.CounterVec
+}
+
+func (c *circuitRejectedAdapter) Increment(host, verb string) {
+	c.m.WithLabelValues(host, verb).Inc()
+}
+
 type expiryToTTLAdapter struct {
 	e *time.Time
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package workqueue
 
 import (
+	"strconv"
+
 	"k8s.io/client-go/util/workqueue"
 	k8smetrics "k8s.io/component-base/metrics"
 	"k8s.io/component-base/metrics/legacyregistry"
@@ -35,6 +37,7 @@ const (
 	UnfinishedWorkKey          = "unfinished_work_seconds"
 	LongestRunningProcessorKey = "longest_running_processor_seconds"
 	RetriesKey                 = "retries_total"
+	PriorityDepthKey           = "priority_depth"
 )
 
 var (
@@ -86,8 +89,14 @@ var (
 		Help:      "Total number of retries handled by workqueue",
 	}, []string{"name"})
 
+	priorityDepth = k8smetrics.NewGaugeVec(&k8smetrics.GaugeOpts{
+		Subsystem: WorkQueueSubsystem,
+		Name:      PriorityDepthKey,
+		Help:      "Current depth of priority workqueue per priority",
+	}, []string{"name", "priority"})
+
 	metrics = []k8smetrics.Registerable{
-		depth, adds, latency, workDuration, unfinished, longestRunningProcessor, retries,
+		depth, adds, latency, workDuration, unfinished, longestRunningProcessor, retries, priorityDepth,
 	}
 )
 
@@ -128,3 +137,7 @@ func (prometheusMetricsProvider) NewLongestRunningProcessorSecondsMetric(name st
 func (prometheusMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
 	return retries.WithLabelValues(name)
 }
+
+func (prometheusMetricsProvider) NewPriorityDepthMetric(name string, priority int) workqueue.GaugeMetric {
+	return priorityDepth.WithLabelValues(name, strconv.Itoa(priority))
+}
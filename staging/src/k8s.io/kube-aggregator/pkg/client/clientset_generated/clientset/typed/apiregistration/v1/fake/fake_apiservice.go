@@ -110,7 +110,7 @@ func (c *FakeAPIServices) UpdateStatus(ctx context.Context, aPIService *apiregis
 // Delete takes name of the aPIService and deletes it. Returns an error if one occurs.
 func (c *FakeAPIServices) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewRootDeleteAction(apiservicesResource, name), &apiregistrationv1.APIService{})
+		Invokes(testing.NewRootDeleteActionWithOptions(apiservicesResource, name, opts), &apiregistrationv1.APIService{})
 	return err
 }
 
@@ -125,7 +125,7 @@ func (c *FakeAPIServices) DeleteCollection(ctx context.Context, opts v1.DeleteOp
 // Patch applies the patch and returns the patched aPIService.
 func (c *FakeAPIServices) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *apiregistrationv1.APIService, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewRootPatchSubresourceAction(apiservicesResource, name, pt, data, subresources...), &apiregistrationv1.APIService{})
+		Invokes(testing.NewRootPatchSubresourceActionWithOptions(apiservicesResource, name, pt, data, opts, subresources...), &apiregistrationv1.APIService{})
 	if obj == nil {
 		return nil, err
 	}
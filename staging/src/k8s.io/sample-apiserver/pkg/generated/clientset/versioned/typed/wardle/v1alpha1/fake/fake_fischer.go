@@ -99,7 +99,7 @@ func (c *FakeFischers) Update(ctx context.Context, fischer *v1alpha1.Fischer, op
 // Delete takes name of the fischer and deletes it. Returns an error if one occurs.
 func (c *FakeFischers) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
-		Invokes(testing.NewRootDeleteAction(fischersResource, name), &v1alpha1.Fischer{})
+		Invokes(testing.NewRootDeleteActionWithOptions(fischersResource, name, opts), &v1alpha1.Fischer{})
 	return err
 }
 
@@ -114,7 +114,7 @@ func (c *FakeFischers) DeleteCollection(ctx context.Context, opts v1.DeleteOptio
 // Patch applies the patch and returns the patched fischer.
 func (c *FakeFischers) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.Fischer, err error) {
 	obj, err := c.Fake.
-		Invokes(testing.NewRootPatchSubresourceAction(fischersResource, name, pt, data, subresources...), &v1alpha1.Fischer{})
+		Invokes(testing.NewRootPatchSubresourceActionWithOptions(fischersResource, name, pt, data, opts, subresources...), &v1alpha1.Fischer{})
 	if obj == nil {
 		return nil, err
 	}